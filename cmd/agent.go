@@ -0,0 +1,230 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"k8x/internal/config"
+	"k8x/internal/history"
+	"k8x/internal/llm"
+	"k8x/internal/llm/providers"
+	"k8x/internal/output"
+)
+
+// maxStepsPerGoal bounds the LLM round trips spent on one goal
+const maxStepsPerGoal = 20
+
+// agentObserver receives the events of the agent loop, so that the
+// console and the TUI can present the same investigation differently
+type agentObserver interface {
+	// StepStarted is called before each LLM round trip
+	StepStarted(step int)
+	// Thought delivers the LLM's response text and token usage
+	Thought(step int, content string, usage *llm.Usage)
+	// ToolCallStarted is called before a tool call runs
+	ToolCallStarted(step int, call llm.ToolCall)
+	// ToolCallFinished delivers the output of a tool call, or its error
+	ToolCallFinished(step int, call llm.ToolCall, result string, err error)
+	// Warning reports a problem that doesn't stop the loop
+	Warning(message string)
+	// StepLimitReached is called when a goal used up maxStepsPerGoal
+	StepLimitReached(limit int)
+}
+
+// runAgentGoal works on a goal in the ongoing conversation: it asks the LLM
+// for the next step and runs the tool calls it requests until the LLM says
+// **DONE** or maxStepsPerGoal is reached. Steps are recorded in the history
+// entry it returns. With cfg, the goal's message carries the context
+// sections gathered for the goal, such as the health snapshot filtered
+// against it.
+func runAgentGoal(goal string, provider *providers.UnifiedProvider, toolManager *llm.MCPToolManager, cfg *config.Config, historyManager *history.Manager, messages *[]llm.Message, stepCount *int, observer agentObserver) (*history.Entry, error) {
+	// Create history entry
+	entry := &history.Entry{
+		Goal:        goal,
+		Timestamp:   time.Now(),
+		Status:      "pending",
+		Steps:       []history.Step{},
+		Environment: sessionEnvironment(provider.Label(), toolManager.KubernetesConfig()),
+	}
+
+	// Save the session
+	if historyManager != nil {
+		if err := historyManager.Save(entry); err != nil {
+			observer.Warning(fmt.Sprintf("Failed to save history: %v", err))
+		}
+	}
+
+	// Add user message
+	userMessage := fmt.Sprintf(history.GoalPrefix+"%s\n\nPlease help me achieve this goal using read-only kubectl commands.", goal)
+	if *stepCount == 0 {
+		userMessage += " Start by suggesting and executing the first step."
+	} else {
+		userMessage += " Continue from where we left off."
+	}
+	if goalContext := gatherGoalContext(toolManager, cfg, goal); goalContext != "" {
+		userMessage += "\n\n" + goalContext
+	}
+	*messages = append(*messages, llm.Message{
+		Role:    "user",
+		Content: userMessage,
+	})
+
+	for stepsForThisGoal := 0; stepsForThisGoal < maxStepsPerGoal; stepsForThisGoal++ {
+		*stepCount++
+		step := *stepCount
+		observer.StepStarted(step)
+
+		// Get available tools, MCP servers may have reconnected or changed their tool lists
+		tools, err := toolManager.GetAllTools(context.Background())
+		if err != nil {
+			return entry, fmt.Errorf("failed to get available tools: %w", err)
+		}
+
+		// Get response from LLM
+		response, err := provider.ChatWithTools(context.Background(), *messages, tools)
+		if err != nil {
+			return entry, fmt.Errorf("failed to get LLM response: %w", err)
+		}
+		observer.Thought(step, response.Content, response.Usage)
+		model := provider.Label()
+
+		// Add to messages
+		assistantMsg := llm.Message{
+			Role:    "assistant",
+			Content: response.Content,
+		}
+
+		// Handle tool calls
+		if len(response.ToolCalls) > 0 {
+			assistantMsg.ToolCalls = response.ToolCalls
+			*messages = append(*messages, assistantMsg)
+
+			for _, toolCall := range response.ToolCalls {
+				observer.ToolCallStarted(step, toolCall)
+
+				result, err := toolManager.ExecuteTool(toolCall.Function.Name, toolCall.Function.Arguments)
+				observer.ToolCallFinished(step, toolCall, result, err)
+				if err != nil {
+					result = fmt.Sprintf("Error: %v", err)
+				}
+
+				// Add result to conversation
+				*messages = append(*messages, llm.Message{
+					Role:       "tool",
+					Content:    result,
+					ToolCallID: toolCall.ID,
+				})
+
+				// Save to history
+				if historyManager != nil {
+					step := history.Step{
+						Description: fmt.Sprintf("Executed: %s", toolCall.Function.Name),
+						Command:     toolCall.Function.Arguments,
+						Output:      result,
+						Type:        "command",
+						Model:       model,
+					}
+					if err := historyManager.AddStep(entry, step); err != nil {
+						observer.Warning(fmt.Sprintf("failed to add step to history: %v", err))
+					}
+				}
+			}
+		} else {
+			*messages = append(*messages, assistantMsg)
+
+			// Save planning step to history
+			if historyManager != nil {
+				step := history.Step{
+					Description: fmt.Sprintf("Planning Step %d", step),
+					Output:      response.Content,
+					Type:        "step",
+					Model:       model,
+				}
+				if err := historyManager.AddStep(entry, step); err != nil {
+					observer.Warning(fmt.Sprintf("failed to add step to history: %v", err))
+				}
+			}
+		}
+
+		// Check if done
+		if strings.Contains(strings.ToUpper(response.Content), "**DONE**") {
+			if historyManager != nil {
+				entry.Status = "completed"
+				if err := historyManager.UpdateEntry(entry); err != nil {
+					observer.Warning(fmt.Sprintf("failed to update history entry: %v", err))
+				}
+			}
+			return entry, nil
+		}
+	}
+
+	observer.StepLimitReached(maxStepsPerGoal)
+	return entry, nil
+}
+
+// describeToolCall summarizes a tool call's arguments for display, e.g.
+// "Command: kubectl get pods (context prod)"
+func describeToolCall(call llm.ToolCall) string {
+	var argsMap map[string]interface{}
+	if err := json.Unmarshal([]byte(call.Function.Arguments), &argsMap); err != nil {
+		return ""
+	}
+	if cmd, ok := argsMap["command"]; ok {
+		if kubeContext, ok := argsMap["context"]; ok {
+			return fmt.Sprintf("Command: %v (context %v)", cmd, kubeContext)
+		}
+		return fmt.Sprintf("Command: %v", cmd)
+	}
+	if uri, ok := argsMap["uri"]; ok {
+		return fmt.Sprintf("Resource: %v", uri)
+	}
+	if len(argsMap) > 0 {
+		return "Arguments: " + call.Function.Arguments
+	}
+	return ""
+}
+
+// printerObserver presents the agent loop as console output
+type printerObserver struct {
+	printer *output.Printer
+}
+
+func (o *printerObserver) StepStarted(step int) {
+	o.printer.PrintInfoln("\n📋 Step %d:", step)
+}
+
+func (o *printerObserver) Thought(step int, content string, usage *llm.Usage) {
+	o.printer.PrintAssistant("💭 ")
+	o.printer.PrintMarkdown(content)
+}
+
+func (o *printerObserver) ToolCallStarted(step int, call llm.ToolCall) {
+	o.printer.PrintInfoln("\n🔧 Executing: %s", call.Function.Name)
+	if description := describeToolCall(call); description != "" {
+		o.printer.PrintCommandln("📝 %s", description)
+	}
+}
+
+func (o *printerObserver) ToolCallFinished(step int, call llm.ToolCall, result string, err error) {
+	if err != nil {
+		result = fmt.Sprintf("Error: %v", err)
+		o.printer.PrintErrorln("❌ Failed: %v", err)
+	} else {
+		o.printer.PrintSuccessln("✅ Success")
+	}
+
+	// Filter secrets from output before printing
+	o.printer.Println("📄 Output:")
+	o.printer.PrintFolded(output.FilterSecrets(result))
+}
+
+func (o *printerObserver) Warning(message string) {
+	o.printer.PrintWarningln("⚠️  Warning: %s", message)
+}
+
+func (o *printerObserver) StepLimitReached(limit int) {
+	o.printer.PrintWarningln("⚠️  Reached maximum steps (%d) for this goal. You can continue with another request.", limit)
+}
@@ -1,13 +1,13 @@
 package cmd
 
 import (
-	"bufio"
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"sort"
 	"strings"
-	"time"
 
 	"k8x/internal/config"
 	k8xcontext "k8x/internal/context"
@@ -16,6 +16,7 @@ import (
 	"k8x/internal/llm/providers"
 	"k8x/internal/output"
 
+	"github.com/chzyer/readline"
 	"github.com/spf13/cobra"
 )
 
@@ -31,6 +32,9 @@ Special commands:
   /history      - Show command history
   /version      - Show version information
   /exit or /q   - Exit the console
+  /refresh      - Gather fresh cluster context
+  /context      - List or switch kube-contexts
+  /namespace    - List or switch namespaces
   /clear        - Clear the screen`,
 	RunE: runConsole,
 }
@@ -71,19 +75,10 @@ func runConsole(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	// Convert credentials
-	provCreds := providers.Credentials{
-		SelectedProvider: creds.SelectedProvider,
-	}
-	provCreds.OpenAI.APIKey = creds.OpenAI.APIKey
-	provCreds.Anthropic.APIKey = creds.Anthropic.APIKey
-	provCreds.Google.APIKey = creds.Google.APIKey
-	provCreds.Google.ApplicationCredentials = creds.Google.ApplicationCredentials
-
 	// Initialize LLM provider
-	unifiedProvider, err := providers.NewUnifiedProvider(provCreds)
+	unifiedProvider, err := newConfiguredProvider(providerCredentials(creds), cfg)
 	if err != nil {
-		return fmt.Errorf("failed to initialize LLM provider: %w", err)
+		return err
 	}
 
 	// Initialize tool manager
@@ -95,8 +90,8 @@ func runConsole(cmd *cobra.Command, args []string) error {
 	// Connect to MCP servers if enabled
 	if cfg.MCP.Enabled {
 		printer.PrintInfoln("🔌 Connecting to MCP servers...")
-		if err := toolManager.ConnectMCPServers(context.Background()); err != nil {
-			printer.PrintWarningln("⚠️  Warning: Failed to connect to some MCP servers: %v", err)
+		for _, err := range toolManager.ConnectMCPServers(context.Background()) {
+			printer.PrintWarningln("⚠️  Warning: %v", err)
 		}
 		defer func() {
 			if err := toolManager.DisconnectMCPServers(); err != nil {
@@ -105,11 +100,16 @@ func runConsole(cmd *cobra.Command, args []string) error {
 		}()
 	}
 
-	// Set Kubernetes configuration
+	// Set Kubernetes configuration and the approval mode
 	toolManager.SetKubernetesConfig(&cfg.Kubernetes)
+	toolManager.SetApprovalMode(cfg.Settings.ApprovalModeOrDefault())
+
+	if tuiMode {
+		return runTUI(unifiedProvider, toolManager, cfg)
+	}
 
 	// Print welcome message
-	printWelcome(unifiedProvider.Name(), printer)
+	printWelcome(unifiedProvider.Label(), printer)
 
 	// Start console loop
 	return runConsoleLoop(unifiedProvider, toolManager, cfg)
@@ -125,45 +125,75 @@ func printWelcome(providerName string, printer *output.Printer) {
 }
 
 func runConsoleLoop(provider *providers.UnifiedProvider, toolManager *llm.MCPToolManager, cfg *config.Config) error {
-	scanner := bufio.NewScanner(os.Stdin)
+	rl, err := newConsoleReadline(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to initialize line editor: %w", err)
+	}
+	defer func() {
+		_ = rl.Close()
+	}()
+	toolManager.SetApprover(llm.NewPromptApprover(readlineLineReader(rl)))
 	historyManager, _ := history.NewManager()
+	saved := newSavedConversation()
 
 	// Initialize colored printer with secret filtering enabled
 	printer := output.NewPrinter(true)
 
-	// Gather initial cluster context
-	printer.PrintInfoln("🔍 Gathering cluster information...")
-	contextInfo, err := k8xcontext.BuildContextInfoString(toolManager.ToolManager, []string{"~/.zsh_history", "~/.bash_history"})
-	if err != nil {
-		printer.PrintWarningln("⚠️  Warning: Failed to gather cluster context: %v", err)
-		contextInfo = "Cluster context information unavailable."
-	}
+	// Gather initial cluster context, reusing cached context if still valid
+	systemPrompt := buildSystemPrompt(gatherConsoleContext(toolManager, cfg, false, printer))
 
 	// Initialize conversation messages with system prompt
-	systemPrompt := buildSystemPrompt(contextInfo)
 	messages := []llm.Message{
 		{Role: "system", Content: systemPrompt},
 	}
 
 	stepCount := 0
 
+	// The history entry of the current goal, which manual commands are
+	// recorded in
+	var historyEntry *history.Entry
+
+	// Save a named conversation after every input, so that it survives
+	// restarts of the console
+	autosave := func() {
+		if err := saved.autosave(messages, stepCount, sessionEnvironment(provider.Label(), toolManager.KubernetesConfig())); err != nil {
+			printer.PrintWarningln("⚠️  Warning: failed to save conversation %s: %v", saved.name, err)
+		}
+	}
+
 	for {
 		fmt.Println()
-		printer.PrintPrompt()
-
-		if !scanner.Scan() {
+		line, err := readConsoleInput(rl, consolePrompt(cfg, printer))
+		if errors.Is(err, readline.ErrInterrupt) {
+			continue
+		}
+		if errors.Is(err, io.EOF) {
 			break
 		}
+		if err != nil {
+			return fmt.Errorf("failed to read input: %w", err)
+		}
 
-		input := strings.TrimSpace(scanner.Text())
+		input := strings.TrimSpace(line)
 
 		if input == "" {
 			continue
 		}
+		saveConsoleHistory(rl, input)
+
+		// Run commands the user typed with ! or /run without the LLM
+		if command, ok := manualCommand(input); ok {
+			historyEntry, err = runManualCommand(command, toolManager, historyManager, historyEntry, &messages, printer)
+			if err != nil {
+				printer.PrintErrorln("❌ Error: %v", err)
+			}
+			autosave()
+			continue
+		}
 
 		// Handle slash commands
 		if strings.HasPrefix(input, "/") {
-			handled, shouldExit, shouldClear := handleSlashCommand(input, provider, toolManager, cfg, &messages, &stepCount, contextInfo)
+			handled, shouldExit, shouldClear := handleSlashCommand(input, provider, toolManager, cfg, &messages, &stepCount, &systemPrompt, saved, printer)
 			if shouldExit {
 				printer.PrintInfoln("👋 Goodbye!")
 				return nil
@@ -174,9 +204,14 @@ func runConsoleLoop(provider *providers.UnifiedProvider, toolManager *llm.MCPToo
 					{Role: "system", Content: systemPrompt},
 				}
 				stepCount = 0
+				historyEntry = nil
 				printer.PrintSuccessln("🔄 Conversation history cleared. Starting fresh!")
+				if name := saved.detach(); name != "" {
+					printer.PrintInfoln("💾 %s stays saved, /load %s returns to it", name, name)
+				}
 			}
 			if handled && !shouldClear {
+				autosave()
 				continue
 			}
 			if !handled {
@@ -186,20 +221,68 @@ func runConsoleLoop(provider *providers.UnifiedProvider, toolManager *llm.MCPToo
 		}
 
 		// Handle natural language command
-		if err := executeGoalWithHistory(input, provider, toolManager, historyManager, &messages, &stepCount, false, printer); err != nil {
+		historyEntry, err = executeGoalWithHistory(input, provider, toolManager, cfg, historyManager, &messages, &stepCount, printer)
+		if err != nil {
 			printer.PrintErrorln("❌ Error: %v", err)
 		}
-	}
-
-	if err := scanner.Err(); err != nil {
-		return fmt.Errorf("scanner error: %w", err)
+		autosave()
 	}
 
 	printer.PrintInfoln("\n👋 Goodbye!")
 	return nil
 }
 
-func handleSlashCommand(input string, provider *providers.UnifiedProvider, toolManager *llm.MCPToolManager, cfg *config.Config, messages *[]llm.Message, stepCount *int, contextInfo string) (handled bool, shouldExit bool, shouldClear bool) {
+// gatherConsoleContext builds the cluster context for the system prompt,
+// reusing cached context unless refresh is set
+func gatherConsoleContext(toolManager *llm.MCPToolManager, cfg *config.Config, refresh bool, printer *output.Printer) string {
+	printer.PrintInfoln("🔍 Gathering cluster information...")
+	contextInfo, err := k8xcontext.BuildContextInfoString(toolManager.ToolManager, k8xcontext.Options{
+		Kubernetes:   &cfg.Kubernetes,
+		HistoryFiles: k8xcontext.DefaultHistoryFiles(),
+		Providers:    cfg.Context.Providers,
+		CacheTTL:     cfg.Context.CacheTTLOrDefault(),
+		Refresh:      refresh,
+		Quiet:        cfg.Context.Quiet,
+	})
+	if err != nil {
+		printer.PrintWarningln("⚠️  Warning: Failed to gather cluster context: %v", err)
+		return "Cluster context information unavailable." + multiClusterGuidance(&cfg.Kubernetes)
+	}
+	return contextInfo + multiClusterGuidance(&cfg.Kubernetes)
+}
+
+// gatherGoalContext gathers the context sections that depend on the goal,
+// fresh for each goal since the system prompt is built once per session.
+// It returns "" without cfg.
+func gatherGoalContext(toolManager *llm.MCPToolManager, cfg *config.Config, goal string) string {
+	if cfg == nil {
+		return ""
+	}
+	return k8xcontext.BuildGoalContextString(toolManager.ToolManager, k8xcontext.Options{
+		Kubernetes:   toolManager.KubernetesConfig(),
+		Goal:         goal,
+		HistoryFiles: k8xcontext.DefaultHistoryFiles(),
+		Providers:    cfg.Context.Providers,
+	})
+}
+
+// multiClusterGuidance tells the LLM how to work with the clusters of a
+// multi-cluster session, or returns "" for a single cluster
+func multiClusterGuidance(kubernetes *config.KubernetesConfig) string {
+	if !kubernetes.MultiCluster() {
+		return ""
+	}
+	contexts := kubernetes.SessionContexts()
+	return fmt.Sprintf(`
+Multi-cluster session: the kube-contexts %s are in scope (default: %s).
+- Choose the cluster of each call with the "context" argument of the tools instead of --context flags.
+- Compare clusters when relevant, e.g. run the same command against each of them.
+- Always say which cluster a finding comes from.
+- Before saying **DONE**, add a "Cross-cluster comparison" listing the relevant findings for each cluster, then what differs between the clusters and which of them show the problem.
+`, strings.Join(contexts, ", "), contexts[0])
+}
+
+func handleSlashCommand(input string, provider *providers.UnifiedProvider, toolManager *llm.MCPToolManager, cfg *config.Config, messages *[]llm.Message, stepCount *int, systemPrompt *string, saved *savedConversation, printer *output.Printer) (handled bool, shouldExit bool, shouldClear bool) {
 	parts := strings.Fields(input)
 	if len(parts) == 0 {
 		return true, false, false
@@ -235,40 +318,200 @@ func handleSlashCommand(input string, provider *providers.UnifiedProvider, toolM
 	case "/clear", "/cls":
 		clearScreen()
 		return true, false, true
+	case "/refresh":
+		// Re-probe the cluster and replace the context in the system prompt
+		*systemPrompt = buildSystemPrompt(gatherConsoleContext(toolManager, cfg, true, output.NewPrinter(true)))
+		if len(*messages) > 0 && (*messages)[0].Role == "system" {
+			(*messages)[0].Content = *systemPrompt
+		}
+		fmt.Println("✅ Cluster context refreshed")
+		return true, false, false
+	case "/context", "/ctx":
+		if err := handleContextCommand(parts[1:], toolManager, cfg, messages, systemPrompt); err != nil {
+			fmt.Printf("❌ Error: %v\n", err)
+		}
+		return true, false, false
+	case "/namespace", "/ns":
+		if err := handleNamespaceCommand(parts[1:], toolManager, cfg, messages, systemPrompt); err != nil {
+			fmt.Printf("❌ Error: %v\n", err)
+		}
+		return true, false, false
+	case "/provider", "/model":
+		creds, err := loadProviderCredentials()
+		if err == nil && command == "/provider" {
+			err = handleProviderCommand(parts[1:], provider, cfg, creds, os.Stdout)
+		} else if err == nil {
+			err = handleModelCommand(parts[1:], provider, cfg, creds, os.Stdout)
+		}
+		if err != nil {
+			fmt.Printf("❌ Error: %v\n", err)
+		}
+		return true, false, false
+	case "/more":
+		if !printer.PrintMore() {
+			fmt.Println("Nothing folded")
+		}
+		return true, false, false
+	case "/save":
+		if err := handleSaveCommand(parts[1:], saved, *messages, *stepCount, sessionEnvironment(provider.Label(), toolManager.KubernetesConfig()), os.Stdout); err != nil {
+			fmt.Printf("❌ Error: %v\n", err)
+		}
+		return true, false, false
+	case "/load":
+		if err := handleLoadCommand(parts[1:], saved, messages, stepCount, *systemPrompt, sessionEnvironment(provider.Label(), toolManager.KubernetesConfig()), os.Stdout); err != nil {
+			fmt.Printf("❌ Error: %v\n", err)
+		}
+		return true, false, false
+	case "/sessions":
+		if err := handleSessionsCommand(parts[1:], saved, os.Stdout); err != nil {
+			fmt.Printf("❌ Error: %v\n", err)
+		}
+		return true, false, false
+	case "/export":
+		if err := handleExportCommand(parts[1:], *messages, sessionEnvironment(provider.Label(), toolManager.KubernetesConfig()), os.Stdout); err != nil {
+			fmt.Printf("❌ Error: %v\n", err)
+		}
+		return true, false, false
+	case "/attach":
+		if err := handleAttachCommand(parts[1:], messages, os.Stdout); err != nil {
+			fmt.Printf("❌ Error: %v\n", err)
+		}
+		return true, false, false
+	case "/report":
+		if err := handleReportCommand(parts[1:], provider, *messages, sessionEnvironment(provider.Label(), toolManager.KubernetesConfig()), os.Stdout); err != nil {
+			fmt.Printf("❌ Error: %v\n", err)
+		}
+		return true, false, false
 	case "/confirm":
-		// Toggle confirmation mode
-		// We track this through the toolManager's SetConfirmationMode
-		fmt.Println("✅ Confirmation mode toggled")
+		if err := handleConfirmCommand(parts[1:], toolManager, cfg, os.Stdout); err != nil {
+			fmt.Printf("❌ Error: %v\n", err)
+		}
 		return true, false, false
 	case "/mcp":
+		// Manage MCP server configuration, e.g. /mcp add <name> <command>
+		if len(parts) > 1 {
+			if err := runMCPSubcommand(parts[1:]); err != nil {
+				fmt.Printf("❌ Error: %v\n", err)
+			} else if parts[1] != "list" {
+				fmt.Println("ℹ️  Restart the console to apply MCP configuration changes")
+			}
+			return true, false, false
+		}
+
 		// Show MCP server status
 		if !cfg.MCP.Enabled {
 			fmt.Println("MCP is disabled")
 		} else {
-			status := toolManager.GetMCPServerStatus()
+			states := toolManager.GetMCPServerStates()
+			names := make([]string, 0, len(states))
+			for name := range states {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+
 			fmt.Println("MCP Server Status:")
-			for name, connected := range status {
-				if connected {
+			for _, name := range names {
+				state := states[name]
+				switch {
+				case state.Connected:
 					fmt.Printf("  ✅ %s: connected\n", name)
-				} else {
+				case state.Reconnecting:
+					fmt.Printf("  🔄 %s: reconnecting (%v)\n", name, state.LastError)
+				case state.LastError != nil:
+					fmt.Printf("  ❌ %s: disconnected (%v)\n", name, state.LastError)
+				default:
 					fmt.Printf("  ❌ %s: disconnected\n", name)
 				}
 			}
 		}
 		return true, false, false
+	case "/tools":
+		handleToolsCommand(parts[1:], toolManager)
+		return true, false, false
+	case "/resources":
+		if err := handleResourcesCommand(parts[1:], toolManager, messages); err != nil {
+			fmt.Printf("❌ Error: %v\n", err)
+		}
+		return true, false, false
+	case "/prompt":
+		if err := handlePromptCommand(parts[1:], provider, toolManager, cfg, messages, stepCount); err != nil {
+			fmt.Printf("❌ Error: %v\n", err)
+		}
+		return true, false, false
 	default:
 		return false, false, false
 	}
 }
 
+// handleToolsCommand lists the tools exposed to the LLM, or restricts the
+// session to tools matching glob patterns. "/tools all" removes the restriction.
+func handleToolsCommand(args []string, toolManager *llm.MCPToolManager) {
+	// Synchronize the registry with the connected MCP servers first
+	if _, err := toolManager.GetAllTools(context.Background()); err != nil {
+		fmt.Printf("❌ Error: %v\n", err)
+		return
+	}
+
+	switch {
+	case len(args) == 1 && args[0] == "all":
+		toolManager.SetToolFilter(nil)
+	case len(args) > 0:
+		filter := llm.MatchToolNames(args...)
+		if len(toolManager.Registry().Tools(filter)) == 0 {
+			fmt.Printf("❌ No tools match %s\n", strings.Join(args, " "))
+			return
+		}
+		toolManager.SetToolFilter(filter)
+	}
+
+	tools, err := toolManager.GetAllTools(context.Background())
+	if err != nil {
+		fmt.Printf("❌ Error: %v\n", err)
+		return
+	}
+
+	origins := toolManager.Registry().Origins()
+	fmt.Printf("🔧 Available tools (%d):\n", len(tools))
+	for _, tool := range tools {
+		origin := origins[tool.Function.Name]
+		if origin.Server != "" {
+			fmt.Printf("  %s (MCP server %s, tool %s)\n", tool.Function.Name, origin.Server, origin.Name)
+		} else {
+			fmt.Printf("  %s (%s)\n", tool.Function.Name, origin.Source)
+		}
+	}
+}
+
 func printHelp() {
 	fmt.Println("\n📚 Available Commands:")
 	fmt.Println("  /help, /h       - Show this help message")
 	fmt.Println("  /configure, /f  - Configure k8x settings")
 	fmt.Println("  /history, /x    - Show command history")
 	fmt.Println("  /version, /v    - Show version information")
-	fmt.Println("  /confirm        - Toggle confirmation mode")
+	fmt.Println("  /save [name]    - Save the conversation under a name, then after every request")
+	fmt.Println("  /load <name>    - Switch to a saved conversation")
+	fmt.Println("  /sessions [delete <name>] - List or delete saved conversations")
+	fmt.Println("  /export <file> [--format md|html|json] - Export the session as a shareable transcript")
+	fmt.Println("  /report [file] [--format md|json] - Write a root cause analysis of the session")
+	fmt.Println("  !<command>, /run <command>    - Run a read-only command without the LLM and share its output")
+	fmt.Println("  /attach <file>...             - Add manifests, logs or values files as context")
+	fmt.Println("  /confirm [always|non-kubectl|never] - Show or set which tool calls need approval")
+	fmt.Println("  /confirm clear  - Forget the patterns allowed for this session")
+	fmt.Println("  /tools [pattern...|all]       - List tools or restrict the session to matching tools")
 	fmt.Println("  /mcp            - Show MCP server status")
+	fmt.Println("  /mcp list       - List configured MCP servers")
+	fmt.Println("  /mcp add <name> [flags] <command> [args...] - Add an MCP server")
+	fmt.Println("  /mcp remove <name>            - Remove an MCP server")
+	fmt.Println("  /mcp enable|disable [name]    - Toggle MCP integration or a server")
+	fmt.Println("  /mcp login|logout <name>      - Authorize with an OAuth MCP server")
+	fmt.Println("  /resources [<server> <uri>]   - List MCP resources or add one to the conversation")
+	fmt.Println("  /prompt [<server> <name> [key=value...]] - List or run MCP prompts")
+	fmt.Println("  /provider [name [model]]      - List LLM providers or switch to one, keeping the conversation")
+	fmt.Println("  /model [[provider/]name]      - List models of the LLM provider or switch to one")
+	fmt.Println("  /more           - Show the last folded tool output in full")
+	fmt.Println("  /refresh        - Gather fresh cluster context")
+	fmt.Println("  /context, /ctx [name]         - List kube-contexts or switch to one")
+	fmt.Println("  /namespace, /ns [name]        - List namespaces or switch to one")
 	fmt.Println("  /clear, /cls    - Clear the screen")
 	fmt.Println("  /exit, /q       - Exit the console")
 	fmt.Println("\nOr type any natural language command to interact with your cluster:")
@@ -307,13 +550,18 @@ Your role:
 3.c. You can use pipe '|' to chain commands for efficiency.
 3.d. You also have access to jq for JSON processing and can use it in commands.
 4. Always explain what each kubectl command will do before suggesting it
-5. Use the execute_shell_command function to run kubectl commands
+5. Prefer the typed Kubernetes tools for reading cluster state; use execute_shell_command for everything else
 6. Provide clear, actionable responses.
-7. Your responses will be printed to the console.
-Use colors and emojis to enhance readability.
-YOU MUST NOT USE MARKDOWN formatting in your responses.
+7. Your responses are rendered as Markdown in a terminal. Use short headings,
+lists, code blocks for commands and YAML, and tables for comparisons; avoid
+long paragraphs.
 
 Available tools:
+- get_resources: List resources of any kind with status, age and optional field columns
+- describe_resource: Show one resource as YAML with its events
+- get_logs: Get the last log lines of a pod's container
+- get_events: List events, optionally of one involved object
+- top: Show CPU and memory usage of pods or nodes
 - execute_shell_command: Execute safe read-only shell commands, primarily kubectl operations
 
 Current mode: READ-ONLY (no cluster modifications, no installations, no changes)
@@ -326,144 +574,10 @@ Guidelines:
 - If you achieve the goal or cannot proceed further, say "**DONE**."`, contextInfo)
 }
 
-func executeGoalWithHistory(goal string, provider *providers.UnifiedProvider, toolManager *llm.MCPToolManager, historyManager *history.Manager, messages *[]llm.Message, stepCount *int, confirm bool, printer *output.Printer) error {
-	// Create history entry
-	entry := &history.Entry{
-		Goal:      goal,
-		Timestamp: time.Now(),
-		Status:    "pending",
-		Steps:     []history.Step{},
-	}
-
-	// Save the session
-	if historyManager != nil {
-		if err := historyManager.Save(entry); err != nil {
-			printer.PrintWarningln("⚠️  Warning: Failed to save history: %v", err)
-		}
-	}
-
-	// Get available tools
-	tools, err := toolManager.GetAllTools(context.Background())
-	if err != nil {
-		return fmt.Errorf("failed to get available tools: %w", err)
-	}
-
-	// Add user message
-	userMessage := fmt.Sprintf("Goal: %s\n\nPlease help me achieve this goal using read-only kubectl commands.", goal)
-	if *stepCount == 0 {
-		userMessage += " Start by suggesting and executing the first step."
-	} else {
-		userMessage += " Continue from where we left off."
-	}
-	*messages = append(*messages, llm.Message{
-		Role:    "user",
-		Content: userMessage,
-	})
-
-	maxStepsPerGoal := 20
-	stepsForThisGoal := 0
-
-	for stepsForThisGoal < maxStepsPerGoal {
-		*stepCount++
-		stepsForThisGoal++
-		printer.PrintInfoln("\n📋 Step %d:", *stepCount)
-
-		// Get response from LLM
-		response, err := provider.ChatWithTools(context.Background(), *messages, tools)
-		if err != nil {
-			return fmt.Errorf("failed to get LLM response: %w", err)
-		}
-
-		printer.PrintAssistantln("💭 %s", response.Content)
-
-		// Add to messages
-		assistantMsg := llm.Message{
-			Role:    "assistant",
-			Content: response.Content,
-		}
-
-		// Handle tool calls
-		if len(response.ToolCalls) > 0 {
-			assistantMsg.ToolCalls = response.ToolCalls
-			*messages = append(*messages, assistantMsg)
-
-			for _, toolCall := range response.ToolCalls {
-				printer.PrintInfoln("\n🔧 Executing: %s", toolCall.Function.Name)
-
-				// Parse and display arguments
-				var argsMap map[string]interface{}
-				if err := json.Unmarshal([]byte(toolCall.Function.Arguments), &argsMap); err == nil {
-					if cmd, ok := argsMap["command"]; ok {
-						printer.PrintCommandln("📝 Command: %v", cmd)
-					}
-				}
-
-				// Execute tool
-				result, err := toolManager.ExecuteTool(toolCall.Function.Name, toolCall.Function.Arguments)
-				if err != nil {
-					result = fmt.Sprintf("Error: %v", err)
-					printer.PrintErrorln("❌ Failed: %v", err)
-				} else {
-					printer.PrintSuccessln("✅ Success")
-				}
-
-				// Filter secrets from output before printing
-				filteredResult := output.FilterSecrets(result)
-				printer.Println("📄 Output:\n%s", filteredResult)
-
-				// Add result to conversation
-				*messages = append(*messages, llm.Message{
-					Role:       "tool",
-					Content:    result,
-					ToolCallID: toolCall.ID,
-				})
-
-				// Save to history
-				if historyManager != nil {
-					step := history.Step{
-						Description: fmt.Sprintf("Executed: %s", toolCall.Function.Name),
-						Command:     toolCall.Function.Arguments,
-						Output:      result,
-						Type:        "command",
-					}
-					if err := historyManager.AddStep(entry, step); err != nil {
-						printer.PrintWarningln("Warning: failed to add step to history: %v", err)
-					}
-				}
-			}
-		} else {
-			*messages = append(*messages, assistantMsg)
-
-			// Save planning step to history
-			if historyManager != nil {
-				step := history.Step{
-					Description: fmt.Sprintf("Planning Step %d", *stepCount),
-					Output:      response.Content,
-					Type:        "step",
-				}
-				if err := historyManager.AddStep(entry, step); err != nil {
-					printer.PrintWarningln("Warning: failed to add step to history: %v", err)
-				}
-			}
-		}
-
-		// Check if done
-		if strings.Contains(strings.ToUpper(response.Content), "**DONE**") {
-			if historyManager != nil {
-				entry.Status = "completed"
-				if err := historyManager.UpdateEntry(entry); err != nil {
-					printer.PrintWarningln("Warning: failed to update history entry: %v", err)
-				}
-			}
-			return nil
-		}
-	}
-
-	if stepsForThisGoal >= maxStepsPerGoal {
-		printer.PrintWarningln("⚠️  Reached maximum steps (%d) for this goal. You can continue with another request.", maxStepsPerGoal)
-	}
-
-	return nil
+// executeGoalWithHistory works on a goal in the console, printing each step.
+// It returns the goal's history entry.
+func executeGoalWithHistory(goal string, provider *providers.UnifiedProvider, toolManager *llm.MCPToolManager, cfg *config.Config, historyManager *history.Manager, messages *[]llm.Message, stepCount *int, printer *output.Printer) (*history.Entry, error) {
+	return runAgentGoal(goal, provider, toolManager, cfg, historyManager, messages, stepCount, &printerObserver{printer: printer})
 }
 
 func init() {
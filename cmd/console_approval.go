@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"k8x/internal/config"
+	"k8x/internal/llm"
+
+	"github.com/chzyer/readline"
+)
+
+// handleConfirmCommand shows the approval mode and the patterns allowed for
+// the session, switches and persists the mode, or forgets the patterns.
+// Messages are written to w.
+func handleConfirmCommand(args []string, toolManager *llm.MCPToolManager, cfg *config.Config, w io.Writer) error {
+	if len(args) > 1 {
+		return fmt.Errorf("usage: /confirm [always|non-kubectl|never|clear]")
+	}
+
+	if len(args) == 0 {
+		fmt.Fprintf(w, "🔐 Approval mode: %s\n", toolManager.ApprovalMode())
+		patterns := toolManager.AllowedPatterns()
+		if len(patterns) == 0 {
+			fmt.Fprintln(w, "No patterns allowed for this session")
+			return nil
+		}
+		fmt.Fprintln(w, "Allowed for this session:")
+		for _, pattern := range patterns {
+			fmt.Fprintf(w, "  %s\n", pattern)
+		}
+		return nil
+	}
+
+	if args[0] == "clear" {
+		toolManager.ClearAllowedPatterns()
+		fmt.Fprintln(w, "✅ Session patterns cleared")
+		return nil
+	}
+
+	mode, err := config.ParseApprovalMode(args[0])
+	if err != nil {
+		return err
+	}
+	toolManager.SetApprovalMode(mode)
+	cfg.Settings.ApprovalMode = mode
+	if err := config.SetConfigValue(string(mode), "settings", "approval_mode"); err != nil {
+		return fmt.Errorf("approval mode set to %s for this session, but failed to save it: %w", mode, err)
+	}
+	fmt.Fprintf(w, "✅ Approval mode set to %s\n", mode)
+	return nil
+}
+
+// readlineLineReader reads approval answers through the console's line
+// editor, which owns the terminal while the console runs
+func readlineLineReader(rl *readline.Instance) llm.LineReader {
+	return func(prompt, initial string) (string, error) {
+		rl.SetPrompt(prompt)
+		return rl.ReadlineWithDefault(initial)
+	}
+}
@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"k8x/internal/attach"
+	"k8x/internal/kube"
+	"k8x/internal/llm"
+)
+
+// handleAttachCommand adds files to the conversation as context for the
+// next goals, e.g. a manifest to compare with the cluster or a log file
+func handleAttachCommand(args []string, messages *[]llm.Message, w io.Writer) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: /attach <file>...")
+	}
+
+	// Attach all files or none
+	attachments := make([]*attach.Attachment, 0, len(args))
+	for _, path := range args {
+		attachment, err := attach.Load(kube.ExpandHome(path))
+		if err != nil {
+			return err
+		}
+		attachments = append(attachments, attachment)
+	}
+
+	for _, attachment := range attachments {
+		*messages = append(*messages, llm.Message{Role: "user", Content: attachment.Message()})
+		printAttachment(attachment, w)
+	}
+	return nil
+}
+
+// printAttachment tells the user what was attached
+func printAttachment(attachment *attach.Attachment, w io.Writer) {
+	fmt.Fprintf(w, "📎 Attached %s\n", attachment.Summary())
+	for _, resource := range attachment.Resources {
+		fmt.Fprintf(w, "   - %s\n", resource)
+	}
+}
+
+// attachablePaths completes the file path being typed after /attach
+func attachablePaths(line string) []string {
+	fields := strings.Fields(line)
+	partial := ""
+	if len(fields) > 1 && !strings.HasSuffix(line, " ") {
+		partial = fields[len(fields)-1]
+	}
+
+	dir := filepath.Dir(partial)
+	if strings.HasSuffix(partial, "/") {
+		dir = partial
+	}
+	entries, err := os.ReadDir(kube.ExpandHome(dir))
+	if err != nil {
+		return nil
+	}
+
+	var paths []string
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), ".") && !strings.HasPrefix(filepath.Base(partial), ".") {
+			continue
+		}
+		path := entry.Name()
+		if dir != "." || strings.HasPrefix(partial, "./") {
+			path = strings.TrimSuffix(dir, "/") + "/" + entry.Name()
+		}
+		if entry.IsDir() {
+			path += "/"
+		}
+		paths = append(paths, path)
+	}
+	return paths
+}
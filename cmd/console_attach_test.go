@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"k8x/internal/llm"
+)
+
+func TestHandleAttachCommand(t *testing.T) {
+	dir := t.TempDir()
+	manifest := filepath.Join(dir, "deploy.yaml")
+	if err := os.WriteFile(manifest, []byte("apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: api\nspec:\n  replicas: 2\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	notes := filepath.Join(dir, "notes.txt")
+	if err := os.WriteFile(notes, []byte("broke after the release\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	messages := []llm.Message{{Role: "system", Content: "You are k8x"}}
+	var out strings.Builder
+	if err := handleAttachCommand([]string{manifest, filepath.Join(dir, "missing.yaml")}, &messages, &out); err == nil {
+		t.Error("/attach of a missing file succeeded")
+	}
+	if len(messages) != 1 {
+		t.Fatalf("a failed /attach added %d messages", len(messages)-1)
+	}
+
+	if err := handleAttachCommand([]string{manifest, notes}, &messages, &out); err != nil {
+		t.Fatalf("/attach failed: %v", err)
+	}
+	if len(messages) != 3 || !strings.Contains(messages[1].Content, "Deployment api: replicas 2") {
+		t.Errorf("messages = %+v, want the two attachments", messages[1:])
+	}
+	if !strings.Contains(out.String(), "Kubernetes manifest") || !strings.Contains(out.String(), "notes.txt: text") {
+		t.Errorf("/attach output = %q", out.String())
+	}
+}
+
+func TestAttachablePaths(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"deploy.yaml", ".hidden"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(dir, "charts"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	got := attachablePaths("/attach " + dir + "/")
+	want := []string{dir + "/charts/", dir + "/deploy.yaml"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("attachablePaths() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,180 @@
+package cmd
+
+import (
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"k8x/internal/config"
+	k8xcontext "k8x/internal/context"
+	"k8x/internal/output"
+
+	"github.com/chzyer/readline"
+)
+
+const (
+	// consoleHistoryLimit is how many entries the input history keeps
+	consoleHistoryLimit = 1000
+	// multiLineDelimiter starts and ends a block of pasted lines
+	multiLineDelimiter = `"""`
+	// continuationPrompt is shown while an entry spans several lines
+	continuationPrompt = "... "
+	// completionTTL is how long completion reuses what it read from disk,
+	// so that it doesn't read on every tab
+	completionTTL = 5 * time.Second
+)
+
+// commonKinds are completed for natural language input before cluster
+// context has been cached
+var commonKinds = []string{
+	"configmaps", "cronjobs", "daemonsets", "deployments", "events",
+	"ingresses", "jobs", "namespaces", "nodes", "persistentvolumeclaims",
+	"persistentvolumes", "pods", "replicasets", "secrets", "services",
+	"statefulsets",
+}
+
+// lineSource reads lines of console input, like readline.Instance
+type lineSource interface {
+	SetPrompt(prompt string)
+	Readline() (string, error)
+}
+
+// readConsoleInput reads one console entry. A line ending in a backslash
+// continues on the next line, and """ starts a block of pasted lines, such
+// as YAML or logs, that ends at the next line of """. Text before the
+// opening """ is kept as the entry's first line.
+func readConsoleInput(rl lineSource, prompt string) (string, error) {
+	rl.SetPrompt(prompt)
+	line, err := rl.Readline()
+	if err != nil {
+		return "", err
+	}
+
+	var lines []string
+	if trimmed := strings.TrimSpace(line); strings.HasSuffix(trimmed, multiLineDelimiter) {
+		if first := strings.TrimSpace(strings.TrimSuffix(trimmed, multiLineDelimiter)); first != "" {
+			lines = append(lines, first)
+		}
+		rl.SetPrompt(continuationPrompt)
+		for {
+			line, err := rl.Readline()
+			if err != nil {
+				return "", err
+			}
+			if strings.TrimSpace(line) == multiLineDelimiter {
+				return strings.Join(lines, "\n"), nil
+			}
+			lines = append(lines, line)
+		}
+	}
+
+	for strings.HasSuffix(line, `\`) {
+		lines = append(lines, strings.TrimSuffix(line, `\`))
+		rl.SetPrompt(continuationPrompt)
+		if line, err = rl.Readline(); err != nil {
+			return "", err
+		}
+	}
+	return strings.Join(append(lines, line), "\n"), nil
+}
+
+// newConsoleReadline creates the console's line editor, with input history
+// persisted in ~/.k8x
+func newConsoleReadline(cfg *config.Config) (*readline.Instance, error) {
+	rlConfig := &readline.Config{
+		AutoComplete:    newConsoleCompleter(cfg),
+		InterruptPrompt: "^C",
+		EOFPrompt:       "/exit",
+		HistoryLimit:    consoleHistoryLimit,
+		// Entries are saved by saveConsoleHistory, redacted and joined
+		DisableAutoSaveHistory: true,
+	}
+
+	if historyPath, err := config.GetConsoleHistoryPath(); err == nil && config.EnsureConfigDir() == nil {
+		// The history holds questions about the cluster, so keep it private
+		if file, err := os.OpenFile(historyPath, os.O_CREATE|os.O_WRONLY, 0600); err == nil {
+			_ = file.Close()
+			rlConfig.HistoryFile = historyPath
+		}
+	}
+	return readline.NewEx(rlConfig)
+}
+
+// saveConsoleHistory adds an entry to the input history with credentials
+// redacted. Multi-line entries are saved as one line.
+func saveConsoleHistory(rl *readline.Instance, input string) {
+	_ = rl.SaveHistory(output.RedactCommand(strings.Join(strings.Fields(input), " ")))
+}
+
+// consoleCompleter completes slash commands and their arguments, and
+// resource kinds and pod names within natural language input
+type consoleCompleter struct {
+	slash  *readline.PrefixCompleter
+	cfg    *config.Config
+	cached cachedWords
+}
+
+// cachedWords keeps words read for completion for completionTTL
+type cachedWords struct {
+	mu     sync.Mutex
+	key    string
+	words  []string
+	readAt time.Time
+}
+
+// get returns the words read for key less than completionTTL ago, or reads
+// them again
+func (c *cachedWords) get(key string, read func() []string) []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.readAt.IsZero() && c.key == key && time.Since(c.readAt) < completionTTL {
+		return c.words
+	}
+	c.key, c.words, c.readAt = key, read(), time.Now()
+	return c.words
+}
+
+// Do implements readline.AutoCompleter
+func (c *consoleCompleter) Do(line []rune, pos int) ([][]rune, int) {
+	if strings.HasPrefix(strings.TrimLeft(string(line), " "), "/") {
+		return c.slash.Do(line, pos)
+	}
+
+	// Complete the word before the cursor
+	start := pos
+	for start > 0 && line[start-1] != ' ' {
+		start--
+	}
+	word := string(line[start:pos])
+	if word == "" {
+		return nil, 0
+	}
+
+	var candidates [][]rune
+	for _, name := range c.words() {
+		if strings.HasPrefix(name, word) && name != word {
+			candidates = append(candidates, []rune(name[len(word):]+" "))
+		}
+	}
+	return candidates, len([]rune(word))
+}
+
+// words returns the resource kinds and pod names cached with the cluster
+// context of the active kube-context and namespace
+func (c *consoleCompleter) words() []string {
+	kube := c.cfg.Kubernetes
+	return c.cached.get(kube.Context+"/"+kube.Namespace, func() []string {
+		completions := k8xcontext.CachedCompletions(&kube)
+		if completions == nil {
+			return commonKinds
+		}
+
+		kinds := completions.Kinds
+		if len(kinds) == 0 {
+			kinds = commonKinds
+		}
+		return append(append([]string(nil), kinds...), completions.Pods...)
+	})
+}
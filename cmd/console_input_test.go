@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"k8x/internal/config"
+)
+
+// scriptedLines is a lineSource returning prepared lines
+type scriptedLines struct {
+	lines   []string
+	prompts []string
+}
+
+func (s *scriptedLines) SetPrompt(prompt string) {
+	s.prompts = append(s.prompts, prompt)
+}
+
+func (s *scriptedLines) Readline() (string, error) {
+	if len(s.lines) == 0 {
+		return "", io.EOF
+	}
+	line := s.lines[0]
+	s.lines = s.lines[1:]
+	return line, nil
+}
+
+func TestReadConsoleInput(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines []string
+		want  string
+		rest  int
+	}{
+		{"single line", []string{"why is api failing?", "next"}, "why is api failing?", 1},
+		{"continuation", []string{`check pods \`, `in shop`}, "check pods \nin shop", 0},
+		{"pasted block", []string{`"""`, "apiVersion: v1", "  kind: Pod", `"""`, "next"}, "apiVersion: v1\n  kind: Pod", 1},
+		{"block after text", []string{`what is wrong with this? """`, "error: boom", `  """  `}, "what is wrong with this?\nerror: boom", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := &scriptedLines{lines: tt.lines}
+			got, err := readConsoleInput(source, "> ")
+			if err != nil {
+				t.Fatalf("readConsoleInput() failed: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("readConsoleInput() = %q, want %q", got, tt.want)
+			}
+			if len(source.lines) != tt.rest {
+				t.Errorf("%d lines left unread, want %d", len(source.lines), tt.rest)
+			}
+		})
+	}
+
+	if _, err := readConsoleInput(&scriptedLines{lines: []string{`"""`, "unterminated"}}, "> "); err != io.EOF {
+		t.Errorf("readConsoleInput() of an unterminated block error = %v, want EOF", err)
+	}
+}
+
+func TestConsoleCompleterWords(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	completer := newConsoleCompleter(&config.Config{Kubernetes: config.KubernetesConfig{Context: "kind-dev"}})
+
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{"why are deploy", []string{"ments "}},
+		{"list persistentvolume", []string{"claims ", "s "}},
+		{"why ", nil},
+		{"pods", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			candidates, _ := completer.Do([]rune(tt.line), len(tt.line))
+			var got []string
+			for _, candidate := range candidates {
+				got = append(got, string(candidate))
+			}
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("completions of %q = %q, want %q", tt.line, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCachedWords(t *testing.T) {
+	var cached cachedWords
+	reads := 0
+	read := func() []string {
+		reads++
+		return []string{"pods"}
+	}
+
+	for i := 0; i < 3; i++ {
+		cached.get("kind-dev/shop", read)
+	}
+	if reads != 1 {
+		t.Errorf("read %d times within completionTTL, want 1", reads)
+	}
+
+	cached.get("kind-dev/other", read)
+	if reads != 2 {
+		t.Errorf("read %d times after the namespace changed, want 2", reads)
+	}
+
+	cached.readAt = time.Now().Add(-completionTTL)
+	cached.get("kind-dev/other", read)
+	if reads != 3 {
+		t.Errorf("read %d times after completionTTL, want 3", reads)
+	}
+}
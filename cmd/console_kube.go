@@ -0,0 +1,234 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"k8x/internal/config"
+	k8xcontext "k8x/internal/context"
+	"k8x/internal/llm"
+	"k8x/internal/llm/providers"
+	"k8x/internal/output"
+
+	"github.com/chzyer/readline"
+)
+
+// namespaceListTimeout bounds listing namespaces for /namespace and completion
+const namespaceListTimeout = 3 * time.Second
+
+// handleContextCommand lists the kubeconfig's contexts, or switches the
+// session to one of them
+func handleContextCommand(args []string, toolManager *llm.MCPToolManager, cfg *config.Config, messages *[]llm.Message, systemPrompt *string) error {
+	contexts, err := k8xcontext.KubeContexts(&cfg.Kubernetes)
+	if err != nil {
+		return err
+	}
+	active := k8xcontext.CurrentKubeContext(&cfg.Kubernetes)
+
+	if len(args) == 0 {
+		if len(contexts) == 0 {
+			fmt.Println("No kube-contexts found in the kubeconfig")
+			return nil
+		}
+		fmt.Println("Kube-contexts:")
+		printMarkedList(contexts, active)
+		fmt.Println("\nUse /context <name> to switch")
+		return nil
+	}
+	if len(args) != 1 {
+		return fmt.Errorf("usage: /context [name]")
+	}
+
+	name := args[0]
+	if !slices.Contains(contexts, name) {
+		return fmt.Errorf("kube-context %q not found in the kubeconfig", name)
+	}
+	if name == active {
+		fmt.Printf("ℹ️  Already using kube-context %s\n", name)
+		return nil
+	}
+
+	cfg.Kubernetes.Context = name
+	applyKubernetesChange(toolManager, cfg, messages, systemPrompt)
+	fmt.Printf("✅ Switched to kube-context %s\n", name)
+	return nil
+}
+
+// handleNamespaceCommand lists the cluster's namespaces, or switches the
+// session to one of them
+func handleNamespaceCommand(args []string, toolManager *llm.MCPToolManager, cfg *config.Config, messages *[]llm.Message, systemPrompt *string) error {
+	active := k8xcontext.CurrentNamespace(&cfg.Kubernetes)
+	namespaces, listErr := listNamespaces(&cfg.Kubernetes)
+
+	if len(args) == 0 {
+		if listErr != nil {
+			return fmt.Errorf("failed to list namespaces: %w", listErr)
+		}
+		fmt.Println("Namespaces:")
+		printMarkedList(namespaces, active)
+		fmt.Println("\nUse /namespace <name> to switch")
+		return nil
+	}
+	if len(args) != 1 {
+		return fmt.Errorf("usage: /namespace [name]")
+	}
+
+	name := args[0]
+	switch {
+	case listErr != nil:
+		fmt.Printf("⚠️  Warning: could not verify namespace %s: %v\n", name, listErr)
+	case !slices.Contains(namespaces, name):
+		return fmt.Errorf("namespace %q not found in kube-context %s", name, k8xcontext.CurrentKubeContext(&cfg.Kubernetes))
+	}
+	if name == active {
+		fmt.Printf("ℹ️  Already using namespace %s\n", name)
+		return nil
+	}
+
+	cfg.Kubernetes.Namespace = name
+	applyKubernetesChange(toolManager, cfg, messages, systemPrompt)
+	fmt.Printf("✅ Switched to namespace %s\n", name)
+	return nil
+}
+
+// applyKubernetesChange points the shell executor at the session's new
+// kube-context or namespace, refreshes the cluster context in the system
+// prompt and tells the LLM about the switch
+func applyKubernetesChange(toolManager *llm.MCPToolManager, cfg *config.Config, messages *[]llm.Message, systemPrompt *string) {
+	toolManager.SetKubernetesConfig(&cfg.Kubernetes)
+
+	*systemPrompt = buildSystemPrompt(gatherConsoleContext(toolManager, cfg, false, output.NewPrinter(true)))
+	if len(*messages) > 0 && (*messages)[0].Role == "system" {
+		(*messages)[0].Content = *systemPrompt
+	}
+
+	*messages = append(*messages, llm.Message{
+		Role: "user",
+		Content: fmt.Sprintf("Note: I switched to kube-context %s, namespace %s. Commands now run there; "+
+			"earlier outputs in this conversation may describe another cluster or namespace.",
+			k8xcontext.CurrentKubeContext(&cfg.Kubernetes), k8xcontext.CurrentNamespace(&cfg.Kubernetes)),
+	})
+}
+
+// printMarkedList prints names, marking the active one with an asterisk
+func printMarkedList(names []string, active string) {
+	for _, name := range names {
+		if name == active {
+			fmt.Printf("  * %s\n", name)
+		} else {
+			fmt.Printf("    %s\n", name)
+		}
+	}
+}
+
+// listNamespaces lists the namespaces of the session's kube-context
+func listNamespaces(kubeConfig *config.KubernetesConfig) ([]string, error) {
+	collector, err := k8xcontext.NewKubeCollector(kubeConfig)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), namespaceListTimeout)
+	defer cancel()
+	return collector.Namespaces(ctx)
+}
+
+// consolePrompt returns the prompt showing the active kube-context,
+// namespace and approval mode
+func consolePrompt(cfg *config.Config, printer *output.Printer) string {
+	return printer.Prompt(k8xcontext.CurrentKubeContext(&cfg.Kubernetes), k8xcontext.CurrentNamespace(&cfg.Kubernetes), string(cfg.Settings.ApprovalModeOrDefault()))
+}
+
+// namespaceCompletions caches namespace names per kube-context, so that
+// completion doesn't query the API server on every tab
+type namespaceCompletions struct {
+	mu         sync.Mutex
+	cfg        *config.Config
+	namespaces map[string][]string
+}
+
+// complete returns the namespaces of the active kube-context
+func (c *namespaceCompletions) complete(string) []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	kubeContext := k8xcontext.CurrentKubeContext(&c.cfg.Kubernetes)
+	if names, ok := c.namespaces[kubeContext]; ok {
+		return names
+	}
+	names, err := listNamespaces(&c.cfg.Kubernetes)
+	if err != nil {
+		return nil
+	}
+	c.namespaces[kubeContext] = names
+	return names
+}
+
+// newConsoleCompleter completes slash commands, kube-contexts from the
+// kubeconfig for /context, namespaces for /namespace, LLM providers and
+// models for /provider and /model, and resource kinds
+// and pod names in natural language input
+func newConsoleCompleter(cfg *config.Config) *consoleCompleter {
+	kubeContexts := func(string) []string {
+		names, _ := k8xcontext.KubeContexts(&cfg.Kubernetes)
+		return names
+	}
+	namespaces := &namespaceCompletions{cfg: cfg, namespaces: make(map[string][]string)}
+	conversations := &cachedWords{}
+	conversationNames := func(prefix string) []string {
+		return conversations.get("", func() []string { return savedConversationNames(prefix) })
+	}
+	var providerNames []readline.PrefixCompleterInterface
+	var llmModels []readline.PrefixCompleterInterface
+	for _, name := range providers.ProviderNames {
+		var models []readline.PrefixCompleterInterface
+		for _, model := range providerModels(cfg, name) {
+			models = append(models, readline.PcItem(model))
+			llmModels = append(llmModels, readline.PcItem(name+"/"+model))
+		}
+		providerNames = append(providerNames, readline.PcItem(name, models...))
+	}
+
+	slash := readline.NewPrefixCompleter(
+		readline.PcItem("/help"),
+		readline.PcItem("/configure"),
+		readline.PcItem("/history"),
+		readline.PcItem("/version"),
+		readline.PcItem("/confirm",
+			readline.PcItem("always"),
+			readline.PcItem("non-kubectl"),
+			readline.PcItem("never"),
+			readline.PcItem("clear"),
+		),
+		readline.PcItem("/tools"),
+		readline.PcItem("/mcp",
+			readline.PcItem("list"),
+			readline.PcItem("add"),
+			readline.PcItem("remove"),
+			readline.PcItem("enable"),
+			readline.PcItem("disable"),
+			readline.PcItem("login"),
+			readline.PcItem("logout"),
+		),
+		readline.PcItem("/resources"),
+		readline.PcItem("/prompt"),
+		readline.PcItem("/context", readline.PcItemDynamic(kubeContexts)),
+		readline.PcItem("/namespace", readline.PcItemDynamic(namespaces.complete)),
+		readline.PcItem("/provider", providerNames...),
+		readline.PcItem("/model", llmModels...),
+		readline.PcItem("/more"),
+		readline.PcItem("/export"),
+		readline.PcItem("/report"),
+		readline.PcItem("/attach", readline.PcItemDynamic(attachablePaths)),
+		readline.PcItem("/run", readline.PcItem("kubectl")),
+		readline.PcItem("/save"),
+		readline.PcItem("/load", readline.PcItemDynamic(conversationNames)),
+		readline.PcItem("/sessions", readline.PcItem("delete", readline.PcItemDynamic(conversationNames))),
+		readline.PcItem("/refresh"),
+		readline.PcItem("/clear"),
+		readline.PcItem("/exit"),
+	)
+	return &consoleCompleter{slash: slash, cfg: cfg}
+}
@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"k8x/internal/config"
+)
+
+func TestConsoleCompleter(t *testing.T) {
+	kubeconfig := filepath.Join(t.TempDir(), "config")
+	content := `apiVersion: v1
+kind: Config
+current-context: staging
+contexts:
+- name: staging
+  context: {cluster: c}
+- name: stage-eu
+  context: {cluster: c}
+- name: prod
+  context: {cluster: c}
+`
+	if err := os.WriteFile(kubeconfig, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	completer := newConsoleCompleter(&config.Config{Kubernetes: config.KubernetesConfig{KubeConfigPath: kubeconfig}})
+
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{"/con", []string{"figure ", "firm ", "text "}},
+		{"/context st", []string{"age-eu ", "aging "}},
+		{"/context p", []string{"rod "}},
+		{"/context x", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			candidates, _ := completer.Do([]rune(tt.line), len(tt.line))
+			var got []string
+			for _, candidate := range candidates {
+				got = append(got, string(candidate))
+			}
+			sort.Strings(got)
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("completions of %q = %q, want %q", tt.line, got, tt.want)
+			}
+		})
+	}
+}
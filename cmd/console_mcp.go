@@ -0,0 +1,166 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8x/internal/config"
+	"k8x/internal/history"
+	"k8x/internal/llm"
+	"k8x/internal/llm/providers"
+	"k8x/internal/output"
+)
+
+// handleResourcesCommand lists MCP resources, or with a server and URI reads
+// the resource and adds it to the conversation as context
+func handleResourcesCommand(args []string, toolManager *llm.MCPToolManager, messages *[]llm.Message) error {
+	ctx := context.Background()
+
+	if len(args) == 0 {
+		resources := toolManager.ListMCPResources(ctx)
+		if len(resources) == 0 {
+			fmt.Println("No MCP resources available")
+			return nil
+		}
+
+		fmt.Println("MCP Resources:")
+		for _, server := range sortedKeys(resources) {
+			fmt.Printf("  %s:\n", server)
+			for _, resource := range resources[server] {
+				fmt.Printf("    📄 %s", resource.URI)
+				if resource.Name != "" {
+					fmt.Printf(" (%s)", resource.Name)
+				}
+				if resource.Description != "" {
+					fmt.Printf(" - %s", resource.Description)
+				}
+				fmt.Println()
+			}
+		}
+		fmt.Println("\nUse /resources <server> <uri> to add a resource to the conversation")
+		return nil
+	}
+
+	if len(args) != 2 {
+		return fmt.Errorf("usage: /resources [<server> <uri>]")
+	}
+
+	server, uri := args[0], args[1]
+	content, err := toolManager.ReadMCPResource(ctx, server, uri)
+	if err != nil {
+		return err
+	}
+
+	*messages = append(*messages, llm.Message{
+		Role:    "user",
+		Content: fmt.Sprintf("Context from MCP resource %s (server %s):\n\n%s", uri, server, content),
+	})
+	fmt.Printf("✅ Added resource %s to the conversation (%d characters)\n", uri, len(content))
+	return nil
+}
+
+// handlePromptCommand lists MCP prompts, or renders a prompt and adds it to
+// the conversation. When the prompt ends with a user message, that message
+// is run as the next goal.
+func handlePromptCommand(args []string, provider *providers.UnifiedProvider, toolManager *llm.MCPToolManager, cfg *config.Config, messages *[]llm.Message, stepCount *int) error {
+	ctx := context.Background()
+
+	if len(args) == 0 {
+		prompts := toolManager.ListMCPPrompts(ctx)
+		if len(prompts) == 0 {
+			fmt.Println("No MCP prompts available")
+			return nil
+		}
+
+		fmt.Println("MCP Prompts:")
+		for _, server := range sortedKeys(prompts) {
+			fmt.Printf("  %s:\n", server)
+			for _, prompt := range prompts[server] {
+				fmt.Printf("    💬 %s", prompt.Name)
+				for _, arg := range prompt.Arguments {
+					if arg.Required {
+						fmt.Printf(" %s=<value>", arg.Name)
+					} else {
+						fmt.Printf(" [%s=<value>]", arg.Name)
+					}
+				}
+				if prompt.Description != "" {
+					fmt.Printf(" - %s", prompt.Description)
+				}
+				fmt.Println()
+			}
+		}
+		fmt.Println("\nUse /prompt <server> <name> [key=value...] to run a prompt")
+		return nil
+	}
+
+	if len(args) < 2 {
+		return fmt.Errorf("usage: /prompt [<server> <name> [key=value...]]")
+	}
+
+	promptArgs, err := parsePromptArgs(args[2:])
+	if err != nil {
+		return err
+	}
+
+	prompt, err := toolManager.GetMCPPrompt(ctx, args[0], args[1], promptArgs)
+	if err != nil {
+		return err
+	}
+	if len(prompt.Messages) == 0 {
+		return fmt.Errorf("prompt '%s' returned no messages", args[1])
+	}
+
+	promptMessages := prompt.Messages
+	var goal string
+	if last := promptMessages[len(promptMessages)-1]; last.Role == "user" {
+		goal = last.Text
+		promptMessages = promptMessages[:len(promptMessages)-1]
+	}
+
+	for _, message := range promptMessages {
+		*messages = append(*messages, llm.Message{
+			Role:    message.Role,
+			Content: message.Text,
+		})
+	}
+
+	if goal == "" {
+		fmt.Printf("✅ Added prompt '%s' to the conversation (%d messages)\n", args[1], len(promptMessages))
+		return nil
+	}
+
+	fmt.Printf("💬 Running prompt '%s'\n", args[1])
+	historyManager, _ := history.NewManager()
+	_, err = executeGoalWithHistory(goal, provider, toolManager, cfg, historyManager, messages, stepCount, output.NewPrinter(true))
+	return err
+}
+
+// parsePromptArgs parses key=value prompt arguments
+func parsePromptArgs(args []string) (map[string]string, error) {
+	if len(args) == 0 {
+		return nil, nil
+	}
+
+	parsed := make(map[string]string, len(args))
+	for _, arg := range args {
+		key, value, ok := strings.Cut(arg, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid prompt argument %q, expected key=value", arg)
+		}
+		parsed[key] = value
+	}
+	return parsed, nil
+}
+
+// sortedKeys returns the keys of a map in sorted order
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
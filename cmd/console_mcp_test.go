@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParsePromptArgs(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			name: "no arguments",
+			args: nil,
+			want: nil,
+		},
+		{
+			name: "key value pairs",
+			args: []string{"namespace=prod", "pod=api-0"},
+			want: map[string]string{"namespace": "prod", "pod": "api-0"},
+		},
+		{
+			name: "value containing equals sign",
+			args: []string{"selector=app=api"},
+			want: map[string]string{"selector": "app=api"},
+		},
+		{
+			name:    "missing equals sign",
+			args:    []string{"namespace"},
+			wantErr: true,
+		},
+		{
+			name:    "empty key",
+			args:    []string{"=prod"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parsePromptArgs(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parsePromptArgs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parsePromptArgs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
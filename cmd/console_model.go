@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"k8x/internal/config"
+	"k8x/internal/llm/providers"
+	"k8x/internal/schemas"
+)
+
+// loadProviderCredentials loads the API credentials of the LLM providers
+func loadProviderCredentials() (providers.Credentials, error) {
+	creds, err := config.LoadCredentials()
+	if err != nil {
+		return providers.Credentials{}, fmt.Errorf("failed to load credentials: %w", err)
+	}
+	return providerCredentials(creds), nil
+}
+
+// providerCredentials converts the credentials file's contents to the
+// credentials of the LLM providers
+func providerCredentials(creds *schemas.Credentials) providers.Credentials {
+	provCreds := providers.Credentials{
+		SelectedProvider: creds.SelectedProvider,
+	}
+	provCreds.OpenAI.APIKey = creds.OpenAI.APIKey
+	provCreds.Anthropic.APIKey = creds.Anthropic.APIKey
+	provCreds.Google.APIKey = creds.Google.APIKey
+	provCreds.Google.ApplicationCredentials = creds.Google.ApplicationCredentials
+	return provCreds
+}
+
+// newConfiguredProvider creates the LLM provider selected in creds, with
+// the model set for it in the config file
+func newConfiguredProvider(creds providers.Credentials, cfg *config.Config) (*providers.UnifiedProvider, error) {
+	provider, err := providers.NewUnifiedProviderWithModel(creds, configuredModel(cfg, creds.SelectedProvider))
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize LLM provider: %w", err)
+	}
+	return provider, nil
+}
+
+// configuredModel returns the model set for a provider in the config file
+func configuredModel(cfg *config.Config, name string) string {
+	if cfg == nil {
+		return ""
+	}
+	return cfg.LLM.Providers[name].Model
+}
+
+// providerModels lists the models suggested for a provider, with the one
+// set in the config file first
+func providerModels(cfg *config.Config, name string) []string {
+	models := providers.SuggestedModels[name]
+	if model := configuredModel(cfg, name); model != "" && !slices.Contains(models, model) {
+		models = append([]string{model}, models...)
+	}
+	return models
+}
+
+// handleProviderCommand lists the LLM providers, or switches to one with
+// "<name> [model]". Without a model, the one set in the config file or the
+// provider's default is used.
+func handleProviderCommand(args []string, provider *providers.UnifiedProvider, cfg *config.Config, creds providers.Credentials, w io.Writer) error {
+	switch len(args) {
+	case 0:
+		fmt.Fprintln(w, "🤖 LLM providers:")
+		for _, name := range providers.ProviderNames {
+			marker, status := " ", "not configured"
+			switch {
+			case name == provider.Name():
+				marker, status = "*", provider.Model()
+			case creds.IsProviderConfigured(name):
+				status = configuredModel(cfg, name)
+				if status == "" {
+					status = providers.SuggestedModels[name][0]
+				}
+			}
+			fmt.Fprintf(w, "  %s %-10s %s\n", marker, name, status)
+		}
+		fmt.Fprintln(w, "Switch with /provider <name> [model], list models with /model")
+		return nil
+	case 1, 2:
+		name := strings.ToLower(args[0])
+		model := configuredModel(cfg, name)
+		if len(args) == 2 {
+			model = args[1]
+		}
+		return switchLLM(provider, creds, name, model, w)
+	default:
+		return fmt.Errorf("usage: /provider [name [model]]")
+	}
+}
+
+// handleModelCommand lists the models of the active LLM provider, or
+// switches to one. "<provider>/<model>" switches the provider as well.
+func handleModelCommand(args []string, provider *providers.UnifiedProvider, cfg *config.Config, creds providers.Credentials, w io.Writer) error {
+	switch len(args) {
+	case 0:
+		fmt.Fprintf(w, "🤖 Models of %s:\n", provider.Name())
+		models := providerModels(cfg, provider.Name())
+		if !slices.Contains(models, provider.Model()) {
+			models = append([]string{provider.Model()}, models...)
+		}
+		for _, model := range models {
+			marker := " "
+			if model == provider.Model() {
+				marker = "*"
+			}
+			fmt.Fprintf(w, "  %s %s\n", marker, model)
+		}
+		fmt.Fprintf(w, "Switch with /model <name>, which may be any %s model\n", provider.Name())
+		return nil
+	case 1:
+		name, model := provider.Name(), args[0]
+		if prefix, rest, ok := strings.Cut(model, "/"); ok && slices.Contains(providers.ProviderNames, strings.ToLower(prefix)) {
+			name, model = strings.ToLower(prefix), rest
+		}
+		if model == "" {
+			return fmt.Errorf("usage: /model [[provider/]name]")
+		}
+		return switchLLM(provider, creds, name, model, w)
+	default:
+		return fmt.Errorf("usage: /model [[provider/]name]")
+	}
+}
+
+// switchLLM switches provider to another provider or model. The
+// conversation carries over; its tool calls are re-encoded for the new
+// provider when it's next asked.
+func switchLLM(provider *providers.UnifiedProvider, creds providers.Credentials, name, model string, w io.Writer) error {
+	previous := provider.Label()
+	if err := provider.Switch(creds, name, model); err != nil {
+		return err
+	}
+	if provider.Label() == previous {
+		fmt.Fprintf(w, "🤖 Already using %s\n", previous)
+		return nil
+	}
+	fmt.Fprintf(w, "🤖 Switched from %s to %s, the conversation continues\n", previous, provider.Label())
+	return nil
+}
@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"k8x/internal/config"
+	"k8x/internal/llm/providers"
+)
+
+func TestHandleProviderAndModelCommands(t *testing.T) {
+	for _, name := range []string{"OPENAI_API_KEY", "GEMINI_API_KEY", "GOOGLE_APPLICATION_CREDENTIALS"} {
+		t.Setenv(name, "")
+	}
+	creds := providers.Credentials{SelectedProvider: "openai"}
+	creds.OpenAI.APIKey = "sk-test"
+	creds.Anthropic.APIKey = "sk-ant-test"
+	cfg := &config.Config{LLM: config.LLMConfig{Providers: map[string]config.ProviderConfig{
+		"anthropic": {Model: "claude-opus-4-0"},
+	}}}
+
+	provider, err := providers.NewUnifiedProvider(creds)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out strings.Builder
+	if err := handleProviderCommand(nil, provider, cfg, creds, &out); err != nil {
+		t.Fatalf("/provider failed: %v", err)
+	}
+	for _, want := range []string{"* openai     o3-mini", "  anthropic  claude-opus-4-0", "  google     not configured"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("/provider output = %q, want %q in it", out.String(), want)
+		}
+	}
+
+	tests := []struct {
+		command string
+		args    []string
+		want    string
+		wantErr bool
+	}{
+		{"/model", []string{"gpt-4.1"}, "openai/gpt-4.1", false},
+		{"/provider", []string{"anthropic"}, "anthropic/claude-opus-4-0", false},
+		{"/model", []string{"claude-3-5-haiku-latest"}, "anthropic/claude-3-5-haiku-latest", false},
+		{"/model", []string{"openai/o4-mini"}, "openai/o4-mini", false},
+		{"/provider", []string{"anthropic", "claude-sonnet-4-0"}, "anthropic/claude-sonnet-4-0", false},
+		{"/provider", []string{"google"}, "anthropic/claude-sonnet-4-0", true},
+		{"/provider", []string{"mistral"}, "anthropic/claude-sonnet-4-0", true},
+		{"/model", []string{"openai/"}, "anthropic/claude-sonnet-4-0", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.command+" "+strings.Join(tt.args, " "), func(t *testing.T) {
+			handle := handleModelCommand
+			if tt.command == "/provider" {
+				handle = handleProviderCommand
+			}
+			err := handle(tt.args, provider, cfg, creds, &out)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("%s error = %v, wantErr %v", tt.command, err, tt.wantErr)
+			}
+			if got := provider.Label(); got != tt.want {
+				t.Errorf("%s switched to %s, want %s", tt.command, got, tt.want)
+			}
+		})
+	}
+
+	out.Reset()
+	if err := handleModelCommand(nil, provider, cfg, creds, &out); err != nil {
+		t.Fatalf("/model failed: %v", err)
+	}
+	for _, want := range []string{"Models of anthropic", "  claude-opus-4-0", "* claude-sonnet-4-0"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("/model output = %q, want %q in it", out.String(), want)
+		}
+	}
+}
+
+func TestNewConfiguredProvider(t *testing.T) {
+	creds := providers.Credentials{SelectedProvider: "anthropic"}
+	creds.Anthropic.APIKey = "sk-ant-test"
+	cfg := &config.Config{LLM: config.LLMConfig{Providers: map[string]config.ProviderConfig{
+		"anthropic": {Model: "claude-opus-4-0"},
+	}}}
+
+	provider, err := newConfiguredProvider(creds, cfg)
+	if err != nil {
+		t.Fatalf("newConfiguredProvider() failed: %v", err)
+	}
+	if got := provider.Label(); got != "anthropic/claude-opus-4-0" {
+		t.Errorf("newConfiguredProvider() uses %s, want the configured model", got)
+	}
+
+	if provider, err = newConfiguredProvider(creds, nil); err != nil {
+		t.Fatalf("newConfiguredProvider() without a config failed: %v", err)
+	}
+	if got := provider.Model(); got != providers.SuggestedModels["anthropic"][0] {
+		t.Errorf("newConfiguredProvider() without a config uses %s, want the default model", got)
+	}
+}
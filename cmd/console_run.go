@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"k8x/internal/history"
+	"k8x/internal/llm"
+	"k8x/internal/output"
+)
+
+// manualStepDescription describes the commands the user ran in history
+// and transcripts
+const manualStepDescription = "Ran manually by the user"
+
+// manualCommand returns the command of "!<command>" or "/run <command>"
+// input, and whether the input is such a command
+func manualCommand(input string) (string, bool) {
+	if command, ok := strings.CutPrefix(input, "!"); ok {
+		return strings.TrimSpace(command), true
+	}
+	fields := strings.Fields(input)
+	if len(fields) > 0 && strings.ToLower(fields[0]) == "/run" {
+		return strings.TrimSpace(strings.TrimPrefix(input, fields[0])), true
+	}
+	return "", false
+}
+
+// runManualCommand runs a command the user typed with ! or /run, without
+// the LLM. It goes through the shell executor's safety checks, but not
+// through approval, since the user asked for it. The output is added to the
+// conversation as evidence and recorded as a manual step in entry, the
+// history entry of the current goal. If there is none yet, an entry of its
+// own is created and returned.
+func runManualCommand(command string, toolManager *llm.MCPToolManager, historyManager *history.Manager, entry *history.Entry, messages *[]llm.Message, printer *output.Printer) (*history.Entry, error) {
+	if command == "" {
+		return entry, fmt.Errorf("usage: !<command> or /run <command>")
+	}
+
+	printer.PrintCommandln("📝 Command: %s", command)
+	result, err := toolManager.ExecuteShellCommand(command)
+	if err != nil && result == "" {
+		// Rejected by the safety checks, or failed without output
+		return entry, err
+	}
+	if err != nil {
+		printer.PrintErrorln("❌ Failed: %v", err)
+		result = strings.TrimRight(result, "\n") + fmt.Sprintf("\n(%v)", err)
+	}
+	printer.Println("📄 Output:")
+	printer.PrintFolded(output.FilterSecrets(result))
+
+	*messages = append(*messages, llm.Message{
+		Role:    "user",
+		Content: history.ManualCommandMessage(command, result),
+	})
+
+	if historyManager == nil {
+		return entry, nil
+	}
+	if entry == nil {
+		entry = &history.Entry{
+			Goal:        "Manual commands",
+			Timestamp:   time.Now(),
+			Status:      "completed",
+			Environment: sessionEnvironment("", toolManager.KubernetesConfig()),
+		}
+	}
+	step := history.Step{
+		Description: manualStepDescription,
+		Command:     command,
+		Output:      result,
+		Type:        "manual",
+	}
+	if err := historyManager.AddStep(entry, step); err != nil {
+		printer.PrintWarningln("⚠️  Warning: failed to add step to history: %v", err)
+	}
+	return entry, nil
+}
@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"testing"
+
+	"k8x/internal/config"
+	"k8x/internal/history"
+	"k8x/internal/llm"
+	"k8x/internal/output"
+)
+
+func TestManualCommand(t *testing.T) {
+	tests := []struct {
+		input       string
+		wantCommand string
+		wantOK      bool
+	}{
+		{"!kubectl get pods -n foo", "kubectl get pods -n foo", true},
+		{"! kubectl get pods", "kubectl get pods", true},
+		{"/run kubectl get nodes", "kubectl get nodes", true},
+		{"/RUN  echo hi", "echo hi", true},
+		{"/run", "", true},
+		{"/runner", "", false},
+		{"why is api failing?", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			command, ok := manualCommand(tt.input)
+			if command != tt.wantCommand || ok != tt.wantOK {
+				t.Errorf("manualCommand(%q) = %q, %v, want %q, %v", tt.input, command, ok, tt.wantCommand, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestRunManualCommand(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	toolManager, err := llm.NewMCPToolManager(".", &config.Config{})
+	if err != nil {
+		t.Fatalf("NewMCPToolManager() failed: %v", err)
+	}
+	historyManager, err := history.NewManager()
+	if err != nil {
+		t.Fatalf("NewManager() failed: %v", err)
+	}
+	printer := output.NewPrinter(true)
+	messages := []llm.Message{{Role: "system", Content: "You are k8x"}}
+
+	if _, err := runManualCommand("rm -rf /tmp/x", toolManager, historyManager, nil, &messages, printer); err == nil {
+		t.Error("runManualCommand() ran a command the safety checks reject")
+	}
+	if len(messages) != 1 {
+		t.Errorf("a rejected command was added to the conversation")
+	}
+
+	entry, err := runManualCommand("echo api-1 CrashLoopBackOff", toolManager, historyManager, nil, &messages, printer)
+	if err != nil {
+		t.Fatalf("runManualCommand() failed: %v", err)
+	}
+	if entry == nil || len(entry.Steps) != 1 || entry.Steps[0].Type != "manual" || entry.Steps[0].Command != "echo api-1 CrashLoopBackOff" {
+		t.Fatalf("history entry = %+v, want one manual step", entry)
+	}
+
+	same, err := runManualCommand("echo again", toolManager, historyManager, entry, &messages, printer)
+	if err != nil || same != entry || len(entry.Steps) != 2 {
+		t.Errorf("the second command wasn't recorded in the same entry: %v", err)
+	}
+
+	transcript := transcriptFromMessages(messages, nil)
+	if len(transcript.Steps) != 2 || transcript.Steps[0].Command != "echo api-1 CrashLoopBackOff" || transcript.Steps[0].Output != "api-1 CrashLoopBackOff" {
+		t.Errorf("transcript steps = %+v, want the manual commands", transcript.Steps)
+	}
+}
@@ -0,0 +1,224 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"k8x/internal/history"
+	"k8x/internal/llm"
+)
+
+// savedConversation tracks the name the console conversation is saved
+// under. Once named, the conversation is saved again after every input.
+type savedConversation struct {
+	store   *history.ConversationStore
+	name    string
+	created time.Time
+}
+
+// newSavedConversation opens the conversation store. The console still
+// runs without one, only /save, /load and /sessions fail.
+func newSavedConversation() *savedConversation {
+	store, _ := history.NewConversationStore()
+	return &savedConversation{store: store}
+}
+
+// storeOrError returns the conversation store, or why there is none
+func (s *savedConversation) storeOrError() (*history.ConversationStore, error) {
+	if s.store == nil {
+		return nil, fmt.Errorf("conversations can't be saved: the ~/.k8x/conversations directory is unavailable")
+	}
+	return s.store, nil
+}
+
+// save writes the conversation under its name
+func (s *savedConversation) save(messages []llm.Message, stepCount int, environment map[string]string) error {
+	store, err := s.storeOrError()
+	if err != nil {
+		return err
+	}
+	conversation := &history.Conversation{
+		Name:        s.name,
+		Created:     s.created,
+		StepCount:   stepCount,
+		Environment: environment,
+		Messages:    messages,
+	}
+	if err := store.Save(conversation); err != nil {
+		return err
+	}
+	s.created = conversation.Created
+	return nil
+}
+
+// autosave saves a named conversation, doing nothing for unnamed ones
+func (s *savedConversation) autosave(messages []llm.Message, stepCount int, environment map[string]string) error {
+	if s.name == "" {
+		return nil
+	}
+	return s.save(messages, stepCount, environment)
+}
+
+// detach stops saving the conversation under its name, e.g. on /clear.
+// It returns the name, empty if the conversation wasn't named.
+func (s *savedConversation) detach() string {
+	name := s.name
+	s.name, s.created = "", time.Time{}
+	return name
+}
+
+// hasGoals reports whether a conversation contains any goal
+func hasGoals(messages []llm.Message) bool {
+	conversation := history.Conversation{Messages: messages}
+	return len(conversation.Goals()) > 0
+}
+
+// handleSaveCommand names the conversation and saves it. Without a name,
+// the conversation is saved again under its current name.
+func handleSaveCommand(args []string, saved *savedConversation, messages []llm.Message, stepCount int, environment map[string]string, w io.Writer) error {
+	if len(args) > 1 {
+		return fmt.Errorf("usage: /save [name]")
+	}
+	store, err := saved.storeOrError()
+	if err != nil {
+		return err
+	}
+
+	name := saved.name
+	if len(args) == 1 {
+		name = args[0]
+	}
+	if name == "" {
+		return fmt.Errorf("usage: /save <name>")
+	}
+	if err := history.ValidateConversationName(name); err != nil {
+		return err
+	}
+
+	// Don't overwrite another conversation by accident
+	if name != saved.name {
+		if _, err := store.Load(name); err == nil {
+			return fmt.Errorf("a conversation named %s already exists, /load it or choose another name", name)
+		}
+		saved.name, saved.created = name, time.Time{}
+	}
+
+	if err := saved.save(messages, stepCount, environment); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "💾 Conversation saved as %s, it's saved again after every request\n", name)
+	return nil
+}
+
+// handleLoadCommand switches to a saved conversation. Its system prompt is
+// replaced with systemPrompt, so that it has fresh cluster context.
+func handleLoadCommand(args []string, saved *savedConversation, messages *[]llm.Message, stepCount *int, systemPrompt string, environment map[string]string, w io.Writer) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: /load <name>")
+	}
+	store, err := saved.storeOrError()
+	if err != nil {
+		return err
+	}
+	if args[0] == saved.name {
+		return fmt.Errorf("%s is the current conversation", args[0])
+	}
+
+	conversation, err := store.Load(args[0])
+	if errors.Is(err, history.ErrConversationNotFound) {
+		return fmt.Errorf("no conversation named %s, /sessions lists them", args[0])
+	}
+	if err != nil {
+		return err
+	}
+
+	// Keep the conversation being left
+	if saved.name != "" {
+		if err := saved.save(*messages, *stepCount, environment); err != nil {
+			return fmt.Errorf("failed to save %s before switching: %w", saved.name, err)
+		}
+	} else if hasGoals(*messages) {
+		return fmt.Errorf("the current conversation isn't saved, /save <name> or /clear it first")
+	}
+
+	loaded := conversation.Messages
+	if len(loaded) > 0 && loaded[0].Role == "system" {
+		loaded[0].Content = systemPrompt
+	} else {
+		loaded = append([]llm.Message{{Role: "system", Content: systemPrompt}}, loaded...)
+	}
+	*messages = loaded
+	*stepCount = conversation.StepCount
+	saved.name, saved.created = conversation.Name, conversation.Created
+
+	goals := conversation.Goals()
+	fmt.Fprintf(w, "📂 Loaded %s: %d requests, last saved %s\n", conversation.Name, len(goals), conversation.Updated.Format("2006-01-02 15:04"))
+	if len(goals) > 0 {
+		fmt.Fprintf(w, "   Last request: %s\n", goals[len(goals)-1])
+	}
+	if was, now := conversation.Environment["context"], environment["context"]; was != "" && now != "" && was != now {
+		fmt.Fprintf(w, "⚠️  The conversation was saved in context %s, the console is now in %s\n", was, now)
+	}
+	return nil
+}
+
+// handleSessionsCommand lists the saved conversations, or deletes one
+func handleSessionsCommand(args []string, saved *savedConversation, w io.Writer) error {
+	store, err := saved.storeOrError()
+	if err != nil {
+		return err
+	}
+
+	if len(args) > 0 {
+		if args[0] != "delete" || len(args) != 2 {
+			return fmt.Errorf("usage: /sessions [delete <name>]")
+		}
+		if err := store.Delete(args[1]); err != nil {
+			return err
+		}
+		if args[1] == saved.name {
+			saved.detach()
+		}
+		fmt.Fprintf(w, "🗑️  Deleted conversation %s\n", args[1])
+		return nil
+	}
+
+	conversations, err := store.List()
+	if err != nil {
+		return err
+	}
+	if len(conversations) == 0 {
+		fmt.Fprintln(w, "No saved conversations, /save <name> saves this one")
+		return nil
+	}
+
+	fmt.Fprintln(w, "Saved conversations:")
+	for _, conversation := range conversations {
+		marker := " "
+		if conversation.Name == saved.name {
+			marker = "*"
+		}
+		goals := conversation.Goals()
+		var first string
+		if len(goals) > 0 {
+			first = truncate(goals[0], 60)
+		}
+		fmt.Fprintf(w, "%s %-20s %s  %3d requests  %s\n", marker, conversation.Name, conversation.Updated.Format("2006-01-02 15:04"), len(goals), first)
+	}
+	return nil
+}
+
+// savedConversationNames completes the names of saved conversations
+func savedConversationNames(string) []string {
+	store, err := history.NewConversationStore()
+	if err != nil {
+		return nil
+	}
+	names, err := store.Names()
+	if err != nil {
+		return nil
+	}
+	return names
+}
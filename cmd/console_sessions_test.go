@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"k8x/internal/history"
+	"k8x/internal/llm"
+)
+
+func TestConsoleConversations(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	saved := newSavedConversation()
+	var out strings.Builder
+
+	messages := []llm.Message{
+		{Role: "system", Content: "old prompt"},
+		{Role: "user", Content: history.GoalPrefix + "why is api failing?\n\nPlease help me."},
+		{Role: "assistant", Content: "It's crash looping. **DONE**"},
+	}
+	stepCount := 2
+	environment := map[string]string{"context": "prod"}
+
+	if err := handleSaveCommand(nil, saved, messages, stepCount, environment, &out); err == nil {
+		t.Error("/save without a name succeeded for an unnamed conversation")
+	}
+	if err := handleSaveCommand([]string{"incident"}, saved, messages, stepCount, environment, &out); err != nil {
+		t.Fatalf("/save failed: %v", err)
+	}
+
+	// Continue the conversation, then start a fresh one
+	messages = append(messages, llm.Message{Role: "user", Content: history.GoalPrefix + "and the worker?"})
+	stepCount = 3
+	if err := saved.autosave(messages, stepCount, environment); err != nil {
+		t.Fatalf("autosave failed: %v", err)
+	}
+	if name := saved.detach(); name != "incident" {
+		t.Errorf("detach() = %q, want incident", name)
+	}
+
+	fresh := []llm.Message{{Role: "system", Content: "new prompt"}, {Role: "user", Content: history.GoalPrefix + "unrelated"}}
+	freshSteps := 1
+	if err := handleSaveCommand([]string{"incident"}, saved, fresh, freshSteps, environment, &out); err == nil {
+		t.Error("/save overwrote another conversation")
+	}
+	if err := handleLoadCommand([]string{"incident"}, saved, &fresh, &freshSteps, "new prompt", environment, &out); err == nil {
+		t.Error("/load discarded an unsaved conversation")
+	}
+
+	// Switch from an empty conversation to the saved one
+	empty := []llm.Message{{Role: "system", Content: "new prompt"}}
+	emptySteps := 0
+	if err := handleLoadCommand([]string{"incident"}, saved, &empty, &emptySteps, "new prompt", map[string]string{"context": "staging"}, &out); err != nil {
+		t.Fatalf("/load failed: %v", err)
+	}
+	if len(empty) != 4 || empty[0].Content != "new prompt" || emptySteps != 3 || saved.name != "incident" {
+		t.Errorf("/load restored %d messages, system prompt %q, %d steps, name %q", len(empty), empty[0].Content, emptySteps, saved.name)
+	}
+	if !strings.Contains(out.String(), "saved in context prod") {
+		t.Errorf("/load didn't warn about the context change: %q", out.String())
+	}
+
+	out.Reset()
+	if err := handleSessionsCommand(nil, saved, &out); err != nil {
+		t.Fatalf("/sessions failed: %v", err)
+	}
+	if !strings.Contains(out.String(), "* incident") || !strings.Contains(out.String(), "2 requests") {
+		t.Errorf("/sessions = %q, want the active conversation marked", out.String())
+	}
+
+	if err := handleSessionsCommand([]string{"delete", "incident"}, saved, &out); err != nil {
+		t.Fatalf("/sessions delete failed: %v", err)
+	}
+	if saved.name != "" {
+		t.Error("deleting the active conversation didn't detach it")
+	}
+}
@@ -0,0 +1,215 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"k8x/internal/config"
+	k8xcontext "k8x/internal/context"
+	"k8x/internal/history"
+	"k8x/internal/llm"
+
+	"github.com/spf13/cobra"
+)
+
+// sessionEnvironment describes where a session runs, for history entries
+// and exported transcripts. llmLabel names the provider and model, e.g.
+// "openai/o3-mini".
+func sessionEnvironment(llmLabel string, kubernetes *config.KubernetesConfig) map[string]string {
+	environment := map[string]string{"k8x version": version}
+	if llmLabel != "" {
+		environment["llm provider"] = llmLabel
+	}
+	if kubernetes == nil {
+		return environment
+	}
+
+	if kubernetes.MultiCluster() {
+		environment["contexts"] = strings.Join(kubernetes.SessionContexts(), ", ")
+	} else if kubeContext := k8xcontext.CurrentKubeContext(kubernetes); kubeContext != "" {
+		environment["context"] = kubeContext
+	}
+	if namespace := k8xcontext.CurrentNamespace(kubernetes); namespace != "" {
+		environment["namespace"] = namespace
+	}
+	return environment
+}
+
+// transcriptFromMessages prepares a console conversation for sharing. Each
+// tool call and manual command is a step, and the last answer saying
+// **DONE** the summary.
+func transcriptFromMessages(messages []llm.Message, environment map[string]string) *history.Transcript {
+	transcript := &history.Transcript{Date: time.Now(), Environment: environment}
+
+	// Tool results are matched to their calls' steps by call ID
+	steps := make(map[string]int)
+	for _, message := range messages {
+		switch message.Role {
+		case "user":
+			if goal, ok := strings.CutPrefix(message.Content, history.GoalPrefix); ok {
+				goal, _, _ = strings.Cut(goal, "\n")
+				transcript.AddGoal(goal)
+			} else if command, output, ok := history.ParseManualCommand(message.Content); ok {
+				transcript.AddStep(history.TranscriptStep{Explanation: manualStepDescription, Command: command, Output: output}, "")
+			}
+		case "assistant":
+			if len(message.ToolCalls) == 0 {
+				if strings.Contains(strings.ToUpper(message.Content), "**DONE**") {
+					transcript.SetSummary(message.Content)
+				} else {
+					transcript.AddStep(history.TranscriptStep{Explanation: message.Content}, "")
+				}
+				continue
+			}
+			for i, call := range message.ToolCalls {
+				step := history.TranscriptStep{Tool: call.Function.Name}
+				if i == 0 {
+					step.Explanation = message.Content
+				}
+				transcript.AddStep(step, call.Function.Arguments)
+				steps[call.ID] = len(transcript.Steps) - 1
+			}
+		case "tool":
+			if i, ok := steps[message.ToolCallID]; ok {
+				transcript.SetOutput(i, message.Content)
+			}
+		}
+	}
+	return transcript
+}
+
+// parseExportArgs parses "<file> [--format md|html|json]". Without
+// --format, the format follows the file's extension.
+func parseExportArgs(args []string) (string, history.ExportFormat, error) {
+	var path, formatName string
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "--format" || args[i] == "-f":
+			if i+1 == len(args) {
+				return "", "", fmt.Errorf("--format needs a value: md, html or json")
+			}
+			i++
+			formatName = args[i]
+		case strings.HasPrefix(args[i], "--format="):
+			formatName = strings.TrimPrefix(args[i], "--format=")
+		case path == "":
+			path = args[i]
+		default:
+			return "", "", fmt.Errorf("usage: /export <file> [--format md|html|json]")
+		}
+	}
+	if path == "" {
+		return "", "", fmt.Errorf("usage: /export <file> [--format md|html|json]")
+	}
+
+	if formatName == "" {
+		return path, history.ExportFormatForPath(path), nil
+	}
+	format, err := history.ParseExportFormat(formatName)
+	return path, format, err
+}
+
+// handleExportCommand writes the console conversation to a file as a
+// shareable transcript. Messages are written to w.
+func handleExportCommand(args []string, messages []llm.Message, environment map[string]string, w io.Writer) error {
+	path, format, err := parseExportArgs(args)
+	if err != nil {
+		return err
+	}
+
+	transcript := transcriptFromMessages(messages, environment)
+	if len(transcript.Goals) == 0 {
+		return fmt.Errorf("nothing to export yet, ask a question first")
+	}
+	data, err := transcript.Render(format)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	fmt.Fprintf(w, "✅ Session exported to %s (%d steps)\n", path, len(transcript.Steps))
+	return nil
+}
+
+// historyExportCmd represents the history export command
+var historyExportCmd = &cobra.Command{
+	Use:   "export <file>",
+	Short: "Export a history entry as a shareable transcript",
+	Long: `Export a recorded session as an incident write-up with its goal, environment,
+numbered steps with commands and collapsible outputs, and the final summary
+and findings. Credentials are redacted.
+
+<file> is a history file as listed by 'k8x history list', a path to a .k8x
+file, or "latest" for the most recent session.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		formatName, _ := cmd.Flags().GetString("format")
+		outputPath, _ := cmd.Flags().GetString("output")
+
+		entry, err := loadHistoryEntry(args[0])
+		if err != nil {
+			return err
+		}
+
+		format := history.ExportMarkdown
+		if formatName != "" {
+			if format, err = history.ParseExportFormat(formatName); err != nil {
+				return err
+			}
+		} else if outputPath != "" {
+			format = history.ExportFormatForPath(outputPath)
+		}
+
+		data, err := history.NewTranscript(entry).Render(format)
+		if err != nil {
+			return err
+		}
+		if outputPath == "" {
+			_, err = cmd.OutOrStdout().Write(data)
+			return err
+		}
+		if err := os.WriteFile(outputPath, data, 0600); err != nil {
+			return fmt.Errorf("failed to write %s: %w", outputPath, err)
+		}
+		fmt.Fprintf(cmd.ErrOrStderr(), "✅ Exported %s to %s\n", args[0], outputPath)
+		return nil
+	},
+}
+
+// loadHistoryEntry loads a history entry by file name, path or "latest"
+func loadHistoryEntry(name string) (*history.Entry, error) {
+	if _, err := os.Stat(name); err == nil {
+		return history.LoadFile(name)
+	}
+
+	manager, err := history.NewManager()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create history manager: %w", err)
+	}
+	if name == "latest" {
+		files, err := manager.List()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list history: %w", err)
+		}
+		if len(files) == 0 {
+			return nil, fmt.Errorf("no command history found")
+		}
+		name = files[len(files)-1]
+	}
+
+	entry, err := manager.Load(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load history entry: %w", err)
+	}
+	return entry, nil
+}
+
+func init() {
+	historyCmd.AddCommand(historyExportCmd)
+	historyExportCmd.Flags().StringP("format", "f", "", "Transcript format: md, html or json (default: from --output's extension, else md)")
+	historyExportCmd.Flags().StringP("output", "o", "", "File to write the transcript to (default: standard output)")
+}
@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"k8x/internal/history"
+	"k8x/internal/llm"
+)
+
+func TestParseExportArgs(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		wantPath   string
+		wantFormat history.ExportFormat
+		wantErr    bool
+	}{
+		{"extension", []string{"incident.html"}, "incident.html", history.ExportHTML, false},
+		{"default", []string{"incident"}, "incident", history.ExportMarkdown, false},
+		{"flag", []string{"incident.txt", "--format", "json"}, "incident.txt", history.ExportJSON, false},
+		{"flag with value", []string{"--format=md", "out.html"}, "out.html", history.ExportMarkdown, false},
+		{"missing file", nil, "", "", true},
+		{"missing format", []string{"out", "--format"}, "", "", true},
+		{"unknown format", []string{"out", "--format", "pdf"}, "out", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, format, err := parseExportArgs(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseExportArgs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && (path != tt.wantPath || format != tt.wantFormat) {
+				t.Errorf("parseExportArgs() = %q, %q, want %q, %q", path, format, tt.wantPath, tt.wantFormat)
+			}
+		})
+	}
+}
+
+func TestTranscriptFromMessages(t *testing.T) {
+	call := llm.ToolCall{ID: "call-1"}
+	call.Function.Name = "execute_shell_command"
+	call.Function.Arguments = `{"command":"kubectl get pods -n shop"}`
+
+	messages := []llm.Message{
+		{Role: "system", Content: "You are k8x"},
+		{Role: "user", Content: history.GoalPrefix + "why is api failing?\n\nPlease help me achieve this goal."},
+		{Role: "assistant", Content: "Listing the pods.", ToolCalls: []llm.ToolCall{call}},
+		{Role: "tool", Content: "api-1 0/1 CrashLoopBackOff\n", ToolCallID: "call-1"},
+		{Role: "assistant", Content: "The api pod is crash looping.\n- api-1 restarts\n**DONE**"},
+	}
+
+	transcript := transcriptFromMessages(messages, map[string]string{"context": "prod"})
+	if !reflect.DeepEqual(transcript.Goals, []string{"why is api failing?"}) {
+		t.Errorf("goals = %q", transcript.Goals)
+	}
+	want := []history.TranscriptStep{{Number: 1, Explanation: "Listing the pods.", Tool: "execute_shell_command", Command: "kubectl get pods -n shop", Output: "api-1 0/1 CrashLoopBackOff"}}
+	if !reflect.DeepEqual(transcript.Steps, want) {
+		t.Errorf("steps = %+v, want %+v", transcript.Steps, want)
+	}
+	if transcript.Summary != "The api pod is crash looping." || !reflect.DeepEqual(transcript.Findings, []string{"api-1 restarts"}) {
+		t.Errorf("summary = %q, findings = %q", transcript.Summary, transcript.Findings)
+	}
+
+	path := filepath.Join(t.TempDir(), "incident.md")
+	var out strings.Builder
+	if err := handleExportCommand([]string{path}, messages, nil, &out); err != nil {
+		t.Fatalf("handleExportCommand() failed: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || !strings.Contains(string(data), "## Summary") {
+		t.Errorf("exported transcript = %q, %v", data, err)
+	}
+	if info, err := os.Stat(path); err != nil {
+		t.Errorf("Stat() failed: %v", err)
+	} else if info.Mode().Perm() != 0600 {
+		t.Errorf("exported transcript mode = %v, want 0600", info.Mode().Perm())
+	}
+
+	if err := handleExportCommand([]string{path}, messages[:1], nil, &out); err == nil {
+		t.Error("handleExportCommand() of an empty session succeeded")
+	}
+}
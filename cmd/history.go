@@ -69,6 +69,9 @@ var historyShowCmd = &cobra.Command{
 		fmt.Printf("Goal: %s\n", entry.Goal)
 		fmt.Printf("Timestamp: %s\n", entry.Timestamp.Format("2006-01-02 15:04:05"))
 		fmt.Printf("Status: %s\n", entry.Status)
+		for _, key := range sortedKeys(entry.Environment) {
+			fmt.Printf("%s: %s\n", key, entry.Environment[key])
+		}
 		fmt.Printf("Steps: %d\n", len(entry.Steps))
 
 		for i, step := range entry.Steps {
@@ -102,8 +105,8 @@ var historyClearCmd = &cobra.Command{
 }
 
 func init() {
-	// Commands are now accessible via /history in console
-	// rootCmd.AddCommand(historyCmd)
+	// Also accessible via /history in console
+	rootCmd.AddCommand(historyCmd)
 	historyCmd.AddCommand(historyListCmd)
 	historyCmd.AddCommand(historyShowCmd)
 	historyCmd.AddCommand(historyClearCmd)
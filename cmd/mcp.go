@@ -1,13 +1,25 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"os/exec"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
 
 	"k8x/internal/config"
+	"k8x/internal/mcp"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
+// mcpValidationTimeout bounds the test connection made by `mcp add`
+const mcpValidationTimeout = 30 * time.Second
+
 // mcpCmd represents the mcp command
 var mcpCmd = &cobra.Command{
 	Use:   "mcp",
@@ -28,7 +40,7 @@ var mcpListCmd = &cobra.Command{
 
 		if !cfg.MCP.Enabled {
 			fmt.Println("MCP integration is disabled")
-			fmt.Println("Enable it with: k8x mcp enable")
+			fmt.Println("Enable it with: /mcp enable")
 			return nil
 		}
 
@@ -37,71 +49,257 @@ var mcpListCmd = &cobra.Command{
 			return nil
 		}
 
+		names := make([]string, 0, len(cfg.MCP.Servers))
+		for name := range cfg.MCP.Servers {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
 		fmt.Println("Configured MCP servers:")
-		for name, server := range cfg.MCP.Servers {
-			status := "disabled"
-			if server.Enabled {
-				status = "enabled"
-			}
-			fmt.Printf("  %s (%s)\n", name, status)
-			fmt.Printf("    Command: %s %v\n", server.Command, server.Args)
-			if server.Description != "" {
-				fmt.Printf("    Description: %s\n", server.Description)
-			}
+		for _, name := range names {
+			printMCPServer(name, cfg.MCP.Servers[name])
 		}
 		return nil
 	},
 }
 
+// printMCPServer prints the transport-specific settings of a server
+func printMCPServer(name string, server config.MCPServerConfig) {
+	status := "disabled"
+	if server.Enabled {
+		status = "enabled"
+	}
+	transport := server.Transport
+	if transport == "" {
+		transport = "stdio"
+	}
+
+	fmt.Printf("  %s (%s, %s)\n", name, status, transport)
+	switch transport {
+	case "stdio":
+		fmt.Printf("    Command: %s\n", strings.TrimSpace(server.Command+" "+strings.Join(server.Args, " ")))
+	default:
+		fmt.Printf("    URL: %s\n", server.BaseURL)
+	}
+
+	if len(server.Env) > 0 {
+		// Only print variable names, values frequently hold tokens
+		keys := make([]string, 0, len(server.Env))
+		for key := range server.Env {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		fmt.Printf("    Env: %s\n", strings.Join(keys, ", "))
+	}
+
+	if server.OAuth != nil {
+		fmt.Printf("    OAuth client: %s\n", server.OAuth.ClientID)
+		if len(server.OAuth.Scopes) > 0 {
+			fmt.Printf("    OAuth scopes: %s\n", strings.Join(server.OAuth.Scopes, " "))
+		}
+	}
+
+	if len(server.AllowedTools) > 0 {
+		fmt.Printf("    Allowed tools: %s\n", strings.Join(server.AllowedTools, ", "))
+	}
+	if len(server.DeniedTools) > 0 {
+		fmt.Printf("    Denied tools: %s\n", strings.Join(server.DeniedTools, ", "))
+	}
+	if server.ReadOnlyHint {
+		fmt.Println("    Read-only: only tools annotated with readOnlyHint")
+	}
+
+	if server.Description != "" {
+		fmt.Printf("    Description: %s\n", server.Description)
+	}
+}
+
 // mcpEnableCmd represents the mcp enable command
 var mcpEnableCmd = &cobra.Command{
-	Use:   "enable",
-	Short: "Enable MCP integration",
-	Long:  `Enable Model Context Protocol integration to use MCP servers as tools.`,
+	Use:   "enable [name]",
+	Short: "Enable MCP integration or a single MCP server",
+	Long: `Enable Model Context Protocol integration to use MCP servers as tools.
+When a server name is given, only that server is enabled.`,
+	Args: cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		// TODO: Implement MCP enable functionality
-		fmt.Println("MCP integration enabled")
-		fmt.Println("Note: Configuration modification not yet implemented")
-		return nil
+		return setMCPEnabled(args, true)
 	},
 }
 
 // mcpDisableCmd represents the mcp disable command
 var mcpDisableCmd = &cobra.Command{
-	Use:   "disable",
-	Short: "Disable MCP integration",
-	Long:  `Disable Model Context Protocol integration.`,
+	Use:   "disable [name]",
+	Short: "Disable MCP integration or a single MCP server",
+	Long: `Disable Model Context Protocol integration.
+When a server name is given, only that server is disabled.`,
+	Args: cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		// TODO: Implement MCP disable functionality
-		fmt.Println("MCP integration disabled")
-		fmt.Println("Note: Configuration modification not yet implemented")
-		return nil
+		return setMCPEnabled(args, false)
 	},
 }
 
+// setMCPEnabled toggles either the global MCP switch or a single server
+func setMCPEnabled(args []string, enabled bool) error {
+	state := "disabled"
+	if enabled {
+		state = "enabled"
+	}
+
+	if len(args) == 0 {
+		if err := config.SetMCPEnabled(enabled); err != nil {
+			return fmt.Errorf("failed to update configuration: %w", err)
+		}
+		fmt.Printf("MCP integration %s\n", state)
+		return nil
+	}
+
+	if err := config.SetMCPServerEnabled(args[0], enabled); err != nil {
+		return fmt.Errorf("failed to update configuration: %w", err)
+	}
+	fmt.Printf("MCP server '%s' %s\n", args[0], state)
+	return nil
+}
+
 // mcpAddCmd represents the mcp add command
 var mcpAddCmd = &cobra.Command{
-	Use:   "add <name> <command> [args...]",
+	Use:   "add <name> [command] [args...]",
 	Short: "Add an MCP server configuration",
-	Long:  `Add a new MCP server configuration with the specified command and arguments.`,
-	Args:  cobra.MinimumNArgs(2),
+	Long: `Add a new MCP server configuration. Stdio servers take a command and
+arguments; sse, http, oauth-sse and oauth-http servers take --base-url.
+Use "--" before the server command when it has flags of its own.
+
+The server is test-connected before the configuration is saved.
+
+Examples:
+  /mcp add filesystem -- npx -y @modelcontextprotocol/server-filesystem /tmp
+  /mcp add github -e GITHUB_TOKEN=ghp_xxx -- npx -y @modelcontextprotocol/server-github
+  /mcp add search -t http --base-url https://mcp.example.com/mcp
+  /mcp add docs -t oauth-http --base-url https://mcp.example.com/mcp --oauth-client-id k8x --oauth-pkce`,
+	Args: cobra.MinimumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		name := args[0]
-		command := args[1]
-		serverArgs := args[2:]
 
-		description, _ := cmd.Flags().GetString("description")
+		server, err := mcpServerFromFlags(cmd.Flags(), args[1:])
+		if err != nil {
+			return err
+		}
+
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			return fmt.Errorf("failed to load configuration: %w", err)
+		}
+		if _, exists := cfg.MCP.Servers[name]; exists {
+			if replace, _ := cmd.Flags().GetBool("replace"); !replace {
+				return fmt.Errorf("MCP server '%s' already exists (use --replace to overwrite it)", name)
+			}
+		}
+
+		if skip, _ := cmd.Flags().GetBool("skip-validation"); !skip {
+			fmt.Printf("Testing connection to MCP server '%s'...\n", name)
+			ctx, cancel := context.WithTimeout(context.Background(), mcpValidationTimeout)
+			tools, err := mcp.TestServerConnection(ctx, name, server)
+			cancel()
+			switch {
+			case errors.Is(err, mcp.ErrAuthorizationRequired):
+				fmt.Printf("⚠️  Server is reachable but requires OAuth authorization, run: k8x mcp login %s\n", name)
+			case err != nil:
+				return fmt.Errorf("failed to connect to MCP server '%s' (use --skip-validation to save anyway): %w", name, err)
+			default:
+				fmt.Printf("✓ Connected, server provides %d tool(s)\n", len(tools))
+			}
+		}
 
-		fmt.Printf("Adding MCP server '%s'\n", name)
-		fmt.Printf("  Command: %s %v\n", command, serverArgs)
-		if description != "" {
-			fmt.Printf("  Description: %s\n", description)
+		if err := config.SaveMCPServer(name, server); err != nil {
+			return fmt.Errorf("failed to update configuration: %w", err)
+		}
+
+		fmt.Printf("✓ Added MCP server '%s'\n", name)
+		printMCPServer(name, server)
+		if !cfg.MCP.Enabled {
+			fmt.Println("Note: MCP integration is disabled. Enable it with: /mcp enable")
 		}
-		fmt.Println("Note: Configuration modification not yet implemented")
 		return nil
 	},
 }
 
+// mcpServerFromFlags builds a server configuration from `mcp add` flags
+func mcpServerFromFlags(flags *pflag.FlagSet, commandArgs []string) (config.MCPServerConfig, error) {
+	transport, _ := flags.GetString("transport")
+	description, _ := flags.GetString("description")
+	baseURL, _ := flags.GetString("base-url")
+	envPairs, _ := flags.GetStringArray("env")
+	disabled, _ := flags.GetBool("disabled")
+	allowedTools, _ := flags.GetStringSlice("allowed-tools")
+	deniedTools, _ := flags.GetStringSlice("denied-tools")
+	readOnly, _ := flags.GetBool("read-only")
+
+	server := config.MCPServerConfig{
+		Transport:    transport,
+		Enabled:      !disabled,
+		Description:  description,
+		AllowedTools: allowedTools,
+		DeniedTools:  deniedTools,
+		ReadOnlyHint: readOnly,
+	}
+	if _, err := mcp.NewToolPolicy(server); err != nil {
+		return server, err
+	}
+
+	for _, pair := range envPairs {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok || key == "" {
+			return server, fmt.Errorf("invalid --env value %q, expected KEY=VALUE", pair)
+		}
+		if server.Env == nil {
+			server.Env = make(map[string]string)
+		}
+		server.Env[key] = value
+	}
+
+	switch transport {
+	case "stdio":
+		if len(commandArgs) == 0 {
+			return server, fmt.Errorf("a command is required for stdio transport")
+		}
+		if baseURL != "" {
+			return server, fmt.Errorf("--base-url is not supported for stdio transport")
+		}
+		server.Command = commandArgs[0]
+		server.Args = commandArgs[1:]
+		return server, nil
+	case "sse", "http", "streamable-http", "oauth-sse", "oauth-http", "oauth-streamable-http":
+		if len(commandArgs) > 0 {
+			return server, fmt.Errorf("unexpected command arguments for %s transport: %v", transport, commandArgs)
+		}
+		if baseURL == "" {
+			return server, fmt.Errorf("--base-url is required for %s transport", transport)
+		}
+		server.BaseURL = baseURL
+	default:
+		return server, fmt.Errorf("unsupported MCP transport type: %s", transport)
+	}
+
+	if strings.HasPrefix(transport, "oauth-") {
+		clientID, _ := flags.GetString("oauth-client-id")
+		clientSecret, _ := flags.GetString("oauth-client-secret")
+		redirectURI, _ := flags.GetString("oauth-redirect-uri")
+		scopes, _ := flags.GetStringSlice("oauth-scopes")
+		metadataURL, _ := flags.GetString("oauth-metadata-url")
+		pkce, _ := flags.GetBool("oauth-pkce")
+
+		server.OAuth = &config.OAuthConfig{
+			ClientID:              clientID,
+			ClientSecret:          clientSecret,
+			RedirectURI:           redirectURI,
+			Scopes:                scopes,
+			AuthServerMetadataURL: metadataURL,
+			PKCEEnabled:           pkce,
+		}
+	}
+
+	return server, nil
+}
+
 // mcpRemoveCmd represents the mcp remove command
 var mcpRemoveCmd = &cobra.Command{
 	Use:   "remove <name>",
@@ -110,21 +308,160 @@ var mcpRemoveCmd = &cobra.Command{
 	Args:  cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		name := args[0]
-		fmt.Printf("Removing MCP server '%s'\n", name)
-		fmt.Println("Note: Configuration modification not yet implemented")
+		if err := config.RemoveMCPServer(name); err != nil {
+			return fmt.Errorf("failed to update configuration: %w", err)
+		}
+		if _, err := mcp.Logout(name); err != nil {
+			fmt.Printf("⚠️  Warning: failed to delete stored OAuth token: %v\n", err)
+		}
+		fmt.Printf("✓ Removed MCP server '%s'\n", name)
 		return nil
 	},
 }
 
+// mcpLoginCmd represents the mcp login command
+var mcpLoginCmd = &cobra.Command{
+	Use:   "login <name>",
+	Short: "Authorize k8x with an OAuth MCP server",
+	Long: `Authorize k8x with an oauth-sse or oauth-http MCP server using the OAuth
+authorization code flow with PKCE. A browser window is opened for you to sign
+in; the authorization server redirects back to a local loopback address.
+
+The token is stored encrypted in ~/.k8x/mcp-tokens and refreshed
+automatically. Servers without a client_id are registered dynamically.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			return fmt.Errorf("failed to load configuration: %w", err)
+		}
+		server, exists := cfg.MCP.Servers[name]
+		if !exists {
+			return fmt.Errorf("MCP server '%s' not found in configuration", name)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), mcp.LoginTimeout)
+		defer cancel()
+
+		err = mcp.Login(ctx, name, server, func(authURL string) {
+			fmt.Printf("Opening your browser to authorize k8x with '%s'.\n", name)
+			fmt.Printf("If it doesn't open, visit this URL:\n\n  %s\n\n", authURL)
+			if err := openBrowser(authURL); err != nil {
+				fmt.Printf("⚠️  Could not open a browser: %v\n", err)
+			}
+			fmt.Println("Waiting for authorization...")
+		})
+		if err != nil {
+			return fmt.Errorf("failed to authorize with MCP server '%s': %w", name, err)
+		}
+
+		fmt.Printf("✓ Logged in to MCP server '%s'\n", name)
+		return nil
+	},
+}
+
+// mcpLogoutCmd represents the mcp logout command
+var mcpLogoutCmd = &cobra.Command{
+	Use:   "logout <name>",
+	Short: "Delete the stored OAuth token of an MCP server",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		deleted, err := mcp.Logout(name)
+		if err != nil {
+			return err
+		}
+		if !deleted {
+			fmt.Printf("No stored OAuth token for MCP server '%s'\n", name)
+			return nil
+		}
+		fmt.Printf("✓ Logged out of MCP server '%s'\n", name)
+		return nil
+	},
+}
+
+// openBrowser opens url in the user's default browser
+func openBrowser(url string) error {
+	var command *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		command = exec.Command("open", url)
+	case "windows":
+		command = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	default:
+		command = exec.Command("xdg-open", url)
+	}
+	return command.Start()
+}
+
+// runMCPSubcommand runs an `mcp` subcommand from the console, e.g. `/mcp add ...`.
+// It's run directly rather than through Execute, which would run the root
+// command with the process's arguments. Flag values are reset afterwards
+// since the command tree is reused.
+func runMCPSubcommand(args []string) error {
+	cmd, rest, err := mcpCmd.Find(args)
+	if err != nil {
+		return err
+	}
+	defer resetFlags(cmd.Flags())
+
+	cmd.InitDefaultHelpFlag()
+	if err := cmd.ParseFlags(rest); err != nil {
+		return err
+	}
+	if help, _ := cmd.Flags().GetBool("help"); help || cmd.RunE == nil {
+		if cmd.RunE == nil && cmd.Flags().NArg() > 0 {
+			return fmt.Errorf("unknown command %q for /mcp", cmd.Flags().Arg(0))
+		}
+		return cmd.Help()
+	}
+	if err := cmd.ValidateArgs(cmd.Flags().Args()); err != nil {
+		return err
+	}
+	return cmd.RunE(cmd, cmd.Flags().Args())
+}
+
+// resetFlags restores every flag in the set to its default value
+func resetFlags(flags *pflag.FlagSet) {
+	flags.VisitAll(func(f *pflag.Flag) {
+		if slice, ok := f.Value.(pflag.SliceValue); ok {
+			_ = slice.Replace(nil)
+		} else {
+			_ = f.Value.Set(f.DefValue)
+		}
+		f.Changed = false
+	})
+}
+
 func init() {
-	// Command is now accessible via /mcp in console
-	// rootCmd.AddCommand(mcpCmd)
+	// Also accessible via /mcp in the console
+	rootCmd.AddCommand(mcpCmd)
 	mcpCmd.AddCommand(mcpListCmd)
 	mcpCmd.AddCommand(mcpEnableCmd)
 	mcpCmd.AddCommand(mcpDisableCmd)
 	mcpCmd.AddCommand(mcpAddCmd)
 	mcpCmd.AddCommand(mcpRemoveCmd)
+	mcpCmd.AddCommand(mcpLoginCmd)
+	mcpCmd.AddCommand(mcpLogoutCmd)
+	mcpCmd.SilenceUsage = true
 
 	// Add flags for MCP commands
 	mcpAddCmd.Flags().StringP("description", "d", "", "Description of the MCP server")
+	mcpAddCmd.Flags().StringP("transport", "t", "stdio", "Transport type (stdio, sse, http, oauth-sse, oauth-http)")
+	mcpAddCmd.Flags().StringArrayP("env", "e", nil, "Environment variable for the server as KEY=VALUE (repeatable)")
+	mcpAddCmd.Flags().String("base-url", "", "Base URL for sse, http and oauth transports")
+	mcpAddCmd.Flags().String("oauth-client-id", "", "OAuth client ID")
+	mcpAddCmd.Flags().String("oauth-client-secret", "", "OAuth client secret")
+	mcpAddCmd.Flags().String("oauth-redirect-uri", "", "OAuth redirect URI")
+	mcpAddCmd.Flags().StringSlice("oauth-scopes", nil, "OAuth scopes (comma separated)")
+	mcpAddCmd.Flags().String("oauth-metadata-url", "", "OAuth authorization server metadata URL")
+	mcpAddCmd.Flags().Bool("oauth-pkce", false, "Enable PKCE for the OAuth flow")
+	mcpAddCmd.Flags().StringSlice("allowed-tools", nil, "Glob patterns of tools to expose (comma separated, default all)")
+	mcpAddCmd.Flags().StringSlice("denied-tools", nil, "Glob patterns of tools to hide (comma separated)")
+	mcpAddCmd.Flags().Bool("read-only", false, "Only expose tools annotated as read-only (readOnlyHint)")
+	mcpAddCmd.Flags().Bool("disabled", false, "Add the server without enabling it")
+	mcpAddCmd.Flags().Bool("replace", false, "Overwrite an existing server with the same name")
+	mcpAddCmd.Flags().Bool("skip-validation", false, "Save the server without test-connecting to it")
 }
@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMCPCommandsAreRegistered(t *testing.T) {
+	for _, args := range [][]string{{"mcp", "login"}, {"mcp", "logout"}, {"mcp", "add"}, {"mcp", "enable"}} {
+		cmd, _, err := rootCmd.Find(args)
+		if err != nil {
+			t.Fatalf("Find(%q) failed: %v", args, err)
+		}
+		if got := cmd.CommandPath(); got != "k8x "+strings.Join(args, " ") {
+			t.Errorf("Find(%q) = %s", args, got)
+		}
+	}
+}
+
+func TestRunMCPSubcommand(t *testing.T) {
+	tests := []struct {
+		args    []string
+		wantErr string
+	}{
+		{[]string{"login"}, "accepts 1 arg(s)"},
+		{[]string{"logout", "a", "b"}, "accepts 1 arg(s)"},
+		{[]string{"remove", "--bogus", "docs"}, "unknown flag: --bogus"},
+		{[]string{"frobnicate"}, `unknown command "frobnicate"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			err := runMCPSubcommand(tt.args)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("runMCPSubcommand(%q) error = %v, want %q", tt.args, err, tt.wantErr)
+			}
+		})
+	}
+}
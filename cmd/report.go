@@ -0,0 +1,203 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"k8x/internal/config"
+	"k8x/internal/history"
+	"k8x/internal/llm"
+	"k8x/internal/llm/providers"
+	"k8x/internal/output"
+	"k8x/internal/report"
+
+	"github.com/spf13/cobra"
+)
+
+// parseReportArgs parses "[file] [--format md|json]". Without --format, the
+// format follows the file's extension.
+func parseReportArgs(args []string) (string, history.ExportFormat, error) {
+	var path, formatName string
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "--format":
+			if i+1 == len(args) {
+				return "", "", fmt.Errorf("--format needs a value: md or json")
+			}
+			i++
+			formatName = args[i]
+		case strings.HasPrefix(args[i], "--format="):
+			formatName = strings.TrimPrefix(args[i], "--format=")
+		case path == "":
+			path = args[i]
+		default:
+			return "", "", fmt.Errorf("usage: /report [file] [--format md|json]")
+		}
+	}
+	format, err := reportFormat(formatName, path)
+	return path, format, err
+}
+
+// reportFormat picks the report format from a --format value, else from
+// the extension of the file the report is written to
+func reportFormat(formatName, path string) (history.ExportFormat, error) {
+	if formatName == "" {
+		if path != "" && history.ExportFormatForPath(path) == history.ExportJSON {
+			return history.ExportJSON, nil
+		}
+		return history.ExportMarkdown, nil
+	}
+
+	format, err := history.ParseExportFormat(formatName)
+	if err != nil || format == history.ExportHTML {
+		return "", fmt.Errorf("unknown report format %q, use md or json", formatName)
+	}
+	return format, nil
+}
+
+// generateReport analyzes the session in transcript and renders the report
+func generateReport(provider llm.Provider, transcript *history.Transcript, format history.ExportFormat) (*report.Report, []byte, error) {
+	rca, err := report.Generate(context.Background(), provider, transcript)
+	if err != nil {
+		return nil, nil, err
+	}
+	data, err := rca.Render(format)
+	if err != nil {
+		return nil, nil, err
+	}
+	return rca, data, nil
+}
+
+// handleReportCommand writes a root cause analysis of the console
+// conversation to a file, or shows it without one. Messages are written to w.
+func handleReportCommand(args []string, provider llm.Provider, messages []llm.Message, environment map[string]string, w io.Writer) error {
+	path, format, err := parseReportArgs(args)
+	if err != nil {
+		return err
+	}
+
+	transcript := transcriptFromMessages(messages, environment)
+	if len(transcript.Steps) == 0 {
+		return fmt.Errorf("nothing to report yet, investigate an issue first")
+	}
+
+	fmt.Fprintf(w, "📝 Analyzing %d steps for the incident report...\n", len(transcript.Steps))
+	rca, data, err := generateReport(provider, transcript, format)
+	if err != nil {
+		return err
+	}
+
+	if path == "" {
+		if format == history.ExportMarkdown {
+			data = []byte(output.RenderMarkdown(string(data), output.ColorEnabled()))
+		}
+		_, err = w.Write(data)
+		return err
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	fmt.Fprintf(w, "✅ Incident report written to %s (confidence: %s)\n", path, rca.Confidence)
+	return nil
+}
+
+// loadSessionTranscript loads a saved conversation by name, or else a
+// history entry by file name, path or "latest"
+func loadSessionTranscript(name string) (*history.Transcript, error) {
+	if _, err := os.Stat(name); err != nil && history.ValidateConversationName(name) == nil {
+		if store, err := history.NewConversationStore(); err == nil {
+			conversation, err := store.Load(name)
+			if err == nil {
+				transcript := transcriptFromMessages(conversation.Messages, conversation.Environment)
+				transcript.Date = conversation.Updated
+				return transcript, nil
+			}
+			if !errors.Is(err, history.ErrConversationNotFound) {
+				return nil, err
+			}
+		}
+	}
+
+	entry, err := loadHistoryEntry(name)
+	if err != nil {
+		return nil, err
+	}
+	return history.NewTranscript(entry), nil
+}
+
+// newLLMProvider creates the LLM provider selected in the credentials, with
+// the model set for it in the config file
+func newLLMProvider() (*providers.UnifiedProvider, error) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load configuration: %w", err)
+	}
+	creds, err := config.LoadCredentials()
+	if err != nil {
+		return nil, errors.New("k8x is not configured correctly.\nHint: Please run `k8x configure`")
+	}
+	if !creds.HasAnyKey("openai_api_key", "anthropic_api_key", "gemini_api_key") {
+		return nil, errors.New("k8x cannot find any LLM configured.\nHint: Run 'k8x configure' to set up your LLM provider")
+	}
+	return newConfiguredProvider(providerCredentials(creds), cfg)
+}
+
+// reportCmd represents the report command
+var reportCmd = &cobra.Command{
+	Use:   "report <session>",
+	Short: "Generate a root cause analysis of a session",
+	Long: `Generate a root cause analysis from a completed investigation: the symptoms,
+the evidence with the commands showing it, the probable cause and how
+confident the analysis is of it, remediation suggestions and checks to follow
+up with. Credentials are redacted before the session is sent to the LLM.
+
+<session> is a conversation saved with /save, a history file as listed by
+'k8x history list', a path to a .k8x file, or "latest" for the most recent
+session. Reports are written as Markdown or as JSON for incident tooling.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		formatName, _ := cmd.Flags().GetString("format")
+		outputPath, _ := cmd.Flags().GetString("output")
+
+		transcript, err := loadSessionTranscript(args[0])
+		if err != nil {
+			return err
+		}
+		if len(transcript.Steps) == 0 {
+			return fmt.Errorf("%s has no steps to analyze", args[0])
+		}
+
+		provider, err := newLLMProvider()
+		if err != nil {
+			return err
+		}
+
+		format, err := reportFormat(formatName, outputPath)
+		if err != nil {
+			return err
+		}
+		rca, data, err := generateReport(provider, transcript, format)
+		if err != nil {
+			return err
+		}
+		if outputPath == "" {
+			_, err = cmd.OutOrStdout().Write(data)
+			return err
+		}
+		if err := os.WriteFile(outputPath, data, 0600); err != nil {
+			return fmt.Errorf("failed to write %s: %w", outputPath, err)
+		}
+		fmt.Fprintf(cmd.ErrOrStderr(), "✅ Incident report on %s written to %s (confidence: %s)\n", args[0], outputPath, rca.Confidence)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(reportCmd)
+	reportCmd.Flags().String("format", "", "Report format: md or json (default: from --output's extension, else md)")
+	reportCmd.Flags().StringP("output", "o", "", "File to write the report to (default: standard output)")
+}
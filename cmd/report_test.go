@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"k8x/internal/history"
+	"k8x/internal/llm"
+)
+
+// fixedProvider is an LLM provider always giving the same answer
+type fixedProvider struct{ content string }
+
+func (p *fixedProvider) Name() string       { return "fixed" }
+func (p *fixedProvider) IsConfigured() bool { return true }
+
+func (p *fixedProvider) Chat(ctx context.Context, messages []llm.Message) (*llm.Response, error) {
+	return &llm.Response{Content: p.content}, nil
+}
+
+func (p *fixedProvider) Stream(ctx context.Context, messages []llm.Message) (io.ReadCloser, error) {
+	return nil, errors.New("not supported")
+}
+
+func TestParseReportArgs(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		wantPath   string
+		wantFormat history.ExportFormat
+		wantErr    bool
+	}{
+		{"screen", nil, "", history.ExportMarkdown, false},
+		{"extension", []string{"rca.json"}, "rca.json", history.ExportJSON, false},
+		{"html extension", []string{"rca.html"}, "rca.html", history.ExportMarkdown, false},
+		{"flag", []string{"rca.txt", "--format", "json"}, "rca.txt", history.ExportJSON, false},
+		{"html format", []string{"--format=html"}, "", "", true},
+		{"missing format", []string{"rca.md", "--format"}, "", "", true},
+		{"no format shorthand", []string{"rca.md", "-f", "json"}, "", "", true},
+		{"two files", []string{"a.md", "b.md"}, "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, format, err := parseReportArgs(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseReportArgs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && (path != tt.wantPath || format != tt.wantFormat) {
+				t.Errorf("parseReportArgs() = %q, %q, want %q, %q", path, format, tt.wantPath, tt.wantFormat)
+			}
+		})
+	}
+}
+
+func TestHandleReportCommand(t *testing.T) {
+	call := llm.ToolCall{ID: "call-1"}
+	call.Function.Name = "execute_shell_command"
+	call.Function.Arguments = `{"command":"kubectl get pods -n shop"}`
+	messages := []llm.Message{
+		{Role: "system", Content: "You are k8x"},
+		{Role: "user", Content: history.GoalPrefix + "why is api failing?\n\nPlease help me."},
+		{Role: "assistant", Content: "Listing the pods.", ToolCalls: []llm.ToolCall{call}},
+		{Role: "tool", Content: "api-1 0/1 CrashLoopBackOff", ToolCallID: "call-1"},
+		{Role: "assistant", Content: "The api pod is crash looping. **DONE**"},
+	}
+	provider := &fixedProvider{content: `{"symptoms": ["api-1 restarts"], "evidence": [{"finding": "api-1 crash loops", "steps": [1]}], "probable_cause": "Bad config", "confidence": "high"}`}
+
+	path := filepath.Join(t.TempDir(), "rca.md")
+	var out strings.Builder
+	if err := handleReportCommand([]string{path}, provider, messages, map[string]string{"context": "prod"}, &out); err != nil {
+		t.Fatalf("handleReportCommand() failed: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || !strings.Contains(string(data), "`kubectl get pods -n shop`") || !strings.Contains(string(data), "**context:** prod") {
+		t.Errorf("report = %q, %v", data, err)
+	}
+	if info, err := os.Stat(path); err != nil {
+		t.Errorf("Stat() failed: %v", err)
+	} else if info.Mode().Perm() != 0600 {
+		t.Errorf("report mode = %v, want 0600", info.Mode().Perm())
+	}
+	if !strings.Contains(out.String(), "confidence: high") {
+		t.Errorf("handleReportCommand() output = %q", out.String())
+	}
+
+	if err := handleReportCommand(nil, provider, messages[:2], nil, &out); err == nil {
+		t.Error("handleReportCommand() of a session without steps succeeded")
+	}
+}
@@ -5,12 +5,16 @@ import (
 	"os"
 	"path/filepath"
 
+	"k8x/internal/output"
+
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
 
 var (
 	cfgFile string
+	noColor bool
+	tuiMode bool
 	version = "dev"
 	commit  = "unknown"
 	date    = "unknown"
@@ -49,15 +53,22 @@ func Execute() {
 }
 
 func init() {
-	cobra.OnInitialize(initConfig)
+	cobra.OnInitialize(initConfig, initTerminal)
 
 	// Config file flag is kept for advanced users who want to specify a custom config
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.k8x/config.yaml)")
+	rootCmd.Flags().BoolVar(&tuiMode, "tui", false, "Start the full-screen console with panes for the conversation, steps, output and cluster state")
+	rootCmd.PersistentFlags().BoolVar(&noColor, "no-color", false, "Disable colors and Markdown styling (also set by NO_COLOR or when output is piped)")
 
 	// Remove all subcommands except console - they're now slash commands
 	// This keeps the binary clean and simple
 }
 
+// initTerminal decides whether output is styled for a terminal
+func initTerminal() {
+	output.SetupTerminal(noColor)
+}
+
 // initConfig reads in config file and ENV variables if set.
 func initConfig() {
 	if cfgFile != "" {
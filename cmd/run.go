@@ -5,14 +5,17 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"os"
 	"strings"
 	"time"
 
+	"k8x/internal/attach"
 	"k8x/internal/config"
 	k8xcontext "k8x/internal/context"
 	"k8x/internal/history"
+	"k8x/internal/kube"
 	"k8x/internal/llm"
-	"k8x/internal/llm/providers"
+	"k8x/internal/output"
 
 	"github.com/spf13/cobra"
 )
@@ -20,20 +23,21 @@ import (
 // runCmd represents the run command
 var runCmd = &cobra.Command{
 	Use:     "run \"<goal>\"",
-	Aliases: []string{"command", "-c"},
-	Short:   "Run a new k8x session with a goal (preferred: k8x -c)",
+	Aliases: []string{"command"},
+	Short:   "Run a new k8x session with a goal",
 	Long: `Start a new k8x session with a natural language goal.
 This will create a new .k8x history file and begin an LLM-driven
 planning and execution loop.
 
-Preferred usage:
-  k8x -c "Diagnose why my nginx pod is failing"
-
-Also supported:
+Examples:
   k8x run "Diagnose why my nginx pod is failing"
   k8x command "Diagnose why my nginx pod is failing"
-  k8x -c "Diagnose why my nginx pod is failing" --confirm
+  k8x run "Diagnose why my nginx pod is failing" --confirm
+  k8x run "Compare the checkout rollout" --context prod --context staging
+  k8x run "Why won't this deploy?" -f deploy.yaml
+  kubectl logs api-1 | k8x run "Why does the api crash?"
 `,
+	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		goal := args[0]
 		if strings.TrimSpace(goal) == "" {
@@ -46,10 +50,18 @@ Also supported:
 			return fmt.Errorf("failed to get confirm flag: %w", err)
 		}
 
+		// Read the attachments first, so that a bad file stops the run early
+		files, _ := cmd.Flags().GetStringArray("file")
+		attachments, err := runAttachments(files, confirm)
+		if err != nil {
+			return err
+		}
+
 		manager, err := history.NewManager()
 		if err != nil {
 			return fmt.Errorf("failed to create history manager: %w", err)
 		}
+		printer := output.NewPrinter(true)
 
 		// Create new session entry
 		entry := &history.Entry{
@@ -86,18 +98,9 @@ Also supported:
 			return errors.New("k8x cannot find any LLM configured.\nHint: Run 'k8x configure' to set up your LLM provider")
 		}
 
-		// Convert schemas.Credentials to providers.Credentials
-		provCreds := providers.Credentials{
-			SelectedProvider: creds.SelectedProvider,
-		}
-		provCreds.OpenAI.APIKey = creds.OpenAI.APIKey
-		provCreds.Anthropic.APIKey = creds.Anthropic.APIKey
-		provCreds.Google.APIKey = creds.Google.APIKey
-		provCreds.Google.ApplicationCredentials = creds.Google.ApplicationCredentials
-
-		unifiedProvider, err := providers.NewUnifiedProvider(provCreds)
+		unifiedProvider, err := newConfiguredProvider(providerCredentials(creds), cfg)
 		if err != nil {
-			return fmt.Errorf("failed to initialize LLM provider: %w", err)
+			return err
 		}
 		fmt.Printf("🤖 Using LLM provider: %s\n", unifiedProvider.Name())
 
@@ -110,23 +113,19 @@ Also supported:
 		// Connect to MCP servers if enabled
 		if cfg.MCP.Enabled {
 			fmt.Println("🔌 Connecting to MCP servers...")
-			if err := toolManager.ConnectMCPServers(context.Background()); err != nil {
-				fmt.Printf("⚠️  Warning: Failed to connect to some MCP servers: %v\n", err)
-			} else {
-				mcpStatus := toolManager.GetMCPServerStatus()
-				connectedCount := 0
-				for serverName, connected := range mcpStatus {
-					if connected {
-						connectedCount++
-						fmt.Printf("✓ Connected to MCP server: %s\n", serverName)
-					} else {
-						fmt.Printf("✗ Failed to connect to MCP server: %s\n", serverName)
-					}
-				}
-				if connectedCount > 0 {
-					fmt.Printf("🔌 Connected to %d MCP server(s)\n", connectedCount)
+			failures := toolManager.ConnectMCPServers(context.Background())
+			connectedCount := 0
+			for serverName, connected := range toolManager.GetMCPServerStatus() {
+				if connected {
+					connectedCount++
+					fmt.Printf("✓ Connected to MCP server: %s\n", serverName)
+				} else if err, failed := failures[serverName]; failed {
+					fmt.Printf("✗ %v\n", err)
 				}
 			}
+			if connectedCount > 0 {
+				fmt.Printf("🔌 Connected to %d MCP server(s)\n", connectedCount)
+			}
 			// Ensure MCP servers are disconnected when done
 			defer func() {
 				if err := toolManager.DisconnectMCPServers(); err != nil {
@@ -138,27 +137,54 @@ Also supported:
 		// Set confirmation mode
 		toolManager.SetConfirmationMode(confirm)
 
+		// Kube-contexts given on the command line replace the configured ones
+		if contexts, _ := cmd.Flags().GetStringSlice("context"); len(contexts) > 0 {
+			cfg.Kubernetes.Context = contexts[0]
+			cfg.Kubernetes.Contexts = contexts[1:]
+		}
+
 		// Set Kubernetes configuration for the tool manager's shell executor
 		toolManager.SetKubernetesConfig(&cfg.Kubernetes)
+		entry.Environment = sessionEnvironment(unifiedProvider.Label(), &cfg.Kubernetes)
+
+		// Restrict the session to a subset of tools if requested
+		if toolPatterns, _ := cmd.Flags().GetStringSlice("tools"); len(toolPatterns) > 0 {
+			toolManager.SetToolFilter(llm.MatchToolNames(toolPatterns...))
+		}
 
 		// Get all available tools (shell + MCP)
 		tools, err := toolManager.GetAllTools(context.Background())
 		if err != nil {
 			return fmt.Errorf("failed to get available tools: %w", err)
 		}
+		if len(tools) == 0 {
+			return fmt.Errorf("no tools match --tools")
+		}
 
-		fmt.Printf("🔧 Available tools: %d (including %d MCP tools)\n",
-			len(tools),
-			len(tools)-len(toolManager.GetTools()))
+		mcpToolCount := 0
+		origins := toolManager.Registry().Origins()
+		for _, tool := range tools {
+			if origins[tool.Function.Name].Source == llm.ToolSourceMCP {
+				mcpToolCount++
+			}
+		}
+		fmt.Printf("🔧 Available tools: %d (including %d MCP tools)\n", len(tools), mcpToolCount)
 
 		// Gather cluster context information before starting
 		fmt.Println("🔍 Gathering cluster information...")
 
 		// Build context info string using new function (prints as it gathers)
-		contextInfo, err := k8xcontext.BuildContextInfoString(toolManager.ToolManager, []string{"~/.zsh_history", "~/.bash_history"})
+		contextInfo, err := k8xcontext.BuildContextInfoString(toolManager.ToolManager, k8xcontext.Options{
+			Kubernetes:   &cfg.Kubernetes,
+			Goal:         goal,
+			HistoryFiles: k8xcontext.DefaultHistoryFiles(),
+			Providers:    cfg.Context.Providers,
+			Quiet:        cfg.Context.Quiet,
+		})
 		if err != nil {
 			return fmt.Errorf("failed to build context info: %w", err)
 		}
+		contextInfo += multiClusterGuidance(&cfg.Kubernetes)
 
 		// Prepare system message to set context for k8x
 		systemPrompt := fmt.Sprintf(`You are k8x, a Kubernetes shell-workflow assistant specialized in read-only diagnostics and operations.
@@ -173,13 +199,18 @@ Your role:
 3.c. You can use pipe '|' to chain commands for efficiency.
 3.d. You also have access to jq for JSON processing and can use it in commands.
 4. Always explain what each kubectl command will do before suggesting it
-5. Use the execute_shell_command function to run kubectl commands
+5. Prefer the typed Kubernetes tools for reading cluster state; use execute_shell_command for everything else
 6. Provide clear, actionable responses.
-7. Your responses will be printed to the console.
-Use colors and emojis to enhance readability.
-YOU MUST NOT USE MARKDOWN formatting in your responses.
+7. Your responses are rendered as Markdown in a terminal. Use short headings,
+lists, code blocks for commands and YAML, and tables for comparisons; avoid
+long paragraphs.
 
 Available tools:
+- get_resources: List resources of any kind with status, age and optional field columns
+- describe_resource: Show one resource as YAML with its events
+- get_logs: Get the last log lines of a pod's container
+- get_events: List events, optionally of one involved object
+- top: Show CPU and memory usage of pods or nodes
 - execute_shell_command: Execute safe read-only shell commands, primarily kubectl operations
 
 Current mode: READ-ONLY (no cluster modifications, no installations, no changes)
@@ -191,11 +222,22 @@ Guidelines:
 - Explain what you're going to do before executing commands.
 - If you achieve the goal or cannot proceed further, say "**DONE**."`, contextInfo)
 
-		// Start conversation with system prompt and user goal
-		messages := []llm.Message{
-			{Role: "system", Content: systemPrompt},
-			{Role: "user", Content: fmt.Sprintf("Goal: %s\n\nPlease help me achieve this goal using read-only kubectl commands. Start by suggesting and executing the first step.", entry.Goal)},
+		// Start conversation with system prompt, attachments and user goal
+		messages := []llm.Message{{Role: "system", Content: systemPrompt}}
+		for _, attachment := range attachments {
+			printAttachment(attachment, os.Stdout)
+			messages = append(messages, llm.Message{Role: "user", Content: attachment.Message()})
+
+			step := history.Step{
+				Description: "Attached " + attachment.Summary(),
+				Output:      joinResources(attachment.Resources),
+				Type:        "exploratory",
+			}
+			if err := manager.AddStep(entry, step); err != nil {
+				return fmt.Errorf("failed to add step to history: %w", err)
+			}
 		}
+		messages = append(messages, llm.Message{Role: "user", Content: fmt.Sprintf("Goal: %s\n\nPlease help me achieve this goal using read-only kubectl commands. Start by suggesting and executing the first step.", entry.Goal)})
 
 		stepCount := 0
 		maxSteps := 20 // Maximum number of steps to prevent infinite loops
@@ -205,10 +247,14 @@ Guidelines:
 			fmt.Println(strings.Repeat("=", 40))
 			fmt.Printf("📋 Step %d:\n", stepCount)
 
-			// Animated 'Thinking...' spinner
+			// Animated 'Thinking...' spinner, only in a terminal
 			thinkingDone := make(chan struct{})
 			spinnerLine := ""
+			interactive := output.StdoutIsTerminal()
 			go func() {
+				if !interactive {
+					return
+				}
 				spinner := []string{"   ", ".  ", ".. ", "..."}
 				idx := 0
 				for {
@@ -224,15 +270,24 @@ Guidelines:
 				}
 			}()
 
+			// Refresh tools, MCP servers may have reconnected or changed their tool lists
+			if tools, err = toolManager.GetAllTools(context.Background()); err != nil {
+				close(thinkingDone)
+				return fmt.Errorf("failed to get available tools: %w", err)
+			}
+
 			// Get response from LLM with tools
 			response, err := unifiedProvider.ChatWithTools(context.Background(), messages, tools)
 			close(thinkingDone)
 			// Clear spinner line and print response in its place
-			fmt.Printf("\r%40s\r", "") // Clear spinner line
+			if interactive {
+				fmt.Printf("\r%40s\r", "") // Clear spinner line
+			}
 			if err != nil {
 				return fmt.Errorf("failed to get LLM response: %w", err)
 			}
-			fmt.Printf("💭 %s\n", response.Content)
+			fmt.Print("💭 ")
+			printer.PrintMarkdown(response.Content)
 
 			// Add LLM response to conversation history
 			assistantMsg := llm.Message{
@@ -284,6 +339,7 @@ Guidelines:
 						Command:     toolCall.Function.Arguments,
 						Output:      result,
 						Type:        "command",
+						Model:       unifiedProvider.Label(),
 					}
 
 					if err := manager.AddStep(entry, step); err != nil {
@@ -300,6 +356,7 @@ Guidelines:
 					Command:     "", // No command for LLM planning steps
 					Output:      response.Content,
 					Type:        "step",
+					Model:       unifiedProvider.Label(),
 				}
 
 				if err := manager.AddStep(entry, step); err != nil {
@@ -339,6 +396,13 @@ summarize each step taken as a simple checklist.
 e.g. "Step 02: ✅ All pods in the production namespace listed." (use cross emoji for failed tool calls)
 The last line should be a single sentence saying what was done. Followed by **DONE**.
 - be clear and concise to summarize the user's original question/command.`
+		if cfg.Kubernetes.MultiCluster() {
+			summaryPrompt += fmt.Sprintf(`
+This session spanned the kube-contexts %s. Before the last line, add a
+"Cross-cluster comparison" listing the relevant findings for each cluster,
+then what differs between the clusters and which of them show the problem.`,
+				strings.Join(cfg.Kubernetes.SessionContexts(), ", "))
+		}
 
 		messages = append(messages, llm.Message{
 			Role:    "user",
@@ -351,18 +415,74 @@ The last line should be a single sentence saying what was done. Followed by **DO
 			fmt.Println("\n==============================")
 			fmt.Println("📋 Session Summary Checklist")
 			fmt.Println("==============================")
-			fmt.Printf("%s\n", response.Content)
+			printer.PrintMarkdown(response.Content)
 			fmt.Println("==============================")
+
+			// Keep the summary for exported transcripts
+			step := history.Step{
+				Description: "Session Summary",
+				Output:      response.Content,
+				Type:        "step",
+				Model:       unifiedProvider.Label(),
+			}
+			if err := manager.AddStep(entry, step); err != nil {
+				return fmt.Errorf("failed to add step to history: %w", err)
+			}
 		}
 
 		return nil
 	},
 }
 
+// runAttachments loads the files given with --file, and standard input if
+// it is a pipe or a file, or given as "-"
+func runAttachments(files []string, confirm bool) ([]*attach.Attachment, error) {
+	var attachments []*attach.Attachment
+	explicitStdin := false
+	for _, file := range files {
+		if file == "-" {
+			explicitStdin = true
+			continue
+		}
+		attachment, err := attach.Load(kube.ExpandHome(file))
+		if err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, attachment)
+	}
+
+	if !explicitStdin && !output.StdinIsPiped() {
+		return attachments, nil
+	}
+	if confirm {
+		return nil, errors.New("--confirm reads approvals from standard input, so it can't be piped.\nHint: save the input to a file and attach it with -f <file>")
+	}
+	attachment, err := attach.Read("stdin", os.Stdin)
+	if errors.Is(err, attach.ErrEmpty) && !explicitStdin {
+		// Nothing was piped, e.g. standard input is /dev/null
+		return attachments, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return append(attachments, attachment), nil
+}
+
+// joinResources lists the resources of a manifest, one per line
+func joinResources(resources []attach.Resource) string {
+	lines := make([]string, len(resources))
+	for i, resource := range resources {
+		lines[i] = resource.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
 func init() {
-	// Command functionality is now integrated into console
-	// rootCmd.AddCommand(runCmd)
+	rootCmd.AddCommand(runCmd)
 
 	// Add confirm flag with alias a
 	runCmd.Flags().BoolP("confirm", "a", false, "Ask for confirmation before executing each tool")
+	runCmd.Flags().StringSlice("context", nil, "Kube-contexts the session spans, the first one being the default (repeatable)")
+	runCmd.Flags().StringSlice("tools", nil, "Only expose tools whose name or MCP server matches these glob patterns")
+	runCmd.Flags().StringArrayP("file", "f", nil, "Attach a manifest, log or values file as context (repeatable, - reads standard input)")
 }
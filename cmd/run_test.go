@@ -3,9 +3,12 @@ package cmd
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"k8x/internal/config"
+
+	"github.com/spf13/pflag"
 )
 
 func TestRunCommand_LoadsKubernetesConfig(t *testing.T) {
@@ -109,3 +112,97 @@ func TestRunCommandFlags(t *testing.T) {
 		t.Errorf("Confirm flag usage = %q, want %q", confirmFlag.Usage, "Ask for confirmation before executing each tool")
 	}
 }
+
+func TestRunCommandAttachments(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	manifest := filepath.Join(home, "deploy.yaml")
+	if err := os.WriteFile(manifest, []byte("apiVersion: v1\nkind: Namespace\nmetadata:\n  name: shop\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	defer rootCmd.SetArgs(nil)
+
+	// Nothing is piped, whatever go test's standard input is
+	stdin, err := os.Open(os.DevNull)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func(original *os.File) {
+		os.Stdin = original
+		_ = stdin.Close()
+	}(os.Stdin)
+	os.Stdin = stdin
+
+	tests := []struct {
+		name    string
+		files   []string
+		wantErr string
+	}{
+		// A missing file stops the run before anything else
+		{"missing file", []string{manifest, filepath.Join(home, "missing.yaml")}, "missing.yaml"},
+		// Attachments are read, then the run stops at the missing configuration
+		{"attached file", []string{manifest}, "not configured"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := runCmd.Flags().Lookup("file").Value.(pflag.SliceValue).Replace(nil); err != nil {
+				t.Fatal(err)
+			}
+			args := []string{"run", "why won't this deploy?"}
+			for _, file := range tt.files {
+				args = append(args, "-f", file)
+			}
+			rootCmd.SetArgs(args)
+			rootCmd.SilenceUsage, rootCmd.SilenceErrors = true, true
+			defer func() { rootCmd.SilenceUsage, rootCmd.SilenceErrors = false, false }()
+
+			err := rootCmd.Execute()
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("k8x %s error = %v, want %q", strings.Join(args, " "), err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRunAttachmentsStdin(t *testing.T) {
+	piped := filepath.Join(t.TempDir(), "piped.log")
+	if err := os.WriteFile(piped, []byte("2025-03-01T14:30:00Z ERROR connection refused\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		stdin string
+		files []string
+		want  int
+	}{
+		// Input redirected from a file is attached
+		{"file", piped, nil, 1},
+		// Devices, e.g. inherited from a wrapper, aren't read: /dev/zero
+		// would otherwise be read as a binary file
+		{"device", "/dev/zero", nil, 0},
+		{"empty device", "/dev/null", nil, 0},
+		{"explicit file", piped, []string{"-"}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdin, err := os.Open(tt.stdin)
+			if err != nil {
+				t.Skipf("can't open %s: %v", tt.stdin, err)
+			}
+			defer func(original *os.File) {
+				os.Stdin = original
+				_ = stdin.Close()
+			}(os.Stdin)
+			os.Stdin = stdin
+
+			attachments, err := runAttachments(tt.files, false)
+			if err != nil {
+				t.Fatalf("runAttachments() failed: %v", err)
+			}
+			if len(attachments) != tt.want {
+				t.Errorf("runAttachments() attached %d inputs, want %d", len(attachments), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,970 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"k8x/internal/config"
+	k8xcontext "k8x/internal/context"
+	"k8x/internal/history"
+	"k8x/internal/llm"
+	"k8x/internal/llm/providers"
+	"k8x/internal/mcp"
+	"k8x/internal/output"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	// tuiSideWidth is the width of the side panel with the cluster state
+	tuiSideWidth = 34
+	// tuiRefreshInterval is how often the side panel's MCP status is refreshed
+	tuiRefreshInterval = 2 * time.Second
+)
+
+// tuiPane identifies the pane that receives keys
+type tuiPane int
+
+const (
+	paneInput tuiPane = iota
+	paneConversation
+	paneSteps
+	paneOutput
+	paneCount
+)
+
+var (
+	tuiBorder        = lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(lipgloss.Color("8"))
+	tuiFocusedBorder = tuiBorder.BorderForeground(lipgloss.Color("6"))
+	tuiTitle         = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("6"))
+	tuiDim           = lipgloss.NewStyle().Foreground(lipgloss.Color("8"))
+	tuiUser          = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("4"))
+	tuiWarning       = lipgloss.NewStyle().Foreground(lipgloss.Color("3"))
+	tuiError         = lipgloss.NewStyle().Foreground(lipgloss.Color("1"))
+	tuiCursor        = lipgloss.NewStyle().Reverse(true)
+	tuiMatch         = lipgloss.NewStyle().Background(lipgloss.Color("3")).Foreground(lipgloss.Color("0"))
+)
+
+// tuiCall is a tool call in the step tree
+type tuiCall struct {
+	id          string
+	name        string
+	description string
+	output      string
+	failed      bool
+	running     bool
+}
+
+// tuiStep is an agent step in the step tree, with the tool calls it made
+type tuiStep struct {
+	number   int
+	thought  string
+	calls    []*tuiCall
+	expanded bool
+}
+
+// tuiTreeRow is a visible row of the step tree: a step, or one of its calls
+// if call isn't -1
+type tuiTreeRow struct {
+	step int
+	call int
+}
+
+// flattenSteps lists the visible rows of the step tree, leaving out the
+// calls of collapsed steps
+func flattenSteps(steps []*tuiStep) []tuiTreeRow {
+	var rows []tuiTreeRow
+	for i, step := range steps {
+		rows = append(rows, tuiTreeRow{step: i, call: -1})
+		if !step.expanded {
+			continue
+		}
+		for j := range step.calls {
+			rows = append(rows, tuiTreeRow{step: i, call: j})
+		}
+	}
+	return rows
+}
+
+// searchMatches returns the indexes of the lines containing query, ignoring
+// case
+func searchMatches(lines []string, query string) []int {
+	if query == "" {
+		return nil
+	}
+	query = strings.ToLower(query)
+
+	var matches []int
+	for i, line := range lines {
+		if strings.Contains(strings.ToLower(line), query) {
+			matches = append(matches, i)
+		}
+	}
+	return matches
+}
+
+// Events of the agent loop, sent to the TUI by tuiObserver
+type (
+	agentStepMsg    struct{ step int }
+	agentThoughtMsg struct {
+		step    int
+		content string
+		usage   *llm.Usage
+	}
+	agentCallStartedMsg struct {
+		step int
+		call llm.ToolCall
+	}
+	agentCallFinishedMsg struct {
+		step   int
+		call   llm.ToolCall
+		result string
+		err    error
+	}
+	agentWarningMsg struct{ message string }
+	agentDoneMsg    struct{ err error }
+	// tuiApprovalMsg asks for a tool call's approval, answered on reply
+	tuiApprovalMsg struct {
+		request llm.ApprovalRequest
+		reply   chan llm.ApprovalDecision
+	}
+	tuiTickMsg time.Time
+)
+
+// tuiObserver forwards the events of the agent loop to the TUI
+type tuiObserver struct {
+	send func(tea.Msg)
+}
+
+func (o *tuiObserver) StepStarted(step int) {
+	o.send(agentStepMsg{step: step})
+}
+
+func (o *tuiObserver) Thought(step int, content string, usage *llm.Usage) {
+	o.send(agentThoughtMsg{step: step, content: content, usage: usage})
+}
+
+func (o *tuiObserver) ToolCallStarted(step int, call llm.ToolCall) {
+	o.send(agentCallStartedMsg{step: step, call: call})
+}
+
+func (o *tuiObserver) ToolCallFinished(step int, call llm.ToolCall, result string, err error) {
+	o.send(agentCallFinishedMsg{step: step, call: call, result: result, err: err})
+}
+
+func (o *tuiObserver) Warning(message string) {
+	o.send(agentWarningMsg{message: message})
+}
+
+func (o *tuiObserver) StepLimitReached(limit int) {
+	o.send(agentWarningMsg{message: fmt.Sprintf("Reached maximum steps (%d) for this goal. You can continue with another request.", limit)})
+}
+
+// tuiApprover asks for approvals in the TUI, blocking the agent loop until
+// the user answers
+func tuiApprover(send func(tea.Msg)) llm.Approver {
+	return func(request llm.ApprovalRequest) llm.ApprovalDecision {
+		reply := make(chan llm.ApprovalDecision, 1)
+		send(tuiApprovalMsg{request: request, reply: reply})
+		return <-reply
+	}
+}
+
+// tuiApproval is an approval request the user is answering
+type tuiApproval struct {
+	request llm.ApprovalRequest
+	reply   chan llm.ApprovalDecision
+	// choice is ApprovalEdit or ApprovalAllowPattern while the input holds
+	// the edited call or the pattern, ApprovalDeny while choosing
+	choice llm.ApprovalChoice
+}
+
+// tuiModel is the full-screen console: a conversation pane, the step tree,
+// an output viewer and a side panel with the cluster state
+type tuiModel struct {
+	provider       *providers.UnifiedProvider
+	toolManager    *llm.MCPToolManager
+	historyManager *history.Manager
+	cfg            *config.Config
+	send           func(tea.Msg)
+
+	// Conversation state, owned by the agent loop while busy
+	messages     []llm.Message
+	stepCount    int
+	systemPrompt string
+	busy         bool
+
+	width, height int
+	focus         tuiPane
+	input         textinput.Model
+	conversation  viewport.Model
+	outputView    viewport.Model
+
+	transcript []string
+	steps      []*tuiStep
+	cursor     int
+	// selected is the call shown in the output viewer, nil to follow the
+	// latest call
+	selected *tuiCall
+
+	searchInput textinput.Model
+	searching   bool
+	query       string
+	matches     []int
+	match       int
+
+	approval  *tuiApproval
+	usage     llm.Usage
+	mcpStates map[string]mcp.ServerStatus
+	quitting  bool
+}
+
+// newTUIModel creates the TUI for a conversation starting with systemPrompt
+func newTUIModel(provider *providers.UnifiedProvider, toolManager *llm.MCPToolManager, historyManager *history.Manager, cfg *config.Config, systemPrompt string) *tuiModel {
+	input := textinput.New()
+	input.Placeholder = "Ask about your cluster, or /help"
+	input.Prompt = "> "
+	input.Focus()
+
+	searchInput := textinput.New()
+	searchInput.Prompt = "/"
+
+	return &tuiModel{
+		provider:       provider,
+		toolManager:    toolManager,
+		historyManager: historyManager,
+		cfg:            cfg,
+		send:           func(tea.Msg) {},
+		systemPrompt:   systemPrompt,
+		messages:       []llm.Message{{Role: "system", Content: systemPrompt}},
+		input:          input,
+		searchInput:    searchInput,
+		conversation:   viewport.New(0, 0),
+		outputView:     viewport.New(0, 0),
+	}
+}
+
+// Init implements tea.Model
+func (m *tuiModel) Init() tea.Cmd {
+	m.refreshMCPStates()
+	return tea.Batch(textinput.Blink, tuiTick())
+}
+
+// tuiTick schedules the next refresh of the side panel
+func tuiTick() tea.Cmd {
+	return tea.Tick(tuiRefreshInterval, func(t time.Time) tea.Msg { return tuiTickMsg(t) })
+}
+
+// Update implements tea.Model
+func (m *tuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		m.layout()
+		return m, nil
+	case tuiTickMsg:
+		m.refreshMCPStates()
+		return m, tuiTick()
+	case tea.KeyMsg:
+		return m.handleKey(msg)
+	case tuiApprovalMsg:
+		m.approval = &tuiApproval{request: msg.request, reply: msg.reply}
+		m.focusPane(paneInput)
+		return m, nil
+	case agentStepMsg:
+		for _, step := range m.steps {
+			step.expanded = false
+		}
+		m.steps = append(m.steps, &tuiStep{number: msg.step, expanded: true})
+		m.cursor = len(flattenSteps(m.steps)) - 1
+	case agentThoughtMsg:
+		if step := m.step(msg.step); step != nil {
+			step.thought = msg.content
+		}
+		if msg.usage != nil {
+			m.usage.PromptTokens += msg.usage.PromptTokens
+			m.usage.CompletionTokens += msg.usage.CompletionTokens
+			m.usage.TotalTokens += msg.usage.TotalTokens
+		}
+		m.appendTranscript(fmt.Sprintf("💭 Step %d\n%s", msg.step, output.RenderMarkdown(msg.content, output.ColorEnabled())))
+	case agentCallStartedMsg:
+		if step := m.step(msg.step); step != nil {
+			step.calls = append(step.calls, &tuiCall{
+				id:          msg.call.ID,
+				name:        msg.call.Function.Name,
+				description: describeToolCall(msg.call),
+				running:     true,
+			})
+			m.cursor = len(flattenSteps(m.steps)) - 1
+		}
+	case agentCallFinishedMsg:
+		if call := m.runningCall(msg.step, msg.call.ID); call != nil {
+			call.running = false
+			call.output = output.FilterSecrets(msg.result)
+			if msg.err != nil {
+				call.failed = true
+				call.output = fmt.Sprintf("Error: %v", msg.err)
+			}
+		}
+	case agentWarningMsg:
+		m.appendTranscript(tuiWarning.Render("⚠️  " + msg.message))
+	case agentDoneMsg:
+		m.busy = false
+		if msg.err != nil {
+			m.appendTranscript(tuiError.Render(fmt.Sprintf("❌ Error: %v", msg.err)))
+		}
+	}
+
+	m.refreshOutput()
+	return m, nil
+}
+
+// handleKey handles a key press in the focused pane
+func (m *tuiModel) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if msg.Type == tea.KeyCtrlC {
+		if m.approval != nil {
+			m.approval.reply <- llm.ApprovalDecision{Choice: llm.ApprovalDeny}
+		}
+		m.quitting = true
+		return m, tea.Quit
+	}
+	if m.approval != nil {
+		return m.handleApprovalKey(msg)
+	}
+	if m.searching {
+		return m.handleSearchKey(msg)
+	}
+
+	switch msg.Type {
+	case tea.KeyTab:
+		m.focusPane((m.focus + 1) % paneCount)
+		return m, nil
+	case tea.KeyShiftTab:
+		m.focusPane((m.focus + paneCount - 1) % paneCount)
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	switch m.focus {
+	case paneInput:
+		if msg.Type == tea.KeyEnter {
+			return m, m.submit(strings.TrimSpace(m.input.Value()))
+		}
+		m.input, cmd = m.input.Update(msg)
+	case paneConversation:
+		m.conversation, cmd = m.conversation.Update(msg)
+	case paneSteps:
+		m.handleStepsKey(msg)
+	case paneOutput:
+		switch msg.String() {
+		case "/":
+			m.searching = true
+			m.searchInput.SetValue("")
+			return m, m.searchInput.Focus()
+		case "n":
+			m.jumpToMatch(m.match + 1)
+		case "N":
+			m.jumpToMatch(m.match - 1)
+		case "f":
+			m.selected = nil
+			m.refreshOutput()
+		case "esc":
+			m.query, m.matches = "", nil
+			m.refreshOutput()
+		default:
+			m.outputView, cmd = m.outputView.Update(msg)
+		}
+	}
+	return m, cmd
+}
+
+// handleStepsKey moves through the step tree, expands and collapses steps
+// and selects the call shown in the output viewer
+func (m *tuiModel) handleStepsKey(msg tea.KeyMsg) {
+	rows := flattenSteps(m.steps)
+	if len(rows) == 0 {
+		return
+	}
+
+	switch msg.String() {
+	case "up", "k":
+		m.cursor = max(m.cursor-1, 0)
+	case "down", "j":
+		m.cursor = min(m.cursor+1, len(rows)-1)
+	case "left", "h":
+		// Collapse the step of the row under the cursor
+		step := m.steps[rows[m.cursor].step]
+		step.expanded = false
+		m.cursor = m.stepRow(rows[m.cursor].step)
+	case "right", "l":
+		m.steps[rows[m.cursor].step].expanded = true
+	case "enter", " ":
+		row := rows[m.cursor]
+		step := m.steps[row.step]
+		if row.call == -1 {
+			step.expanded = !step.expanded
+		} else {
+			m.selected = step.calls[row.call]
+			m.refreshOutput()
+			m.outputView.GotoTop()
+		}
+	case "f":
+		m.selected = nil
+		m.refreshOutput()
+	}
+}
+
+// stepRow is the row of the step tree showing the step at index
+func (m *tuiModel) stepRow(index int) int {
+	for i, row := range flattenSteps(m.steps) {
+		if row.step == index && row.call == -1 {
+			return i
+		}
+	}
+	return 0
+}
+
+// handleSearchKey edits the output viewer's search query
+func (m *tuiModel) handleSearchKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEnter:
+		m.searching = false
+		m.searchInput.Blur()
+		m.query = m.searchInput.Value()
+		m.refreshOutput()
+		m.jumpToMatch(0)
+		return m, nil
+	case tea.KeyEsc:
+		m.searching = false
+		m.searchInput.Blur()
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.searchInput, cmd = m.searchInput.Update(msg)
+	return m, cmd
+}
+
+// handleApprovalKey answers the pending approval request
+func (m *tuiModel) handleApprovalKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	approval := m.approval
+
+	// Editing the call or the pattern
+	if approval.choice != llm.ApprovalDeny {
+		switch msg.Type {
+		case tea.KeyEnter:
+			text := strings.TrimSpace(m.input.Value())
+			decision := llm.ApprovalDecision{Choice: llm.ApprovalDeny}
+			if text != "" && approval.choice == llm.ApprovalEdit {
+				decision = llm.ApprovalDecision{Choice: llm.ApprovalEdit, Edited: text}
+			} else if text != "" {
+				decision = llm.ApprovalDecision{Choice: llm.ApprovalAllowPattern, Pattern: text}
+			}
+			m.answerApproval(decision)
+			return m, nil
+		case tea.KeyEsc:
+			m.answerApproval(llm.ApprovalDecision{Choice: llm.ApprovalDeny})
+			return m, nil
+		}
+		var cmd tea.Cmd
+		m.input, cmd = m.input.Update(msg)
+		return m, cmd
+	}
+
+	switch msg.String() {
+	case "y":
+		m.answerApproval(llm.ApprovalDecision{Choice: llm.ApprovalApprove})
+	case "n", "esc":
+		m.answerApproval(llm.ApprovalDecision{Choice: llm.ApprovalDeny})
+	case "e":
+		approval.choice = llm.ApprovalEdit
+		m.input.SetValue(approval.request.Editable)
+		m.input.CursorEnd()
+	case "a":
+		if !approval.request.Destructive {
+			approval.choice = llm.ApprovalAllowPattern
+			m.input.SetValue(llm.SuggestApprovalPattern(approval.request.Subject))
+			m.input.CursorEnd()
+		}
+	}
+	return m, nil
+}
+
+// answerApproval resumes the agent loop with the user's decision
+func (m *tuiModel) answerApproval(decision llm.ApprovalDecision) {
+	if decision.Choice == llm.ApprovalDeny {
+		m.appendTranscript(tuiWarning.Render("🚫 Denied: " + m.approval.request.Display))
+	}
+	m.approval.reply <- decision
+	m.approval = nil
+	m.input.SetValue("")
+}
+
+// submit runs a slash command or starts working on a goal
+func (m *tuiModel) submit(input string) tea.Cmd {
+	if input == "" {
+		return nil
+	}
+	if m.busy {
+		m.notice("⏳ Still working on the previous request")
+		return nil
+	}
+	m.input.SetValue("")
+
+	if _, ok := manualCommand(input); ok {
+		m.notice(tuiError.Render("❌ !<command> and /run are available in the line-based console (k8x without --tui)"))
+		return nil
+	}
+	if strings.HasPrefix(input, "/") {
+		return m.handleSlashCommand(input)
+	}
+
+	m.busy = true
+	m.appendTranscript(tuiUser.Render("> ") + input)
+	observer := &tuiObserver{send: m.send}
+	return func() tea.Msg {
+		_, err := runAgentGoal(input, m.provider, m.toolManager, m.cfg, m.historyManager, &m.messages, &m.stepCount, observer)
+		return agentDoneMsg{err: err}
+	}
+}
+
+// handleSlashCommand runs the slash commands available in the TUI
+func (m *tuiModel) handleSlashCommand(input string) tea.Cmd {
+	parts := strings.Fields(input)
+	switch strings.ToLower(parts[0]) {
+	case "/exit", "/quit", "/q":
+		m.quitting = true
+		return tea.Quit
+	case "/clear", "/cls":
+		m.messages = []llm.Message{{Role: "system", Content: m.systemPrompt}}
+		m.stepCount = 0
+		m.steps, m.cursor, m.selected = nil, 0, nil
+		m.transcript = nil
+		m.notice("🔄 Conversation history cleared. Starting fresh!")
+		m.refreshOutput()
+	case "/confirm":
+		var out strings.Builder
+		if err := handleConfirmCommand(parts[1:], m.toolManager, m.cfg, &out); err != nil {
+			m.notice(tuiError.Render(fmt.Sprintf("❌ Error: %v", err)))
+		} else {
+			m.notice(strings.TrimRight(out.String(), "\n"))
+		}
+	case "/attach":
+		var out strings.Builder
+		if err := handleAttachCommand(parts[1:], &m.messages, &out); err != nil {
+			m.notice(tuiError.Render(fmt.Sprintf("❌ Error: %v", err)))
+		} else {
+			m.notice(strings.TrimRight(out.String(), "\n"))
+		}
+	case "/export":
+		var out strings.Builder
+		environment := sessionEnvironment(m.provider.Label(), m.toolManager.KubernetesConfig())
+		if err := handleExportCommand(parts[1:], m.messages, environment, &out); err != nil {
+			m.notice(tuiError.Render(fmt.Sprintf("❌ Error: %v", err)))
+		} else {
+			m.notice(strings.TrimRight(out.String(), "\n"))
+		}
+	case "/provider", "/model":
+		var out strings.Builder
+		creds, err := loadProviderCredentials()
+		if err == nil && strings.ToLower(parts[0]) == "/provider" {
+			err = handleProviderCommand(parts[1:], m.provider, m.cfg, creds, &out)
+		} else if err == nil {
+			err = handleModelCommand(parts[1:], m.provider, m.cfg, creds, &out)
+		}
+		if err != nil {
+			m.notice(tuiError.Render(fmt.Sprintf("❌ Error: %v", err)))
+		} else {
+			m.notice(strings.TrimRight(out.String(), "\n"))
+		}
+	case "/help", "/h":
+		m.notice(strings.Join([]string{
+			"Commands: /attach <file>..., /confirm [always|non-kubectl|never|clear], /export <file> [--format md|html|json], /provider [name [model]], /model [[provider/]name], /clear, /exit",
+			"Keys: Tab/Shift+Tab switch panes, Ctrl+C quits",
+			"Steps: ↑/↓ move, Enter expands a step or shows a call's output, ← collapses, f follows the latest call",
+			"Output: ↑/↓/PgUp/PgDn scroll, / searches, n/N jump between matches, Esc clears the search",
+			"Other console commands are available in the line-based console (k8x without --tui).",
+		}, "\n"))
+	default:
+		m.notice(tuiError.Render(fmt.Sprintf("❌ %s isn't available in the TUI (type /help for available commands)", parts[0])))
+	}
+	return nil
+}
+
+// focusPane moves the focus to pane
+func (m *tuiModel) focusPane(pane tuiPane) {
+	m.focus = pane
+	if pane == paneInput {
+		m.input.Focus()
+	} else {
+		m.input.Blur()
+	}
+}
+
+// step returns the step with number, nil if there is none
+func (m *tuiModel) step(number int) *tuiStep {
+	for i := len(m.steps) - 1; i >= 0; i-- {
+		if m.steps[i].number == number {
+			return m.steps[i]
+		}
+	}
+	return nil
+}
+
+// runningCall returns the running call with id in the step with number,
+// or the first running call there if no ID matches
+func (m *tuiModel) runningCall(number int, id string) *tuiCall {
+	step := m.step(number)
+	if step == nil {
+		return nil
+	}
+
+	var first *tuiCall
+	for _, call := range step.calls {
+		if !call.running {
+			continue
+		}
+		if call.id == id {
+			return call
+		}
+		if first == nil {
+			first = call
+		}
+	}
+	return first
+}
+
+// latestCall returns the most recent tool call, nil if there is none
+func (m *tuiModel) latestCall() *tuiCall {
+	for i := len(m.steps) - 1; i >= 0; i-- {
+		if calls := m.steps[i].calls; len(calls) > 0 {
+			return calls[len(calls)-1]
+		}
+	}
+	return nil
+}
+
+// notice adds a message from k8x to the conversation
+func (m *tuiModel) notice(text string) {
+	m.appendTranscript(tuiDim.Render(text))
+}
+
+// appendTranscript adds an entry to the conversation and scrolls to it
+func (m *tuiModel) appendTranscript(entry string) {
+	m.transcript = append(m.transcript, entry)
+	m.refreshConversation()
+	m.conversation.GotoBottom()
+}
+
+// refreshConversation wraps the conversation to the pane's width
+func (m *tuiModel) refreshConversation() {
+	text := strings.Join(m.transcript, "\n\n")
+	if m.conversation.Width > 0 {
+		text = lipgloss.NewStyle().Width(m.conversation.Width).Render(text)
+	}
+	m.conversation.SetContent(text)
+}
+
+// refreshOutput shows the selected call, or the latest one, in the output
+// viewer with the lines matching the search query highlighted
+func (m *tuiModel) refreshOutput() {
+	call := m.selected
+	if call == nil {
+		call = m.latestCall()
+	}
+
+	var text string
+	switch {
+	case call == nil:
+		text = "No tool calls yet"
+	case call.running:
+		text = "⏳ Running..."
+	case call.output == "":
+		text = "(no output)"
+	default:
+		text = call.output
+	}
+	if m.outputView.Width > 0 {
+		text = lipgloss.NewStyle().Width(m.outputView.Width).Render(text)
+	}
+
+	lines := strings.Split(text, "\n")
+	m.matches = searchMatches(lines, m.query)
+	for _, i := range m.matches {
+		lines[i] = tuiMatch.Render(lines[i])
+	}
+	m.outputView.SetContent(strings.Join(lines, "\n"))
+	if m.selected == nil && m.query == "" {
+		m.outputView.GotoBottom()
+	}
+}
+
+// jumpToMatch scrolls the output viewer to the search match at index,
+// wrapping around at either end
+func (m *tuiModel) jumpToMatch(index int) {
+	if len(m.matches) == 0 {
+		return
+	}
+	m.match = (index%len(m.matches) + len(m.matches)) % len(m.matches)
+	m.outputView.SetYOffset(m.matches[m.match])
+}
+
+// refreshMCPStates reads the status of the MCP servers for the side panel
+func (m *tuiModel) refreshMCPStates() {
+	if m.cfg.MCP.Enabled {
+		m.mcpStates = m.toolManager.GetMCPServerStates()
+	}
+}
+
+// layout sizes the panes to the terminal
+func (m *tuiModel) layout() {
+	mainWidth := m.width - tuiSideWidth
+	bodyHeight := m.height - 3
+	conversationHeight := bodyHeight / 2
+	lowerHeight := bodyHeight - conversationHeight
+	stepsWidth := mainWidth * 2 / 5
+
+	// Borders take two lines and columns, the pane title another line
+	m.conversation.Width = max(mainWidth-2, 1)
+	m.conversation.Height = max(conversationHeight-3, 1)
+	m.outputView.Width = max(mainWidth-stepsWidth-2, 1)
+	m.outputView.Height = max(lowerHeight-3, 1)
+	m.input.Width = max(mainWidth-6, 1)
+	m.searchInput.Width = max(mainWidth-stepsWidth-4, 1)
+
+	m.refreshConversation()
+	m.conversation.GotoBottom()
+	m.refreshOutput()
+}
+
+// View implements tea.Model
+func (m *tuiModel) View() string {
+	if m.quitting {
+		return ""
+	}
+	if m.width == 0 {
+		return "Starting k8x..."
+	}
+
+	mainWidth := m.width - tuiSideWidth
+	bodyHeight := m.height - 3
+	conversationHeight := bodyHeight / 2
+	lowerHeight := bodyHeight - conversationHeight
+	stepsWidth := mainWidth * 2 / 5
+
+	conversation := m.pane(paneConversation, "Conversation", m.conversation.View(), mainWidth, conversationHeight)
+	steps := m.pane(paneSteps, "Steps", m.renderSteps(stepsWidth-2, lowerHeight-3), stepsWidth, lowerHeight)
+	outputTitle := "Output"
+	if m.selected == nil {
+		outputTitle += " (following)"
+	}
+	outputBody := m.outputView.View()
+	if m.searching {
+		outputTitle += "  " + m.searchInput.View()
+	} else if m.query != "" {
+		outputTitle += fmt.Sprintf("  /%s [%d matches]", m.query, len(m.matches))
+	}
+	outputPane := m.pane(paneOutput, outputTitle, outputBody, mainWidth-stepsWidth, lowerHeight)
+
+	main := lipgloss.JoinVertical(lipgloss.Left,
+		conversation,
+		lipgloss.JoinHorizontal(lipgloss.Top, steps, outputPane),
+		m.renderInput(mainWidth),
+	)
+	return lipgloss.JoinHorizontal(lipgloss.Top, main, m.renderSidePanel(m.height))
+}
+
+// pane draws a bordered pane, highlighted when it has the focus
+func (m *tuiModel) pane(pane tuiPane, title, body string, width, height int) string {
+	style := tuiBorder
+	if m.focus == pane {
+		style = tuiFocusedBorder
+	}
+	content := lipgloss.NewStyle().MaxWidth(max(width-2, 1)).Render(tuiTitle.Render(title)) + "\n" + body
+	return style.Width(max(width-2, 1)).Height(max(height-2, 1)).MaxHeight(height).Render(content)
+}
+
+// renderSteps draws the visible part of the step tree
+func (m *tuiModel) renderSteps(width, height int) string {
+	rows := flattenSteps(m.steps)
+	if len(rows) == 0 {
+		return tuiDim.Render("No steps yet")
+	}
+
+	// Scroll to keep the cursor visible
+	start := 0
+	if m.cursor >= height {
+		start = m.cursor - height + 1
+	}
+
+	var lines []string
+	for i := start; i < len(rows) && i < start+height; i++ {
+		row := rows[i]
+		step := m.steps[row.step]
+
+		var line string
+		if row.call == -1 {
+			marker := "▸"
+			if step.expanded {
+				marker = "▾"
+			}
+			line = fmt.Sprintf("%s Step %d (%d calls)", marker, step.number, len(step.calls))
+		} else {
+			call := step.calls[row.call]
+			status := "✅"
+			switch {
+			case call.running:
+				status = "⏳"
+			case call.failed:
+				status = "❌"
+			}
+			line = fmt.Sprintf("   %s %s %s", status, call.name, strings.TrimPrefix(call.description, "Command: "))
+			if call == m.selected {
+				line = strings.Replace(line, status, status+"▶", 1)
+			}
+		}
+
+		line = truncate(line, width)
+		if i == m.cursor && m.focus == paneSteps {
+			line = tuiCursor.Render(line)
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// renderInput draws the input line, or the pending approval request
+func (m *tuiModel) renderInput(width int) string {
+	style := tuiBorder
+	if m.focus == paneInput {
+		style = tuiFocusedBorder
+	}
+
+	body := m.input.View()
+	if approval := m.approval; approval != nil {
+		switch approval.choice {
+		case llm.ApprovalEdit:
+			body = "Edit (Enter runs, Esc denies): " + m.input.View()
+		case llm.ApprovalAllowPattern:
+			body = "Allow pattern (Enter allows, Esc denies): " + m.input.View()
+		default:
+			answers := "[y]es / [n]o / [e]dit / [a]lways allow matching calls"
+			if approval.request.Destructive {
+				answers = "[y]es / [n]o / [e]dit"
+			}
+			body = tuiWarning.Render("🔍 "+truncate(approval.request.Display, width-len(answers)-10)) + "  " + answers
+		}
+	} else if m.busy {
+		body = tuiDim.Render("⏳ Working... ") + m.input.View()
+	}
+	return style.Width(max(width-2, 1)).MaxHeight(3).Render(truncateStyled(body, width-2))
+}
+
+// renderSidePanel draws the current context, approval mode, token usage
+// and MCP server status
+func (m *tuiModel) renderSidePanel(height int) string {
+	kubeContext := k8xcontext.CurrentKubeContext(&m.cfg.Kubernetes)
+	namespace := k8xcontext.CurrentNamespace(&m.cfg.Kubernetes)
+	if kubeContext == "" {
+		kubeContext = "-"
+	}
+	if namespace == "" {
+		namespace = "default"
+	}
+
+	lines := []string{
+		tuiTitle.Render("Cluster"),
+		"Context:   " + kubeContext,
+		"Namespace: " + namespace,
+		"Approval:  " + string(m.toolManager.ApprovalMode()),
+		"",
+		tuiTitle.Render("Tokens"),
+		fmt.Sprintf("Prompt:     %d", m.usage.PromptTokens),
+		fmt.Sprintf("Completion: %d", m.usage.CompletionTokens),
+		fmt.Sprintf("Total:      %d", m.usage.TotalTokens),
+		"",
+		tuiTitle.Render("MCP Servers"),
+	}
+
+	switch {
+	case !m.cfg.MCP.Enabled:
+		lines = append(lines, tuiDim.Render("disabled"))
+	case len(m.mcpStates) == 0:
+		lines = append(lines, tuiDim.Render("none configured"))
+	default:
+		names := make([]string, 0, len(m.mcpStates))
+		for name := range m.mcpStates {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			state := m.mcpStates[name]
+			status := "❌"
+			switch {
+			case state.Connected:
+				status = "✅"
+			case state.Reconnecting:
+				status = "🔄"
+			}
+			lines = append(lines, status+" "+name)
+		}
+	}
+
+	status := "idle"
+	switch {
+	case m.approval != nil:
+		status = "awaiting approval"
+	case m.busy:
+		status = "working"
+	}
+	lines = append(lines, "", tuiTitle.Render("Status"), status)
+
+	for i, line := range lines {
+		lines[i] = truncateStyled(line, tuiSideWidth-2)
+	}
+	return tuiBorder.Width(tuiSideWidth - 2).Height(max(height-2, 1)).MaxHeight(height).Render(strings.Join(lines, "\n"))
+}
+
+// truncate shortens text to width characters, ending it with … if cut
+func truncate(text string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	runes := []rune(text)
+	if lipgloss.Width(text) <= width {
+		return text
+	}
+	for len(runes) > 0 && lipgloss.Width(string(runes)) > width-1 {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "…"
+}
+
+// truncateStyled shortens text that may contain color codes to width columns
+func truncateStyled(text string, width int) string {
+	return lipgloss.NewStyle().MaxWidth(max(width, 1)).Render(text)
+}
+
+// runTUI runs the full-screen console until the user quits
+func runTUI(provider *providers.UnifiedProvider, toolManager *llm.MCPToolManager, cfg *config.Config) error {
+	historyManager, _ := history.NewManager()
+
+	// Gather the cluster context before taking over the screen
+	systemPrompt := buildSystemPrompt(gatherConsoleContext(toolManager, cfg, false, output.NewPrinter(true)))
+
+	model := newTUIModel(provider, toolManager, historyManager, cfg, systemPrompt)
+	model.notice(fmt.Sprintf("🤖 Using LLM provider: %s. Tab switches panes, /help lists commands.", provider.Label()))
+	program := tea.NewProgram(model, tea.WithAltScreen())
+	model.send = program.Send
+	toolManager.SetApprover(tuiApprover(program.Send))
+
+	if _, err := program.Run(); err != nil {
+		return fmt.Errorf("failed to run the TUI: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package cmd
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"k8x/internal/config"
+	"k8x/internal/llm"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestFlattenSteps(t *testing.T) {
+	steps := []*tuiStep{
+		{number: 1, calls: []*tuiCall{{name: "a"}, {name: "b"}}},
+		{number: 2, calls: []*tuiCall{{name: "c"}}, expanded: true},
+		{number: 3, expanded: true},
+	}
+
+	want := []tuiTreeRow{{0, -1}, {1, -1}, {1, 0}, {2, -1}}
+	if got := flattenSteps(steps); !reflect.DeepEqual(got, want) {
+		t.Errorf("flattenSteps() = %v, want %v", got, want)
+	}
+
+	steps[0].expanded = true
+	want = []tuiTreeRow{{0, -1}, {0, 0}, {0, 1}, {1, -1}, {1, 0}, {2, -1}}
+	if got := flattenSteps(steps); !reflect.DeepEqual(got, want) {
+		t.Errorf("flattenSteps() after expanding = %v, want %v", got, want)
+	}
+}
+
+func TestSearchMatches(t *testing.T) {
+	lines := []string{"NAME READY STATUS", "api-1 0/1 CrashLoopBackOff", "web-1 1/1 Running", "api-2 0/1 crashloopbackoff"}
+
+	tests := []struct {
+		query string
+		want  []int
+	}{
+		{"CrashLoop", []int{1, 3}},
+		{"running", []int{2}},
+		{"pending", nil},
+		{"", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			if got := searchMatches(lines, tt.query); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("searchMatches(%q) = %v, want %v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTUIModelFollowsAgent(t *testing.T) {
+	m := newTUIModel(nil, nil, nil, &config.Config{}, "system")
+	m.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+
+	var events []tea.Msg
+	observer := &tuiObserver{send: func(msg tea.Msg) { events = append(events, msg) }}
+	call := llm.ToolCall{ID: "call-1"}
+	call.Function.Name = "execute_shell_command"
+	call.Function.Arguments = `{"command":"kubectl get pods"}`
+
+	observer.StepStarted(1)
+	observer.Thought(1, "Listing pods", &llm.Usage{PromptTokens: 100, CompletionTokens: 20, TotalTokens: 120})
+	observer.ToolCallStarted(1, call)
+	for _, msg := range events {
+		m.Update(msg)
+	}
+	events = nil
+
+	if len(m.steps) != 1 || len(m.steps[0].calls) != 1 || !m.steps[0].calls[0].running {
+		t.Fatalf("steps after starting a call = %+v, want one step with a running call", m.steps)
+	}
+	if !strings.Contains(m.outputView.View(), "Running") {
+		t.Errorf("output viewer doesn't show the running call: %q", m.outputView.View())
+	}
+
+	observer.ToolCallFinished(1, call, "api-1 CrashLoopBackOff", nil)
+	observer.StepStarted(2)
+	observer.Thought(2, "**DONE**", &llm.Usage{PromptTokens: 200, CompletionTokens: 10, TotalTokens: 210})
+	for _, msg := range events {
+		m.Update(msg)
+	}
+
+	first := m.steps[0].calls[0]
+	if first.running || first.output != "api-1 CrashLoopBackOff" {
+		t.Errorf("finished call = %+v, want its output", first)
+	}
+	if m.steps[0].expanded || !m.steps[1].expanded {
+		t.Errorf("only the latest step should be expanded")
+	}
+	if m.usage.TotalTokens != 330 || m.usage.PromptTokens != 300 {
+		t.Errorf("usage = %+v, want the sum of both responses", m.usage)
+	}
+
+	// Search the followed output
+	m.focusPane(paneOutput)
+	m.query = "crashloop"
+	m.refreshOutput()
+	if !reflect.DeepEqual(m.matches, []int{0}) {
+		t.Errorf("matches = %v, want [0]", m.matches)
+	}
+
+	m.Update(agentDoneMsg{err: errors.New("boom")})
+	if m.busy || !strings.Contains(m.transcript[len(m.transcript)-1], "boom") {
+		t.Errorf("agent error not shown: %q", m.transcript[len(m.transcript)-1])
+	}
+}
+
+func TestTUIApproval(t *testing.T) {
+	m := newTUIModel(nil, nil, nil, &config.Config{}, "system")
+	request := llm.ApprovalRequest{Tool: "execute_shell_command", Subject: "kubectl get pods -A", Display: "kubectl get pods -A", Editable: "kubectl get pods -A"}
+
+	tests := []struct {
+		name string
+		keys []tea.KeyMsg
+		want llm.ApprovalDecision
+	}{
+		{"approve", []tea.KeyMsg{{Type: tea.KeyRunes, Runes: []rune("y")}}, llm.ApprovalDecision{Choice: llm.ApprovalApprove}},
+		{"deny", []tea.KeyMsg{{Type: tea.KeyEsc}}, llm.ApprovalDecision{Choice: llm.ApprovalDeny}},
+		{"allow pattern", []tea.KeyMsg{{Type: tea.KeyRunes, Runes: []rune("a")}, {Type: tea.KeyEnter}}, llm.ApprovalDecision{Choice: llm.ApprovalAllowPattern, Pattern: "kubectl get *"}},
+		{"edit", []tea.KeyMsg{{Type: tea.KeyRunes, Runes: []rune("e")}, {Type: tea.KeyBackspace}, {Type: tea.KeyBackspace}, {Type: tea.KeyEnter}}, llm.ApprovalDecision{Choice: llm.ApprovalEdit, Edited: "kubectl get pods"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reply := make(chan llm.ApprovalDecision, 1)
+			m.Update(tuiApprovalMsg{request: request, reply: reply})
+			for _, key := range tt.keys {
+				m.Update(key)
+			}
+
+			select {
+			case got := <-reply:
+				if got != tt.want {
+					t.Errorf("decision = %+v, want %+v", got, tt.want)
+				}
+			default:
+				t.Fatal("no decision sent")
+			}
+			if m.approval != nil {
+				t.Error("approval still pending")
+			}
+		})
+	}
+}
@@ -0,0 +1,565 @@
+// Package attach turns local files and piped input into context for a
+// goal, so that the LLM can compare the intended state with the cluster's
+package attach
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"k8x/internal/kube"
+	"k8x/internal/output"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// MaxSize is the largest attachment accepted, in bytes
+	MaxSize = 1024 * 1024
+	// maxContentBytes is how much of an attachment's content the LLM is shown
+	maxContentBytes = 24 * 1024
+	// maxResources is how many resources of a manifest are summarized
+	maxResources = 50
+)
+
+// ErrEmpty is returned for input with nothing but whitespace
+var ErrEmpty = errors.New("nothing to attach")
+
+// Type is what an attachment contains
+type Type string
+
+const (
+	// TypeManifest is one or more Kubernetes resources, as YAML or JSON
+	TypeManifest Type = "Kubernetes manifest"
+	// TypeHelmValues is a Helm values file
+	TypeHelmValues Type = "Helm values"
+	// TypeYAML is any other YAML document
+	TypeYAML Type = "YAML"
+	// TypeJSON is any other JSON document
+	TypeJSON Type = "JSON"
+	// TypeLog is a log file
+	TypeLog Type = "log"
+	// TypeText is any other text
+	TypeText Type = "text"
+)
+
+// Attachment is a file or piped input prepared for the LLM
+type Attachment struct {
+	// Name is the file's path, or "stdin"
+	Name string
+	Type Type
+	// Size is the size of the input in bytes
+	Size int
+	// Content is the redacted input, cut to maxContentBytes: logs keep
+	// their last lines, other types their beginning
+	Content string
+	// Truncated is set if Content was cut
+	Truncated bool
+	// Resources summarizes the resources of a manifest
+	Resources []Resource
+}
+
+// Resource summarizes a resource of a manifest
+type Resource struct {
+	Kind      string
+	Name      string
+	Namespace string
+	// Details are the fields worth comparing with the live resource, e.g.
+	// replicas, images, ports and resource requests
+	Details []string
+}
+
+// String describes the resource, e.g. "Deployment shop/api: replicas 3"
+func (r Resource) String() string {
+	name := r.Name
+	if r.Namespace != "" {
+		name = r.Namespace + "/" + name
+	}
+	description := fmt.Sprintf("%s %s", r.Kind, name)
+	if len(r.Details) > 0 {
+		description += ": " + strings.Join(r.Details, "; ")
+	}
+	return description
+}
+
+// Load reads a file as an attachment
+func Load(path string) (*Attachment, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("%s is a directory, attach its files one by one", path)
+	}
+	if info.Size() > MaxSize {
+		return nil, fmt.Errorf("%s is %s, attachments are limited to %s", path, formatSize(int(info.Size())), formatSize(MaxSize))
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+	return Read(path, file)
+}
+
+// Read reads an attachment named name, such as piped input, from r
+func Read(name string, r io.Reader) (*Attachment, error) {
+	data, err := io.ReadAll(io.LimitReader(r, MaxSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	if len(data) > MaxSize {
+		return nil, fmt.Errorf("%s is larger than %s, the limit for attachments", name, formatSize(MaxSize))
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil, fmt.Errorf("%s: %w", name, ErrEmpty)
+	}
+	if bytes.IndexByte(data, 0) >= 0 || !utf8.Valid(data) {
+		return nil, fmt.Errorf("%s is a binary file, only text can be attached", name)
+	}
+
+	attachment := &Attachment{Name: name, Size: len(data)}
+	attachment.Type, attachment.Resources = detect(name, data)
+
+	content := string(data)
+	if attachment.Type == TypeManifest {
+		if redacted, ok := redactSecrets(data); ok {
+			content = redacted
+		}
+	}
+	content = output.FilterSecrets(content)
+	if len(content) > maxContentBytes {
+		attachment.Truncated = true
+		if attachment.Type == TypeLog {
+			content = strings.ToValidUTF8(content[len(content)-maxContentBytes:], "")
+			if i := strings.IndexByte(content, '\n'); i >= 0 {
+				content = content[i+1:]
+			}
+		} else {
+			content = strings.ToValidUTF8(content[:maxContentBytes], "")
+		}
+	}
+	attachment.Content = content
+	return attachment, nil
+}
+
+// timestampPattern matches the timestamps log lines usually start with,
+// e.g. 2025-01-02T15:04:05Z, 2025/01/02 15:04:05, Jan  2 15:04:05 or
+// klog's I0102 15:04:05.000000
+var timestampPattern = regexp.MustCompile(`^\[?(\d{4}[-/]\d{2}[-/]\d{2}[T ]\d{2}:\d{2}|[A-Z][a-z]{2} +\d{1,2} \d{2}:\d{2}|[IWEF]\d{4} \d{2}:\d{2})`)
+
+// levelPattern matches log levels and JSON log lines
+var levelPattern = regexp.MustCompile(`(?i)(\blevel[=:]|\b(INFO|WARN|WARNING|ERROR|DEBUG|FATAL)\b|^\{"(ts|time|timestamp|level|msg)")`)
+
+// detect works out what data contains from its name and content. The
+// resources of manifests are summarized.
+func detect(name string, data []byte) (Type, []Resource) {
+	extension := strings.ToLower(filepath.Ext(name))
+	switch extension {
+	case ".yaml", ".yml", ".json", "":
+		if resources, ok := parseManifests(data); ok {
+			return TypeManifest, resources
+		}
+	}
+
+	switch {
+	case extension == ".yaml" || extension == ".yml":
+		if strings.HasPrefix(strings.ToLower(filepath.Base(name)), "values") {
+			return TypeHelmValues, nil
+		}
+		return TypeYAML, nil
+	case extension == ".json" || (extension == "" && json.Valid(data)):
+		return TypeJSON, nil
+	case extension == ".log" || looksLikeLog(data):
+		return TypeLog, nil
+	}
+	return TypeText, nil
+}
+
+// looksLikeLog reports whether most of the first lines of data look like
+// log lines
+func looksLikeLog(data []byte) bool {
+	lines, matching := 0, 0
+	for _, line := range strings.SplitN(string(data), "\n", 40) {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		lines++
+		if timestampPattern.MatchString(line) || levelPattern.MatchString(line) {
+			matching++
+		}
+	}
+	return lines > 0 && matching*2 > lines
+}
+
+// parseManifests summarizes the resources in YAML or JSON documents. It
+// reports false if any document isn't a Kubernetes resource. Lists, such as
+// the output of kubectl get -o yaml, are expanded.
+func parseManifests(data []byte) ([]Resource, bool) {
+	decoder := utilyaml.NewYAMLOrJSONDecoder(bytes.NewReader(data), 4096)
+	var resources []Resource
+	documents := 0
+	for {
+		var object map[string]interface{}
+		if err := decoder.Decode(&object); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, false
+		}
+		if object == nil {
+			// An empty document, e.g. after a trailing ---
+			continue
+		}
+		documents++
+
+		resource := unstructured.Unstructured{Object: object}
+		if resource.GetAPIVersion() == "" || resource.GetKind() == "" {
+			return nil, false
+		}
+		if !resource.IsList() {
+			resources = append(resources, summarize(&resource))
+			continue
+		}
+		items, _, _ := unstructured.NestedSlice(object, "items")
+		for _, item := range items {
+			if m, ok := item.(map[string]interface{}); ok {
+				resources = append(resources, summarize(&unstructured.Unstructured{Object: m}))
+			}
+		}
+	}
+
+	if documents == 0 {
+		return nil, false
+	}
+	if len(resources) > maxResources {
+		resources = resources[:maxResources]
+	}
+	return resources, true
+}
+
+// redactSecrets replaces the values of the Secrets in manifests by their
+// sizes, whatever their keys, and re-encodes the documents as YAML. It
+// reports false if there are no Secrets.
+func redactSecrets(data []byte) (string, bool) {
+	decoder := utilyaml.NewYAMLOrJSONDecoder(bytes.NewReader(data), 4096)
+	var documents []string
+	found := false
+	for {
+		var object map[string]interface{}
+		if err := decoder.Decode(&object); err == io.EOF {
+			break
+		} else if err != nil {
+			return "", false
+		}
+		if object == nil {
+			continue
+		}
+
+		objects := []map[string]interface{}{object}
+		items, _, _ := unstructured.NestedFieldNoCopy(object, "items")
+		if list, ok := items.([]interface{}); ok {
+			for _, item := range list {
+				if m, ok := item.(map[string]interface{}); ok {
+					objects = append(objects, m)
+				}
+			}
+		}
+		for _, o := range objects {
+			if kind, _ := o["kind"].(string); kind == "Secret" {
+				kube.RedactSecretData(o)
+				found = true
+			}
+		}
+
+		document, err := yaml.Marshal(object)
+		if err != nil {
+			return "", false
+		}
+		documents = append(documents, string(document))
+	}
+	return strings.Join(documents, "---\n"), found
+}
+
+// summarize describes a resource by the fields that usually explain why it
+// doesn't behave as intended
+func summarize(resource *unstructured.Unstructured) Resource {
+	summary := Resource{Kind: resource.GetKind(), Name: resource.GetName(), Namespace: resource.GetNamespace()}
+	object := resource.Object
+
+	var podSpec []string
+	switch summary.Kind {
+	case "Pod":
+		podSpec = []string{"spec"}
+	case "Deployment", "StatefulSet", "DaemonSet", "ReplicaSet", "Job":
+		podSpec = []string{"spec", "template", "spec"}
+	case "CronJob":
+		podSpec = []string{"spec", "jobTemplate", "spec", "template", "spec"}
+		if schedule, _, _ := unstructured.NestedString(object, "spec", "schedule"); schedule != "" {
+			summary.Details = append(summary.Details, "schedule "+schedule)
+		}
+	case "Service":
+		summary.Details = serviceDetails(object)
+	case "Ingress":
+		summary.Details = ingressDetails(object)
+	case "ConfigMap", "Secret":
+		// Only the keys, Read replaces the values of Secrets by their sizes
+		var keys []string
+		for _, field := range []string{"data", "stringData", "binaryData"} {
+			values, _, _ := unstructured.NestedMap(object, field)
+			for key := range values {
+				keys = append(keys, key)
+			}
+		}
+		if len(keys) > 0 {
+			sort.Strings(keys)
+			summary.Details = append(summary.Details, "keys "+strings.Join(keys, ", "))
+		}
+	case "HorizontalPodAutoscaler":
+		target, _, _ := unstructured.NestedString(object, "spec", "scaleTargetRef", "name")
+		minReplicas, found := intField(object, "spec", "minReplicas")
+		if !found {
+			minReplicas = 1
+		}
+		maxReplicas, _ := intField(object, "spec", "maxReplicas")
+		summary.Details = append(summary.Details, fmt.Sprintf("scales %s from %d to %d replicas", target, minReplicas, maxReplicas))
+	}
+
+	if podSpec == nil {
+		return summary
+	}
+	if replicas, found := intField(object, "spec", "replicas"); found {
+		summary.Details = append(summary.Details, fmt.Sprintf("replicas %d", replicas))
+	}
+	if selector, _, _ := stringMap(object, "spec", "selector", "matchLabels"); len(selector) > 0 {
+		summary.Details = append(summary.Details, "selector "+formatLabels(selector))
+	}
+	if serviceAccount, _, _ := unstructured.NestedString(object, append(podSpec, "serviceAccountName")...); serviceAccount != "" {
+		summary.Details = append(summary.Details, "service account "+serviceAccount)
+	}
+	for _, field := range []string{"initContainers", "containers"} {
+		containers, _, _ := unstructured.NestedSlice(object, append(podSpec, field)...)
+		for _, container := range containers {
+			if m, ok := container.(map[string]interface{}); ok {
+				summary.Details = append(summary.Details, containerDetails(m, field == "initContainers"))
+			}
+		}
+	}
+	return summary
+}
+
+// containerDetails describes a container's image, ports, resources and probes
+func containerDetails(container map[string]interface{}, init bool) string {
+	name, _, _ := unstructured.NestedString(container, "name")
+	image, _, _ := unstructured.NestedString(container, "image")
+	description := fmt.Sprintf("container %s image %s", name, image)
+	if init {
+		description = "init " + description
+	}
+
+	ports, _, _ := unstructured.NestedSlice(container, "ports")
+	var numbers []string
+	for _, port := range ports {
+		if m, ok := port.(map[string]interface{}); ok {
+			numbers = append(numbers, fmt.Sprint(m["containerPort"]))
+		}
+	}
+	if len(numbers) > 0 {
+		description += ", ports " + strings.Join(numbers, ",")
+	}
+
+	for _, kind := range []string{"requests", "limits"} {
+		if values, _, _ := stringMap(container, "resources", kind); len(values) > 0 {
+			description += fmt.Sprintf(", %s %s", kind, formatLabels(values))
+		}
+	}
+
+	var probes []string
+	for _, probe := range []string{"readinessProbe", "livenessProbe", "startupProbe"} {
+		if _, found, _ := unstructured.NestedMap(container, probe); found {
+			probes = append(probes, strings.TrimSuffix(probe, "Probe"))
+		}
+	}
+	if len(probes) > 0 {
+		description += ", probes " + strings.Join(probes, ",")
+	}
+	return description
+}
+
+// serviceDetails describes a service's type, ports and selector
+func serviceDetails(object map[string]interface{}) []string {
+	serviceType, _, _ := unstructured.NestedString(object, "spec", "type")
+	if serviceType == "" {
+		serviceType = "ClusterIP"
+	}
+	details := []string{"type " + serviceType}
+
+	ports, _, _ := unstructured.NestedSlice(object, "spec", "ports")
+	var mappings []string
+	for _, port := range ports {
+		m, ok := port.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		mapping := fmt.Sprint(m["port"])
+		if target, ok := m["targetPort"]; ok {
+			mapping += fmt.Sprintf("->%v", target)
+		}
+		mappings = append(mappings, mapping)
+	}
+	if len(mappings) > 0 {
+		details = append(details, "ports "+strings.Join(mappings, ","))
+	}
+	if selector, _, _ := stringMap(object, "spec", "selector"); len(selector) > 0 {
+		details = append(details, "selector "+formatLabels(selector))
+	}
+	return details
+}
+
+// ingressDetails describes an ingress's class and routes
+func ingressDetails(object map[string]interface{}) []string {
+	var details []string
+	if class, _, _ := unstructured.NestedString(object, "spec", "ingressClassName"); class != "" {
+		details = append(details, "class "+class)
+	}
+
+	rules, _, _ := unstructured.NestedSlice(object, "spec", "rules")
+	for _, rule := range rules {
+		m, ok := rule.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		host, _, _ := unstructured.NestedString(m, "host")
+		paths, _, _ := unstructured.NestedSlice(m, "http", "paths")
+		for _, path := range paths {
+			p, ok := path.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			route, _, _ := unstructured.NestedString(p, "path")
+			service, _, _ := unstructured.NestedString(p, "backend", "service", "name")
+			port, _, _ := unstructured.NestedFieldNoCopy(p, "backend", "service", "port", "number")
+			details = append(details, fmt.Sprintf("%s%s -> %s:%v", host, route, service, port))
+		}
+	}
+	return details
+}
+
+// intField returns the number at fields. Decoded YAML and JSON numbers are
+// float64, not int64 as in objects read from the API server.
+func intField(object map[string]interface{}, fields ...string) (int64, bool) {
+	value, found, _ := unstructured.NestedFieldNoCopy(object, fields...)
+	switch number := value.(type) {
+	case int64:
+		return number, found
+	case float64:
+		return int64(number), found
+	}
+	return 0, false
+}
+
+// stringMap returns the map at fields with its values formatted as
+// strings, as requests like cpu: 1 aren't strings
+func stringMap(object map[string]interface{}, fields ...string) (map[string]string, bool, error) {
+	values, found, err := unstructured.NestedMap(object, fields...)
+	if !found || err != nil {
+		return nil, found, err
+	}
+	formatted := make(map[string]string, len(values))
+	for key, value := range values {
+		formatted[key] = fmt.Sprint(value)
+	}
+	return formatted, true, nil
+}
+
+// formatLabels formats a map as sorted key=value pairs
+func formatLabels(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, len(keys))
+	for i, key := range keys {
+		pairs[i] = key + "=" + labels[key]
+	}
+	return strings.Join(pairs, ",")
+}
+
+// Message is the text giving the attachment to the LLM
+func (a *Attachment) Message() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Attached %s (%s, %s):\n", a.Name, a.Type, formatSize(a.Size))
+
+	if len(a.Resources) > 0 {
+		b.WriteString("\nResources:\n")
+		for _, resource := range a.Resources {
+			fmt.Fprintf(&b, "- %s\n", resource)
+		}
+		b.WriteString("\nThis is the intended state. Where it matters for the goal, compare it with the live resources in the cluster and point out the differences.\n")
+	}
+
+	if a.Truncated {
+		if a.Type == TypeLog {
+			fmt.Fprintf(&b, "\nThe last %s of the content:\n", formatSize(len(a.Content)))
+		} else {
+			fmt.Fprintf(&b, "\nThe first %s of the content:\n", formatSize(len(a.Content)))
+		}
+	}
+	fence := "```"
+	for strings.Contains(a.Content, fence) {
+		fence += "`"
+	}
+	fmt.Fprintf(&b, "\n%s%s\n%s\n%s", fence, a.language(), strings.TrimRight(a.Content, "\n"), fence)
+	return b.String()
+}
+
+// Summary describes the attachment in a line, for the user
+func (a *Attachment) Summary() string {
+	summary := fmt.Sprintf("%s: %s, %s", a.Name, a.Type, formatSize(a.Size))
+	if len(a.Resources) > 0 {
+		summary += fmt.Sprintf(", %d resources", len(a.Resources))
+	}
+	if a.Truncated {
+		summary += fmt.Sprintf(", %s shown", formatSize(len(a.Content)))
+	}
+	return summary
+}
+
+// language is the code fence language of the attachment's content
+func (a *Attachment) language() string {
+	switch a.Type {
+	case TypeManifest, TypeHelmValues, TypeYAML:
+		if strings.HasPrefix(strings.TrimSpace(a.Content), "{") {
+			return "json"
+		}
+		return "yaml"
+	case TypeJSON:
+		return "json"
+	}
+	return ""
+}
+
+// formatSize formats a size in bytes, e.g. 12.5 KiB
+func formatSize(size int) string {
+	switch {
+	case size >= 1024*1024:
+		return fmt.Sprintf("%.1f MiB", float64(size)/(1024*1024))
+	case size >= 1024:
+		return fmt.Sprintf("%.1f KiB", float64(size)/1024)
+	}
+	return fmt.Sprintf("%d bytes", size)
+}
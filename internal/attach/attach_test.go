@@ -0,0 +1,185 @@
+package attach
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const deployment = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: api
+  namespace: shop
+spec:
+  replicas: 3
+  selector:
+    matchLabels:
+      app: api
+  template:
+    spec:
+      containers:
+      - name: api
+        image: shop/api:1.4
+        ports:
+        - containerPort: 8080
+        resources:
+          requests:
+            cpu: 1
+            memory: 128Mi
+        readinessProbe:
+          httpGet:
+            path: /healthz
+            port: 8080
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: api
+  namespace: shop
+spec:
+  selector:
+    app: api
+  ports:
+  - port: 80
+    targetPort: 8080
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: db
+stringData:
+  password: hunter2
+`
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		data string
+		want Type
+	}{
+		{"manifest", "deploy.yaml", deployment, TypeManifest},
+		{"JSON list from stdin", "stdin", `{"apiVersion":"v1","kind":"List","items":[{"apiVersion":"v1","kind":"Pod","metadata":{"name":"a"}}]}`, TypeManifest},
+		{"Helm values", "values-prod.yaml", "replicaCount: 2\nimage:\n  tag: 1.4\n", TypeHelmValues},
+		{"YAML", "kustomization.yml", "resources:\n- deploy.yaml\n", TypeYAML},
+		{"JSON", "stdin", `{"level":"info"}`, TypeJSON},
+		{"log", "stdin", "2025-01-02T15:04:05Z INFO starting\n2025-01-02T15:04:06Z ERROR connection refused\n", TypeLog},
+		{"klog", "kubelet.txt", "I0102 15:04:05.000000 1 server.go:1] started\nE0102 15:04:06.000000 1 pod.go:2] failed\n", TypeLog},
+		{"text", "notes.txt", "The api broke after the 1.4 release.\n", TypeText},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, _ := detect(tt.file, []byte(tt.data)); got != tt.want {
+				t.Errorf("detect(%q) = %q, want %q", tt.file, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadManifest(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "deploy.yaml")
+	if err := os.WriteFile(path, []byte(deployment), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	attachment, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	want := []string{
+		"Deployment shop/api: replicas 3; selector app=api; container api image shop/api:1.4, ports 8080, requests cpu=1,memory=128Mi, probes readiness",
+		"Service shop/api: type ClusterIP; ports 80->8080; selector app=api",
+		"Secret db: keys password",
+	}
+	var got []string
+	for _, resource := range attachment.Resources {
+		got = append(got, resource.String())
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("resources = %q, want %q", got, want)
+	}
+
+	message := attachment.Message()
+	if strings.Contains(message, "hunter2") {
+		t.Errorf("the message leaks the secret: %s", message)
+	}
+	if !strings.Contains(message, "compare it with the live resources") || !strings.Contains(message, "```yaml") {
+		t.Errorf("message = %s", message)
+	}
+}
+
+func TestReadRedactsSecrets(t *testing.T) {
+	manifest := `apiVersion: v1
+kind: Secret
+metadata:
+  name: db
+data:
+  db-password: aHVudGVyMg==
+  tls.key: c2VjcmV0LWtleQ==
+stringData:
+  api.token: ghp_abcdef
+---
+apiVersion: v1
+kind: List
+items:
+- apiVersion: v1
+  kind: Secret
+  metadata:
+    name: registry
+  data:
+    .dockerconfigjson: eyJhdXRocyI6e319
+- apiVersion: v1
+  kind: ConfigMap
+  metadata:
+    name: settings
+  data:
+    log-level: debug
+`
+	attachment, err := Read("secrets.yaml", strings.NewReader(manifest))
+	if err != nil {
+		t.Fatalf("Read() failed: %v", err)
+	}
+
+	for _, secret := range []string{"aHVudGVyMg==", "c2VjcmV0LWtleQ==", "ghp_abcdef", "eyJhdXRocyI6e319"} {
+		if strings.Contains(attachment.Content, secret) {
+			t.Errorf("the content leaks %q:\n%s", secret, attachment.Content)
+		}
+	}
+	for _, want := range []string{"db-password: 7 bytes", "tls.key: 10 bytes", "api.token: 10 bytes", ".dockerconfigjson: 12 bytes", "log-level: debug"} {
+		if !strings.Contains(attachment.Content, want) {
+			t.Errorf("the content is missing %q:\n%s", want, attachment.Content)
+		}
+	}
+}
+
+func TestReadLimits(t *testing.T) {
+	if _, err := Read("stdin", strings.NewReader(strings.Repeat("a", MaxSize+1))); err == nil {
+		t.Error("Read() accepted input over MaxSize")
+	}
+	if _, err := Read("stdin", strings.NewReader("  \n")); err == nil {
+		t.Error("Read() accepted empty input")
+	}
+	if _, err := Read("core", strings.NewReader("ELF\x00\x01")); err == nil {
+		t.Error("Read() accepted binary input")
+	}
+	if _, err := Load(t.TempDir()); err == nil {
+		t.Error("Load() accepted a directory")
+	}
+
+	var log strings.Builder
+	for i := 0; log.Len() <= maxContentBytes; i++ {
+		log.WriteString("2025-01-02T15:04:05Z INFO request served\n")
+	}
+	log.WriteString("2025-01-02T15:04:06Z ERROR out of memory\n")
+	attachment, err := Read("stdin", strings.NewReader(log.String()))
+	if err != nil {
+		t.Fatalf("Read() failed: %v", err)
+	}
+	if !attachment.Truncated || !strings.HasSuffix(attachment.Content, "ERROR out of memory\n") || !strings.HasPrefix(attachment.Content, "2025-") {
+		t.Errorf("a long log should keep its last whole lines, got truncated=%v", attachment.Truncated)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
 	"gopkg.in/yaml.v2"
 )
@@ -17,6 +18,16 @@ const (
 	CredentialsFile = "credentials"
 	// DefaultConfigFileName is the default configuration file name
 	DefaultConfigFileName = "config.yaml"
+	// DefaultMCPTokenDir is the subdirectory for encrypted MCP OAuth tokens
+	DefaultMCPTokenDir = "mcp-tokens"
+	// DefaultCacheDir is the subdirectory for cached cluster context
+	DefaultCacheDir = "cache"
+	// DefaultContextCacheTTL is how long cached cluster context is reused
+	DefaultContextCacheTTL = 10 * time.Minute
+	// ConsoleHistoryFile is the file holding the console's input history
+	ConsoleHistoryFile = "console_history"
+	// DefaultConversationsDir is the subdirectory for saved console conversations
+	DefaultConversationsDir = "conversations"
 )
 
 // Config represents the application configuration
@@ -27,6 +38,8 @@ type Config struct {
 	MCP MCPConfig `yaml:"mcp"`
 	// Kubernetes configuration
 	Kubernetes KubernetesConfig `yaml:"kubernetes"`
+	// Cluster context gathering
+	Context ContextConfig `yaml:"context"`
 	// General settings
 	Settings GeneralSettings `yaml:"settings"`
 }
@@ -45,6 +58,11 @@ type MCPConfig struct {
 	Servers map[string]MCPServerConfig `yaml:"servers"`
 	// Enabled controls whether MCP integration is enabled
 	Enabled bool `yaml:"enabled"`
+	// ConnectTimeout bounds each server connection attempt (default 30s)
+	ConnectTimeout time.Duration `yaml:"connect_timeout,omitempty"`
+	// HealthCheckInterval is how often connected servers are pinged and
+	// reconnected if unresponsive (default 30s, negative disables)
+	HealthCheckInterval time.Duration `yaml:"health_check_interval,omitempty"`
 }
 
 // MCPServerConfig contains configuration for a specific MCP server
@@ -67,6 +85,14 @@ type MCPServerConfig struct {
 	// OAuth configuration (for oauth-sse and oauth-http transports)
 	OAuth *OAuthConfig `yaml:"oauth,omitempty"`
 
+	// Tool filtering. Tool names are matched against glob patterns; denied
+	// patterns take precedence over allowed ones and an empty allow list
+	// allows every tool.
+	AllowedTools []string `yaml:"allowed_tools,omitempty"`
+	DeniedTools  []string `yaml:"denied_tools,omitempty"`
+	// ReadOnlyHint only exposes tools annotated with readOnlyHint
+	ReadOnlyHint bool `yaml:"read_only_hint,omitempty"`
+
 	// Transport-specific options
 	Options map[string]interface{} `yaml:"options,omitempty"`
 }
@@ -93,10 +119,75 @@ type ProviderConfig struct {
 type KubernetesConfig struct {
 	// Context is the default Kubernetes context to use
 	Context string `yaml:"context,omitempty"`
+	// Contexts are further kube-contexts a session spans, e.g. staging and
+	// prod, or several regional clusters
+	Contexts []string `yaml:"contexts,omitempty"`
 	// Namespace is the default namespace
 	Namespace string `yaml:"namespace,omitempty"`
 	// KubeConfigPath is the path to the kubeconfig file
 	KubeConfigPath string `yaml:"kubeconfig_path,omitempty"`
+	// HealthSnapshot adds unhealthy pods, warning events, node pressure,
+	// pending PVCs and failing deployments to the initial context (default: true)
+	HealthSnapshot *bool `yaml:"health_snapshot,omitempty"`
+}
+
+// HealthSnapshotEnabled reports whether the cluster health snapshot is
+// gathered
+func (k KubernetesConfig) HealthSnapshotEnabled() bool {
+	return k.HealthSnapshot == nil || *k.HealthSnapshot
+}
+
+// SessionContexts returns the kube-contexts a session spans: Context
+// followed by Contexts, without duplicates. It is empty if the session uses
+// the kubeconfig's current context.
+func (k KubernetesConfig) SessionContexts() []string {
+	var contexts []string
+	seen := make(map[string]bool)
+	for _, name := range append([]string{k.Context}, k.Contexts...) {
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		contexts = append(contexts, name)
+	}
+	return contexts
+}
+
+// MultiCluster reports whether a session spans more than one kube-context
+func (k KubernetesConfig) MultiCluster() bool {
+	return len(k.SessionContexts()) > 1
+}
+
+// ContextConfig controls how cluster context is gathered for the prompt
+type ContextConfig struct {
+	// CacheTTL is how long the console reuses gathered context for a
+	// kube-context (default 10m, negative disables caching)
+	CacheTTL time.Duration `yaml:"cache_ttl,omitempty"`
+	// Quiet prints a one-line context summary instead of the full dump
+	Quiet bool `yaml:"quiet,omitempty"`
+	// Providers enables, disables and configures context providers by name
+	Providers map[string]ContextProviderConfig `yaml:"providers,omitempty"`
+}
+
+// ContextProviderConfig configures a context provider
+type ContextProviderConfig struct {
+	// Enabled overrides whether the provider runs
+	Enabled *bool `yaml:"enabled,omitempty"`
+	// Timeout overrides how long the provider may take
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+	// Options are provider-specific settings, e.g. dir for runbooks
+	Options map[string]string `yaml:"options,omitempty"`
+}
+
+// CacheTTLOrDefault returns the effective cache TTL, or 0 if caching is disabled
+func (c ContextConfig) CacheTTLOrDefault() time.Duration {
+	switch {
+	case c.CacheTTL < 0:
+		return 0
+	case c.CacheTTL == 0:
+		return DefaultContextCacheTTL
+	}
+	return c.CacheTTL
 }
 
 // GeneralSettings contains general application settings
@@ -107,6 +198,44 @@ type GeneralSettings struct {
 	HistoryEnabled bool `yaml:"history_enabled"`
 	// UndoEnabled enables undo functionality
 	UndoEnabled bool `yaml:"undo_enabled"`
+	// ApprovalMode controls which tool calls the console asks to approve
+	// (default: never)
+	ApprovalMode ApprovalMode `yaml:"approval_mode,omitempty"`
+}
+
+// ApprovalMode controls which tool calls need the user's approval
+type ApprovalMode string
+
+const (
+	// ApprovalAlways asks before every tool call
+	ApprovalAlways ApprovalMode = "always"
+	// ApprovalNonKubectl asks before tool calls other than kubectl commands
+	// and the read-only Kubernetes tools
+	ApprovalNonKubectl ApprovalMode = "non-kubectl"
+	// ApprovalNever runs tool calls without asking
+	ApprovalNever ApprovalMode = "never"
+)
+
+// ApprovalModes lists the valid approval modes
+var ApprovalModes = []ApprovalMode{ApprovalAlways, ApprovalNonKubectl, ApprovalNever}
+
+// ParseApprovalMode validates an approval mode name
+func ParseApprovalMode(name string) (ApprovalMode, error) {
+	for _, mode := range ApprovalModes {
+		if string(mode) == name {
+			return mode, nil
+		}
+	}
+	return "", fmt.Errorf("unknown approval mode %q, expected one of always, non-kubectl or never", name)
+}
+
+// ApprovalModeOrDefault returns the configured approval mode, or
+// ApprovalNever if it is unset or invalid
+func (s GeneralSettings) ApprovalModeOrDefault() ApprovalMode {
+	if mode, err := ParseApprovalMode(string(s.ApprovalMode)); err == nil {
+		return mode
+	}
+	return ApprovalNever
 }
 
 // GetConfigDir returns the configuration directory path
@@ -127,6 +256,42 @@ func GetHistoryDir() (string, error) {
 	return filepath.Join(configDir, DefaultHistoryDir), nil
 }
 
+// GetMCPTokenDir returns the directory holding MCP OAuth tokens
+func GetMCPTokenDir() (string, error) {
+	configDir, err := GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, DefaultMCPTokenDir), nil
+}
+
+// GetCacheDir returns the directory holding cached cluster context
+func GetCacheDir() (string, error) {
+	configDir, err := GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, DefaultCacheDir), nil
+}
+
+// GetConversationsDir returns the directory holding saved console conversations
+func GetConversationsDir() (string, error) {
+	configDir, err := GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, DefaultConversationsDir), nil
+}
+
+// GetConsoleHistoryPath returns the console's input history file path
+func GetConsoleHistoryPath() (string, error) {
+	configDir, err := GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, ConsoleHistoryFile), nil
+}
+
 // GetCredentialsPath returns the credentials file path
 func GetCredentialsPath() (string, error) {
 	configDir, err := GetConfigDir()
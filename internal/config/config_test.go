@@ -3,7 +3,9 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestGetConfigDir(t *testing.T) {
@@ -185,3 +187,68 @@ settings:
 		}
 	})
 }
+
+func TestContextCacheTTLOrDefault(t *testing.T) {
+	tests := []struct {
+		ttl  time.Duration
+		want time.Duration
+	}{
+		{0, DefaultContextCacheTTL},
+		{time.Minute, time.Minute},
+		{-time.Second, 0},
+	}
+
+	for _, tt := range tests {
+		if got := (ContextConfig{CacheTTL: tt.ttl}).CacheTTLOrDefault(); got != tt.want {
+			t.Errorf("CacheTTLOrDefault() with %v = %v, want %v", tt.ttl, got, tt.want)
+		}
+	}
+}
+
+func TestSessionContexts(t *testing.T) {
+	tests := []struct {
+		name   string
+		config KubernetesConfig
+		want   []string
+	}{
+		{"current context", KubernetesConfig{}, nil},
+		{"single context", KubernetesConfig{Context: "prod"}, []string{"prod"}},
+		{"multiple contexts", KubernetesConfig{Context: "prod", Contexts: []string{"staging", "prod", "eu"}}, []string{"prod", "staging", "eu"}},
+		{"contexts without default", KubernetesConfig{Contexts: []string{"us", "eu"}}, []string{"us", "eu"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.config.SessionContexts()
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("SessionContexts() = %v, want %v", got, tt.want)
+			}
+			if multi := tt.config.MultiCluster(); multi != (len(tt.want) > 1) {
+				t.Errorf("MultiCluster() = %v, want %v", multi, len(tt.want) > 1)
+			}
+		})
+	}
+}
+
+func TestApprovalModeOrDefault(t *testing.T) {
+	tests := []struct {
+		mode ApprovalMode
+		want ApprovalMode
+	}{
+		{"", ApprovalNever},
+		{"always", ApprovalAlways},
+		{"non-kubectl", ApprovalNonKubectl},
+		{"sometimes", ApprovalNever},
+	}
+
+	for _, tt := range tests {
+		settings := GeneralSettings{ApprovalMode: tt.mode}
+		if got := settings.ApprovalModeOrDefault(); got != tt.want {
+			t.Errorf("ApprovalModeOrDefault() for %q = %q, want %q", tt.mode, got, tt.want)
+		}
+	}
+
+	if _, err := ParseApprovalMode("sometimes"); err == nil {
+		t.Error("ParseApprovalMode() should reject unknown modes")
+	}
+}
@@ -0,0 +1,217 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// configDocument is a comment-preserving view of the config.yaml file.
+// Edits are applied to the YAML node tree so that comments, key ordering
+// and unrelated settings survive a round trip.
+type configDocument struct {
+	path string
+	root *yaml.Node
+}
+
+// loadConfigDocument reads config.yaml into a node tree, starting from an
+// empty mapping if the file doesn't exist yet
+func loadConfigDocument() (*configDocument, error) {
+	configPath, err := GetConfigPath()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get config path: %w", err)
+	}
+
+	doc := &configDocument{path: configPath}
+
+	data, err := os.ReadFile(configPath)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var root yaml.Node
+	if len(bytes.TrimSpace(data)) > 0 {
+		if err := yaml.Unmarshal(data, &root); err != nil {
+			return nil, fmt.Errorf("failed to parse config file: %w", err)
+		}
+	}
+
+	if root.Kind != yaml.DocumentNode || len(root.Content) == 0 {
+		root = yaml.Node{
+			Kind:    yaml.DocumentNode,
+			Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}},
+		}
+	}
+	if root.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("config file %s is not a YAML mapping", configPath)
+	}
+
+	doc.root = &root
+	return doc, nil
+}
+
+// mapping returns the mapping node at the given key path, creating empty
+// mappings along the way. Non-mapping values on the path (e.g. `servers: []`)
+// are replaced with an empty mapping.
+func (d *configDocument) mapping(path ...string) *yaml.Node {
+	node := d.root.Content[0]
+	for _, key := range path {
+		_, value := findMappingKey(node, key)
+		if value == nil {
+			value = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, value)
+		} else if value.Kind != yaml.MappingNode {
+			*value = yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", HeadComment: value.HeadComment, LineComment: value.LineComment}
+		}
+		node = value
+	}
+	return node
+}
+
+// set stores value under key in the mapping at path, keeping the existing
+// key position and comments if the key is already present
+func (d *configDocument) set(value interface{}, path ...string) error {
+	if len(path) == 0 {
+		return fmt.Errorf("empty config key path")
+	}
+
+	var encoded yaml.Node
+	if err := encoded.Encode(value); err != nil {
+		return fmt.Errorf("failed to encode value for %v: %w", path, err)
+	}
+
+	parent := d.mapping(path[:len(path)-1]...)
+	key := path[len(path)-1]
+
+	_, existing := findMappingKey(parent, key)
+	if existing == nil {
+		parent.Content = append(parent.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, &encoded)
+		return nil
+	}
+
+	encoded.HeadComment = existing.HeadComment
+	encoded.LineComment = existing.LineComment
+	encoded.FootComment = existing.FootComment
+	*existing = encoded
+	return nil
+}
+
+// remove deletes key from the mapping at path and reports whether it existed
+func (d *configDocument) remove(path ...string) bool {
+	if len(path) == 0 {
+		return false
+	}
+
+	node := d.root.Content[0]
+	for _, key := range path[:len(path)-1] {
+		_, value := findMappingKey(node, key)
+		if value == nil || value.Kind != yaml.MappingNode {
+			return false
+		}
+		node = value
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == path[len(path)-1] {
+			node.Content = append(node.Content[:i], node.Content[i+2:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// save writes the node tree back to config.yaml. The file may hold
+// secrets, such as MCP server environment variables, so a new one is only
+// readable by the user; an existing one keeps its permissions.
+func (d *configDocument) save() error {
+	if err := os.MkdirAll(filepath.Dir(d.path), 0700); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(d.root); err != nil {
+		return fmt.Errorf("failed to encode config file: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return fmt.Errorf("failed to encode config file: %w", err)
+	}
+
+	if err := os.WriteFile(d.path, buf.Bytes(), 0600); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	return nil
+}
+
+// findMappingKey returns the key and value nodes for key in a mapping node
+func findMappingKey(node *yaml.Node, key string) (*yaml.Node, *yaml.Node) {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil, nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i], node.Content[i+1]
+		}
+	}
+	return nil, nil
+}
+
+// SetConfigValue sets a single value in config.yaml, e.g.
+// SetConfigValue(true, "mcp", "enabled"), preserving comments and ordering
+func SetConfigValue(value interface{}, path ...string) error {
+	doc, err := loadConfigDocument()
+	if err != nil {
+		return err
+	}
+	if err := doc.set(value, path...); err != nil {
+		return err
+	}
+	return doc.save()
+}
+
+// SetMCPEnabled enables or disables MCP integration in config.yaml
+func SetMCPEnabled(enabled bool) error {
+	return SetConfigValue(enabled, "mcp", "enabled")
+}
+
+// SaveMCPServer adds or replaces an MCP server entry in config.yaml
+func SaveMCPServer(name string, server MCPServerConfig) error {
+	if name == "" {
+		return fmt.Errorf("MCP server name cannot be empty")
+	}
+	return SetConfigValue(server, "mcp", "servers", name)
+}
+
+// RemoveMCPServer deletes an MCP server entry from config.yaml
+func RemoveMCPServer(name string) error {
+	doc, err := loadConfigDocument()
+	if err != nil {
+		return err
+	}
+	if !doc.remove("mcp", "servers", name) {
+		return fmt.Errorf("MCP server '%s' not found in configuration", name)
+	}
+	return doc.save()
+}
+
+// SetMCPServerEnabled enables or disables a configured MCP server in config.yaml
+func SetMCPServerEnabled(name string, enabled bool) error {
+	doc, err := loadConfigDocument()
+	if err != nil {
+		return err
+	}
+
+	servers := doc.mapping("mcp", "servers")
+	if _, server := findMappingKey(servers, name); server == nil || server.Kind != yaml.MappingNode {
+		return fmt.Errorf("MCP server '%s' not found in configuration", name)
+	}
+
+	if err := doc.set(enabled, "mcp", "servers", name, "enabled"); err != nil {
+		return err
+	}
+	return doc.save()
+}
@@ -0,0 +1,215 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// setTestHome points HOME at a temporary directory for the duration of the test
+func setTestHome(t *testing.T) string {
+	t.Helper()
+	tempDir := t.TempDir()
+	t.Setenv("HOME", tempDir)
+	return tempDir
+}
+
+func writeTestConfig(t *testing.T, home, content string) string {
+	t.Helper()
+	configDir := filepath.Join(home, DefaultConfigDir)
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatalf("Failed to create config directory: %v", err)
+	}
+	configPath := filepath.Join(configDir, DefaultConfigFileName)
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write test config file: %v", err)
+	}
+	return configPath
+}
+
+func TestSaveMCPServerPreservesComments(t *testing.T) {
+	home := setTestHome(t)
+	configPath := writeTestConfig(t, home, `# K8X Configuration
+
+# Kubernetes settings
+kubernetes:
+  # Namespace to use if not specified in commands
+  namespace: "default"
+
+# MCP (Model Context Protocol) settings
+mcp:
+  enabled: false
+  servers: []
+`)
+
+	err := SaveMCPServer("docs", MCPServerConfig{
+		Transport: "http",
+		Enabled:   true,
+		BaseURL:   "https://mcp.example.com/mcp",
+	})
+	if err != nil {
+		t.Fatalf("SaveMCPServer() failed: %v", err)
+	}
+
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatalf("Failed to read config file: %v", err)
+	}
+	content := string(data)
+
+	for _, want := range []string{
+		"# K8X Configuration",
+		"# Namespace to use if not specified in commands",
+		"# MCP (Model Context Protocol) settings",
+	} {
+		if !strings.Contains(content, want) {
+			t.Errorf("Config lost comment %q:\n%s", want, content)
+		}
+	}
+
+	if strings.Index(content, "kubernetes:") > strings.Index(content, "mcp:") {
+		t.Errorf("Config key order changed:\n%s", content)
+	}
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig() failed: %v", err)
+	}
+	server, ok := cfg.MCP.Servers["docs"]
+	if !ok {
+		t.Fatalf("Server 'docs' not found in %v", cfg.MCP.Servers)
+	}
+	if server.BaseURL != "https://mcp.example.com/mcp" || server.Transport != "http" || !server.Enabled {
+		t.Errorf("Server = %+v, want enabled http server", server)
+	}
+	if cfg.Kubernetes.Namespace != "default" {
+		t.Errorf("Kubernetes.Namespace = %q, want default", cfg.Kubernetes.Namespace)
+	}
+}
+
+func TestMCPServerLifecycle(t *testing.T) {
+	setTestHome(t)
+
+	// Writing to a missing config file creates it
+	if err := SetMCPEnabled(true); err != nil {
+		t.Fatalf("SetMCPEnabled() failed: %v", err)
+	}
+	if err := SaveMCPServer("fs", MCPServerConfig{
+		Transport: "stdio",
+		Enabled:   true,
+		Command:   "npx",
+		Args:      []string{"-y", "@modelcontextprotocol/server-filesystem"},
+		Env:       map[string]string{"DEBUG": "1"},
+	}); err != nil {
+		t.Fatalf("SaveMCPServer() failed: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		action      func() error
+		wantErr     bool
+		wantExists  bool
+		wantEnabled bool
+	}{
+		{
+			name:        "disable server",
+			action:      func() error { return SetMCPServerEnabled("fs", false) },
+			wantExists:  true,
+			wantEnabled: false,
+		},
+		{
+			name:        "enable server",
+			action:      func() error { return SetMCPServerEnabled("fs", true) },
+			wantExists:  true,
+			wantEnabled: true,
+		},
+		{
+			name:        "enable unknown server",
+			action:      func() error { return SetMCPServerEnabled("missing", true) },
+			wantErr:     true,
+			wantExists:  true,
+			wantEnabled: true,
+		},
+		{
+			name:       "remove server",
+			action:     func() error { return RemoveMCPServer("fs") },
+			wantExists: false,
+		},
+		{
+			name:       "remove unknown server",
+			action:     func() error { return RemoveMCPServer("fs") },
+			wantErr:    true,
+			wantExists: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.action()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			cfg, err := LoadConfig()
+			if err != nil {
+				t.Fatalf("LoadConfig() failed: %v", err)
+			}
+			if !cfg.MCP.Enabled {
+				t.Error("MCP.Enabled = false, want true")
+			}
+
+			server, exists := cfg.MCP.Servers["fs"]
+			if exists != tt.wantExists {
+				t.Fatalf("server exists = %v, want %v", exists, tt.wantExists)
+			}
+			if exists {
+				if server.Enabled != tt.wantEnabled {
+					t.Errorf("server.Enabled = %v, want %v", server.Enabled, tt.wantEnabled)
+				}
+				if server.Command != "npx" || len(server.Args) != 2 || server.Env["DEBUG"] != "1" {
+					t.Errorf("server settings not preserved: %+v", server)
+				}
+			}
+		})
+	}
+}
+
+func TestSaveConfigFileMode(t *testing.T) {
+	home := setTestHome(t)
+
+	// A new config file holding a secret is only readable by the user
+	if err := SaveMCPServer("github", MCPServerConfig{
+		Transport: "stdio",
+		Enabled:   true,
+		Command:   "github-mcp",
+		Env:       map[string]string{"GITHUB_TOKEN": "ghp_xxx"},
+	}); err != nil {
+		t.Fatalf("SaveMCPServer() failed: %v", err)
+	}
+	configPath := filepath.Join(home, DefaultConfigDir, DefaultConfigFileName)
+	for path, want := range map[string]os.FileMode{configPath: 0600, filepath.Dir(configPath): 0700} {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatalf("Stat() failed: %v", err)
+		}
+		if got := info.Mode().Perm(); got != want {
+			t.Errorf("%s mode = %v, want %v", path, got, want)
+		}
+	}
+
+	// An existing config file keeps its permissions
+	if err := os.Chmod(configPath, 0640); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetMCPEnabled(true); err != nil {
+		t.Fatalf("SetMCPEnabled() failed: %v", err)
+	}
+	info, err := os.Stat(configPath)
+	if err != nil {
+		t.Fatalf("Stat() failed: %v", err)
+	}
+	if got := info.Mode().Perm(); got != 0640 {
+		t.Errorf("existing config file mode = %v, want 0640", got)
+	}
+}
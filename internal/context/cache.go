@@ -0,0 +1,89 @@
+package context
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"k8x/internal/config"
+)
+
+// cachedContext is the content of a context cache file
+type cachedContext struct {
+	KubeContext string      `json:"kube_context"`
+	Namespace   string      `json:"namespace,omitempty"`
+	GatheredAt  time.Time   `json:"gathered_at"`
+	Info        ContextInfo `json:"info"`
+}
+
+// contextCachePath returns the cache file for a kube-context and namespace
+// in ~/.k8x/cache
+func contextCachePath(kubeContext, namespace string) (string, error) {
+	cacheDir, err := config.GetCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get cache directory: %w", err)
+	}
+
+	name := "context-" + url.PathEscape(kubeContext)
+	if namespace != "" {
+		name += "@" + url.PathEscape(namespace)
+	}
+	return filepath.Join(cacheDir, name+".json"), nil
+}
+
+// loadCachedContext returns the cached context of a kube-context and
+// namespace if it was gathered less than ttl ago
+func loadCachedContext(kubeContext, namespace string, ttl time.Duration) (*ContextInfo, time.Time, bool) {
+	path, err := contextCachePath(kubeContext, namespace)
+	if err != nil {
+		return nil, time.Time{}, false
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, time.Time{}, false
+	}
+
+	var cached cachedContext
+	if err := json.Unmarshal(data, &cached); err != nil {
+		return nil, time.Time{}, false
+	}
+	if cached.KubeContext != kubeContext || cached.Namespace != namespace || time.Since(cached.GatheredAt) > ttl {
+		return nil, time.Time{}, false
+	}
+	// Entries written before context providers have no sections
+	if len(cached.Info.Sections) == 0 {
+		return nil, time.Time{}, false
+	}
+	return &cached.Info, cached.GatheredAt, true
+}
+
+// saveCachedContext stores gathered context for a kube-context and namespace
+func saveCachedContext(kubeContext, namespace string, info *ContextInfo) error {
+	path, err := contextCachePath(kubeContext, namespace)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	data, err := json.Marshal(cachedContext{
+		KubeContext: kubeContext,
+		Namespace:   namespace,
+		GatheredAt:  time.Now(),
+		Info:        *info,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode context cache: %w", err)
+	}
+
+	// Cached context includes shell history examples, so keep it private
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write context cache: %w", err)
+	}
+	return nil
+}
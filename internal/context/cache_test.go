@@ -0,0 +1,76 @@
+package context
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestContextCache(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	info := &ContextInfo{
+		KubeContext: "kind-dev",
+		Sections:    []Section{{Provider: "cluster", Title: "Cluster", Content: "Server Version: v1.30.2"}},
+	}
+	if err := saveCachedContext("kind-dev", "shop", info); err != nil {
+		t.Fatalf("saveCachedContext() failed: %v", err)
+	}
+
+	got, gatheredAt, ok := loadCachedContext("kind-dev", "shop", time.Minute)
+	if !ok {
+		t.Fatal("loadCachedContext() missed a fresh entry")
+	}
+	if !reflect.DeepEqual(got, info) {
+		t.Errorf("loadCachedContext() = %+v, want %+v", got, info)
+	}
+	if time.Since(gatheredAt) > time.Minute {
+		t.Errorf("gatheredAt = %v, want recent", gatheredAt)
+	}
+
+	path, err := contextCachePath("kind-dev", "shop")
+	if err != nil {
+		t.Fatalf("contextCachePath() failed: %v", err)
+	}
+	stat, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("cache file not written: %v", err)
+	}
+	if stat.Mode().Perm() != 0600 {
+		t.Errorf("cache file mode = %v, want 0600", stat.Mode().Perm())
+	}
+
+	tests := []struct {
+		name        string
+		kubeContext string
+		namespace   string
+		ttl         time.Duration
+	}{
+		{"expired", "kind-dev", "shop", time.Nanosecond},
+		{"other context", "prod", "shop", time.Minute},
+		{"other namespace", "kind-dev", "", time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, ok := loadCachedContext(tt.kubeContext, tt.namespace, tt.ttl); ok {
+				t.Error("loadCachedContext() returned a cached entry")
+			}
+		})
+	}
+}
+
+func TestFormatContextPromptMarksCachedSections(t *testing.T) {
+	info := &ContextInfo{Sections: []Section{{Provider: "health", Title: "Cluster Health", Content: "api-1 CrashLoopBackOff"}}}
+
+	fresh := formatContextPrompt(info, 0)
+	if !strings.Contains(fresh, "Cluster Health:\napi-1") || strings.Contains(fresh, "out of date") {
+		t.Errorf("formatContextPrompt() of fresh context = %q", fresh)
+	}
+
+	cached := formatContextPrompt(info, 4*time.Minute)
+	if !strings.Contains(cached, "Cluster Health (gathered 4m0s ago):\napi-1") || !strings.Contains(cached, "may be out of date") {
+		t.Errorf("formatContextPrompt() of cached context = %q, want its age", cached)
+	}
+}
@@ -0,0 +1,114 @@
+package context
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sort"
+	"strings"
+
+	"k8x/internal/config"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// maxCompletionPods limits how many pod names are kept for completion
+const maxCompletionPods = 500
+
+// Completions are names the console offers for tab completion. They are
+// gathered and cached together with the cluster context.
+type Completions struct {
+	// Kinds are resource names and short names, e.g. deployments and deploy
+	Kinds []string `json:"kinds,omitempty"`
+	// Pods are pod names in the session's namespace, or in all namespaces
+	// if none is configured
+	Pods []string `json:"pods,omitempty"`
+}
+
+// Completions fetches resource kinds and pod names. Whatever can't be
+// fetched before the timeout is left empty.
+func (c *KubeCollector) Completions(ctx context.Context) *Completions {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	completions := &Completions{}
+	kinds := make(chan []string, 1)
+	go func() {
+		kinds <- c.resourceKinds()
+	}()
+
+	list, err := c.client.CoreV1().Pods(c.namespace).List(ctx, metav1.ListOptions{Limit: maxCompletionPods})
+	if err == nil {
+		for _, pod := range list.Items {
+			completions.Pods = append(completions.Pods, pod.Name)
+		}
+		completions.Pods = sortedUnique(completions.Pods)
+	}
+
+	// The discovery client doesn't accept a context; stop waiting for it
+	// when ctx expires
+	select {
+	case completions.Kinds = <-kinds:
+	case <-ctx.Done():
+	}
+	return completions
+}
+
+// resourceKinds returns the names and short names of the resources served
+// by the cluster, without subresources
+func (c *KubeCollector) resourceKinds() []string {
+	// Partial results are still useful if some API groups are unavailable
+	_, lists, _ := c.client.Discovery().ServerGroupsAndResources()
+
+	var kinds []string
+	for _, list := range lists {
+		for _, resource := range list.APIResources {
+			if strings.Contains(resource.Name, "/") {
+				continue
+			}
+			kinds = append(kinds, resource.Name)
+			kinds = append(kinds, resource.ShortNames...)
+		}
+	}
+	return sortedUnique(kinds)
+}
+
+// sortedUnique sorts names and removes duplicates
+func sortedUnique(names []string) []string {
+	sort.Strings(names)
+	unique := names[:0]
+	for i, name := range names {
+		if i == 0 || name != names[i-1] {
+			unique = append(unique, name)
+		}
+	}
+	return unique
+}
+
+// CachedCompletions returns the completions cached with the cluster
+// context of kubeConfig's kube-context and namespace, however old they are.
+// It returns nil if nothing is cached.
+func CachedCompletions(kubeConfig *config.KubernetesConfig) *Completions {
+	kubeContext := CurrentKubeContext(kubeConfig)
+	if kubeContext == "" {
+		return nil
+	}
+	var namespace string
+	if kubeConfig != nil {
+		namespace = kubeConfig.Namespace
+	}
+
+	path, err := contextCachePath(kubeContext, namespace)
+	if err != nil {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var cached cachedContext
+	if err := json.Unmarshal(data, &cached); err != nil || cached.KubeContext != kubeContext {
+		return nil
+	}
+	return cached.Info.Completions
+}
@@ -0,0 +1,71 @@
+package context
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"k8x/internal/config"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestKubeCollectorCompletions(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "api-0", Namespace: "shop"}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "api-1", Namespace: "shop"}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "coredns", Namespace: "kube-system"}},
+	)
+	client.Discovery().(*fakediscovery.FakeDiscovery).Resources = []*metav1.APIResourceList{
+		{
+			GroupVersion: "v1",
+			APIResources: []metav1.APIResource{
+				{Name: "pods", ShortNames: []string{"po"}, Verbs: metav1.Verbs{"list"}},
+				{Name: "pods/log", Verbs: metav1.Verbs{"get"}},
+			},
+		},
+		{
+			GroupVersion: "apps/v1",
+			APIResources: []metav1.APIResource{
+				{Name: "deployments", ShortNames: []string{"deploy"}, Verbs: metav1.Verbs{"list"}},
+			},
+		},
+	}
+
+	completions := newKubeCollector(client, "kind-dev", "shop").Completions(context.Background())
+	if got := strings.Join(completions.Kinds, ","); got != "deploy,deployments,po,pods" {
+		t.Errorf("Kinds = %s, want deploy,deployments,po,pods", got)
+	}
+	if got := strings.Join(completions.Pods, ","); got != "api-0,api-1" {
+		t.Errorf("Pods = %s, want api-0,api-1", got)
+	}
+}
+
+func TestCachedCompletions(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	kubeConfig := &config.KubernetesConfig{Context: "kind-dev", Namespace: "shop"}
+
+	if got := CachedCompletions(kubeConfig); got != nil {
+		t.Errorf("CachedCompletions() = %+v without a cache, want nil", got)
+	}
+
+	info := &ContextInfo{
+		KubeContext: "kind-dev",
+		Sections:    []Section{{Provider: "cluster", Title: "Cluster", Content: "ok"}},
+		Completions: &Completions{Kinds: []string{"pods"}, Pods: []string{"api-0"}},
+	}
+	if err := saveCachedContext("kind-dev", "shop", info); err != nil {
+		t.Fatal(err)
+	}
+
+	got := CachedCompletions(kubeConfig)
+	if got == nil || strings.Join(got.Pods, ",") != "api-0" {
+		t.Errorf("CachedCompletions() = %+v, want the cached pods", got)
+	}
+	if got := CachedCompletions(&config.KubernetesConfig{Context: "kind-dev"}); got != nil {
+		t.Errorf("CachedCompletions() for another namespace = %+v, want nil", got)
+	}
+}
@@ -1,279 +1,291 @@
 package context
 
 import (
-	"bufio"
+	"context"
 	"fmt"
+	"k8x/internal/config"
 	"k8x/internal/llm"
 	"os"
 	"strings"
+	"sync"
+	"time"
 )
 
-// ContextInfo holds all relevant cluster and shell context for k8x
-// Add more fields as needed for future context expansion
-// This struct is designed for easy extension and testing
-// You can add more fields for additional context sources
+// Section is the context contributed by one provider
+type Section struct {
+	Provider string `json:"provider"`
+	Title    string `json:"title"`
+	Content  string `json:"content"`
+	// Cluster is the kube-context the section describes in a multi-cluster
+	// session, empty otherwise
+	Cluster string `json:"cluster,omitempty"`
+}
 
+// ContextInfo holds all relevant cluster and shell context for k8x, one
+// section per enabled ContextProvider
 type ContextInfo struct {
-	KubectlVersion string
-	ClusterVersion string
-	Namespaces     string
-	ToolsCheck     string
-	HelmReleases   string
-	RecentExamples string
+	// KubeContext is the kube-context the cluster sections describe, empty
+	// if no kubeconfig is usable
+	KubeContext string `json:"kube_context,omitempty"`
+	// Clusters are the kube-contexts of a multi-cluster session
+	Clusters []string  `json:"clusters,omitempty"`
+	Sections []Section `json:"sections"`
+	// Completions are names for the console's tab completion, gathered for
+	// single-cluster sessions
+	Completions *Completions `json:"completions,omitempty"`
 }
 
-// BuildContextInfo gathers cluster, tool, helm, and shell history context for k8x
-// All shell execution and file operations should be injected for testability
-func BuildContextInfo(
-	toolManager *llm.ToolManager,
-	historyFiles []string,
-) (*ContextInfo, error) {
-	var (
-		kubectlVersion string
-		clusterVersion string
-		namespaces     string
-		toolsCheck     string
-		helmReleases   string
-		recentExamples string
-	)
-
-	// Get kubectl version
-	kubectlVersion, err := toolManager.ExecuteTool("execute_shell_command", `{"command": "kubectl version --client --output=yaml | grep 'gitVersion:' | head -1 | awk '{print $2}'"}`)
-	if err != nil || strings.TrimSpace(kubectlVersion) == "" {
-		kubectlVersionFallback, errFallback := toolManager.ExecuteTool("execute_shell_command", `{"command": "kubectl version --client --short"}`)
-		if errFallback != nil {
-			kubectlVersion = fmt.Sprintf("Error getting kubectl version: %v (fallback error: %v)", err, errFallback)
-		} else {
-			kubectlVersion = strings.TrimSpace(kubectlVersionFallback)
+// Section returns the section contributed by a provider
+func (c *ContextInfo) Section(provider string) (Section, bool) {
+	for _, section := range c.Sections {
+		if section.Provider == provider {
+			return section, true
 		}
-	} else {
-		kubectlVersion = strings.TrimSpace(kubectlVersion)
 	}
+	return Section{}, false
+}
 
-	// Get cluster version
-	clusterVersion, err = toolManager.ExecuteTool("execute_shell_command", `{"command": "kubectl version --output=yaml 2>/dev/null | grep 'gitVersion:' | tail -1 | awk '{print $2}'"}`)
-	if err != nil || strings.TrimSpace(clusterVersion) == "" {
-		clusterVersion = "No cluster connection available"
-	} else {
-		clusterVersion = strings.TrimSpace(clusterVersion)
-	}
+// Options selects the sources BuildContextInfo gathers context from
+type Options struct {
+	// Kubernetes selects the kubeconfig, context and namespace
+	Kubernetes *config.KubernetesConfig
+	// Goal is the user's goal, used to filter the health snapshot
+	Goal string
+	// HistoryFiles are the shell history files searched for examples
+	HistoryFiles []string
+	// Providers enables, disables and configures context providers by name
+	Providers map[string]config.ContextProviderConfig
+	// CacheTTL enables reusing context gathered for the same kube-context
+	// within the TTL by BuildContextInfoString. Zero disables the cache.
+	CacheTTL time.Duration
+	// Refresh gathers fresh context even if a cached one is valid
+	Refresh bool
+	// Quiet prints a one-line summary instead of the full context
+	Quiet bool
+}
 
-	// Get namespaces
-	namespaces, err = toolManager.ExecuteTool("execute_shell_command", `{"command": "kubectl get namespaces --output=name"}`)
-	if err != nil {
-		namespaces = "No cluster connection available"
-	} else {
-		namespaceList := strings.Split(strings.TrimSpace(namespaces), "\n")
-		namespaces = strings.Join(namespaceList, ", ")
+// BuildContextInfo gathers context from all enabled providers concurrently.
+// Cluster information is read from the Kubernetes API using opts.Kubernetes;
+// the kubectl shell commands are only used when no kubeconfig is usable.
+// In multi-cluster sessions, per-cluster providers are gathered for each
+// kube-context.
+func BuildContextInfo(toolManager *llm.ToolManager, opts Options) (*ContextInfo, error) {
+	enabled := enabledProviders(opts.Providers)
+	if opts.Kubernetes != nil && opts.Kubernetes.MultiCluster() {
+		return buildMultiClusterContextInfo(toolManager, opts, enabled), nil
 	}
 
-	// Check for common tools
-	toolsCheck = ""
-	var helmAvailable bool
-	for _, tool := range []string{"kubectl", "helm", "kustomize", "jq"} {
-		result, err := toolManager.ExecuteTool("execute_shell_command", fmt.Sprintf(`{"command": "which %s"}`, tool))
-		if err == nil && strings.TrimSpace(result) != "" {
-			version, _ := toolManager.ExecuteTool("execute_shell_command", fmt.Sprintf(`{"command": "%s version --short 2>/dev/null || %s --version 2>/dev/null || echo 'version unknown'"}`, tool, tool))
-			toolsCheck += fmt.Sprintf("- %s: %s (%s)\n", tool, strings.TrimSpace(result), strings.TrimSpace(version))
-			if tool == "helm" {
-				helmAvailable = true
-			}
+	env := newEnvironment(toolManager, opts)
+
+	// Gather completions alongside the sections so that an unreachable
+	// cluster doesn't delay the context twice
+	completions := make(chan *Completions, 1)
+	go func() {
+		if collector, err := env.Collector(); err == nil {
+			completions <- collector.Completions(context.Background())
 		} else {
-			toolsCheck += fmt.Sprintf("- %s: not available\n", tool)
+			completions <- nil
 		}
+	}()
+
+	info := &ContextInfo{
+		Sections:    gatherSections(context.Background(), env, enabled),
+		Completions: <-completions,
 	}
+	if collector, err := env.Collector(); err == nil {
+		info.KubeContext = collector.contextName
+	}
+	return info, nil
+}
 
-	// Helm releases
-	if helmAvailable {
-		releases, err := toolManager.ExecuteTool("execute_shell_command", `{"command": "helm list --all-namespaces"}`)
-		if err != nil {
-			helmReleases = fmt.Sprintf("Error getting Helm releases: %v", err)
+// buildMultiClusterContextInfo gathers session-wide providers once and
+// per-cluster providers for each kube-context of the session, concurrently.
+// Per-cluster sections are grouped by cluster and titled with its context.
+func buildMultiClusterContextInfo(toolManager *llm.ToolManager, opts Options, enabled []enabledProvider) *ContextInfo {
+	var shared, perCluster []enabledProvider
+	for _, p := range enabled {
+		if p.perCluster {
+			perCluster = append(perCluster, p)
 		} else {
-			helmReleases = releases
+			shared = append(shared, p)
 		}
-	} else {
-		helmReleases = "Helm not available"
 	}
 
-	// Recent CLI examples from shell history
-	historyPath := ""
-	for _, file := range historyFiles {
-		path := file
-		if strings.HasPrefix(file, "~") {
-			homeDir, err := os.UserHomeDir()
-			if err == nil {
-				path = strings.Replace(file, "~", homeDir, 1)
+	contexts := opts.Kubernetes.SessionContexts()
+	results := make([][]Section, len(contexts)+1)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		results[0] = gatherSections(context.Background(), newEnvironment(toolManager, opts), shared)
+	}()
+	for i, name := range contexts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			kubernetes := *opts.Kubernetes
+			kubernetes.Context = name
+			kubernetes.Contexts = nil
+			clusterOpts := opts
+			clusterOpts.Kubernetes = &kubernetes
+
+			env := newEnvironment(toolManager, clusterOpts)
+			env.Cluster = name
+			sections := gatherSections(context.Background(), env, perCluster)
+			for j := range sections {
+				sections[j].Cluster = name
+				sections[j].Title = fmt.Sprintf("%s [%s]", sections[j].Title, name)
 			}
+			results[i+1] = sections
+		}()
+	}
+	wg.Wait()
+
+	info := &ContextInfo{Clusters: contexts}
+	for _, sections := range results {
+		info.Sections = append(info.Sections, sections...)
+	}
+	return info
+}
+
+// BuildContextInfoString gathers cluster context and returns a formatted string for LLM prompt
+func BuildContextInfoString(toolManager *llm.ToolManager, opts Options) (string, error) {
+	var (
+		ctxInfo     *ContextInfo
+		gatheredAt  time.Time
+		cached      bool
+		kubeContext string
+		namespace   string
+	)
+
+	// Multi-cluster context isn't cached, it is keyed by a single kube-context
+	multiCluster := opts.Kubernetes != nil && opts.Kubernetes.MultiCluster()
+	if opts.CacheTTL > 0 && !multiCluster {
+		kubeContext = CurrentKubeContext(opts.Kubernetes)
+		if opts.Kubernetes != nil {
+			namespace = opts.Kubernetes.Namespace
 		}
-		if _, err := os.Stat(path); err == nil {
-			historyPath = path
-			break
+		if kubeContext != "" && !opts.Refresh {
+			ctxInfo, gatheredAt, cached = loadCachedContext(kubeContext, namespace, opts.CacheTTL)
 		}
 	}
-	cmds := []string{}
-	if historyPath != "" {
-		file, err := os.Open(historyPath)
-		if err == nil {
-			defer func() {
-				if err := file.Close(); err != nil {
-					fmt.Fprintf(os.Stderr, "error closing file %s: %v\n", file.Name(), err)
-				}
-			}()
-			scanner := bufio.NewScanner(file)
-			var lines []string
-			for scanner.Scan() {
-				lines = append(lines, scanner.Text())
-			}
-			if err := scanner.Err(); err == nil {
-				// Process commands from oldest to newest to maintain history order
-				// but collect them in reverse to show most recent first
-				var allCommands []string
-				allCmdSet := make(map[string]struct{})
-
-				i := 0
-				for i < len(lines) {
-					line := lines[i]
-
-					// Skip empty lines
-					if line == "" {
-						i++
-						continue
-					}
-
-					// Handle zsh timestamp format: ": timestamp:duration;command"
-					if strings.HasPrefix(line, ":") {
-						semicolonIndex := strings.Index(line, ";")
-						if semicolonIndex != -1 && semicolonIndex < len(line)-1 {
-							line = line[semicolonIndex+1:]
-						} else {
-							i++
-							continue
-						}
-					}
-
-					// Check if this line starts a command we're interested in
-					if strings.HasPrefix(line, "kubectl") || strings.HasPrefix(line, "helm") || strings.HasPrefix(line, "kustomize") {
-						// Reconstruct the complete command, handling multi-line continuations
-						fullCommand := line
-						j := i + 1
-
-						// Look forward for continuation lines (current line ends with \)
-						for strings.HasSuffix(fullCommand, "\\") && j < len(lines) {
-							nextLine := lines[j]
-
-							// Handle zsh timestamp format in continuation lines
-							if strings.HasPrefix(nextLine, ":") {
-								semicolonIndex := strings.Index(nextLine, ";")
-								if semicolonIndex != -1 && semicolonIndex < len(nextLine)-1 {
-									nextLine = nextLine[semicolonIndex+1:]
-								} else {
-									// If it's just a timestamp line without command, stop
-									break
-								}
-							}
-
-							// Add the continuation line
-							fullCommand += "\n" + nextLine
-							j++
-						}
-
-						// Add the complete command if we haven't seen it before
-						if _, exists := allCmdSet[fullCommand]; !exists {
-							allCmdSet[fullCommand] = struct{}{}
-							allCommands = append(allCommands, fullCommand)
-						}
-
-						// Skip the lines we've already processed as part of this command
-						i = j
-					} else {
-						i++
-					}
-				}
-
-				// Reverse the commands to show most recent first, and limit to 20
-				for i := len(allCommands) - 1; i >= 0 && len(cmds) < 20; i-- {
-					cmds = append(cmds, allCommands[i])
-				}
-				if len(cmds) > 20 {
-					cmds = cmds[:20]
-				}
-				if len(cmds) > 0 {
-					recentExamples = "Recent Examples:\n" + strings.Join(cmds, "\n")
-				}
+
+	if !cached {
+		var err error
+		ctxInfo, err = BuildContextInfo(toolManager, opts)
+		if err != nil {
+			return "", fmt.Errorf("failed to build context info: %w", err)
+		}
+
+		// Only context read from a reachable cluster is worth reusing
+		if opts.CacheTTL > 0 && !multiCluster && kubeContext != "" && ctxInfo.KubeContext == kubeContext {
+			if err := saveCachedContext(kubeContext, namespace, ctxInfo); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
 			}
 		}
 	}
 
-	// Ensure context values have (unavailable) as default if empty
-	if strings.TrimSpace(kubectlVersion) == "" {
-		kubectlVersion = "(unavailable)"
-	}
-	if strings.TrimSpace(clusterVersion) == "" {
-		clusterVersion = "(unavailable)"
-	}
-	if strings.TrimSpace(namespaces) == "" {
-		namespaces = "(unavailable)"
-	}
-	if strings.TrimSpace(toolsCheck) == "" {
-		toolsCheck = "(unavailable)"
+	cacheNote := ""
+	if cached {
+		cacheNote = fmt.Sprintf(" (cached %s ago, /refresh to update)", time.Since(gatheredAt).Round(time.Second))
 	}
-	if strings.TrimSpace(helmReleases) == "" {
-		helmReleases = "(unavailable)"
+
+	var body strings.Builder
+	for _, section := range ctxInfo.Sections {
+		fmt.Fprintf(&body, "%s:\n%s\n\n", section.Title, strings.TrimRight(section.Content, "\n"))
 	}
-	if strings.TrimSpace(recentExamples) == "" {
-		recentExamples = "(unavailable)"
+
+	if opts.Quiet {
+		names := make([]string, 0, len(ctxInfo.Sections))
+		for _, section := range ctxInfo.Sections {
+			names = append(names, section.Provider)
+		}
+		kubeContext := ctxInfo.KubeContext
+		if len(ctxInfo.Clusters) > 0 {
+			kubeContext = strings.Join(ctxInfo.Clusters, ", ")
+		} else if kubeContext == "" {
+			kubeContext = "no cluster"
+		}
+		fmt.Printf("📋 Cluster context for %s: %s%s\n", kubeContext, strings.Join(names, ", "), cacheNote)
+	} else {
+		fmt.Println("==============================")
+		fmt.Printf("📋 Cluster Information%s\n", cacheNote)
+		fmt.Println("==============================")
+		fmt.Print(body.String())
+		fmt.Println("==============================")
 	}
 
-	return &ContextInfo{
-		KubectlVersion: kubectlVersion,
-		ClusterVersion: clusterVersion,
-		Namespaces:     namespaces,
-		ToolsCheck:     toolsCheck,
-		HelmReleases:   helmReleases,
-		RecentExamples: recentExamples,
-	}, nil
+	var age time.Duration
+	if cached {
+		age = time.Since(gatheredAt)
+	}
+	return formatContextPrompt(ctxInfo, age), nil
 }
 
-// BuildContextInfoString gathers cluster context and returns a formatted string for LLM prompt
-func BuildContextInfoString(toolManager *llm.ToolManager, historyFiles []string) (string, error) {
-	fmt.Println("==============================")
-	fmt.Println("📋 Cluster Information")
-	fmt.Println("==============================")
-
-	ctxInfo, err := BuildContextInfo(toolManager, historyFiles)
-	if err != nil {
-		return "", fmt.Errorf("failed to build context info: %w", err)
+// formatContextPrompt formats the context for the system prompt. Context
+// reused from the cache is marked with its age, section by section, so that
+// the LLM doesn't take a cached health snapshot for the current state.
+func formatContextPrompt(ctxInfo *ContextInfo, age time.Duration) string {
+	clusters := ""
+	if len(ctxInfo.Clusters) > 0 {
+		clusters = fmt.Sprintf("This session spans the kube-contexts %s. Sections marked [context] describe that cluster.\n\n",
+			strings.Join(ctxInfo.Clusters, ", "))
 	}
 
-	fmt.Printf("kubectl Version:\n%s\n\n", ctxInfo.KubectlVersion)
-	fmt.Printf("Cluster Version:\n%s\n\n", ctxInfo.ClusterVersion)
-	fmt.Printf("Available Namespaces:\n%s\n\n", ctxInfo.Namespaces)
-	fmt.Printf("Available CLI Commands:\n%s\n\n", ctxInfo.ToolsCheck)
-	fmt.Printf("Helm Releases:\n%s\n\n", ctxInfo.HelmReleases)
-	fmt.Printf("Recent CLI Examples (may be unoptimized, but useful for context):\n%s\n", ctxInfo.RecentExamples)
-	fmt.Println("==============================")
-
-	contextInfo := fmt.Sprintf(`Here's the current cluster context information: (use only the relevant information towards the goal)
-================
+	staleness, gathered := "", ""
+	if age > 0 {
+		gathered = fmt.Sprintf(" (gathered %s ago)", age.Round(time.Second))
+		staleness = "This context was gathered earlier and may be out of date, especially pod failures and events; verify them with tools before relying on them.\n\n"
+	}
 
-kubectl Version:
-%s
+	var body strings.Builder
+	for _, section := range ctxInfo.Sections {
+		fmt.Fprintf(&body, "%s%s:\n%s\n\n", section.Title, gathered, strings.TrimRight(section.Content, "\n"))
+	}
 
-Cluster Version:
-%s
+	return fmt.Sprintf(`Here's the current cluster context information: (use only the relevant information towards the goal)
+================
 
-Available Namespaces:
-%s
+%s%s%s`, staleness, clusters, body.String())
+}
 
-Available CLI Commands:
-%s
+// BuildGoalContextString gathers the sections of goal-dependent providers
+// for opts.Goal, such as the health snapshot filtered against it and the
+// history examples ranked by it, and formats them for the goal's message.
+// The system prompt is built once per session, so these sections are
+// gathered again for each goal and never cached. It returns "" if there is
+// nothing to add.
+func BuildGoalContextString(toolManager *llm.ToolManager, opts Options) string {
+	if strings.TrimSpace(opts.Goal) == "" {
+		return ""
+	}
 
-Helm Releases:
-%s
+	var enabled []enabledProvider
+	for _, p := range enabledProviders(opts.Providers) {
+		if p.goalDependent {
+			enabled = append(enabled, p)
+		}
+	}
+	if len(enabled) == 0 {
+		return ""
+	}
 
-Recent CLI Examples (may be unoptimized, but useful for context):
-%s
-`, ctxInfo.KubectlVersion, ctxInfo.ClusterVersion, ctxInfo.Namespaces, ctxInfo.ToolsCheck, ctxInfo.HelmReleases, ctxInfo.RecentExamples)
+	var sections []Section
+	if opts.Kubernetes != nil && opts.Kubernetes.MultiCluster() {
+		sections = buildMultiClusterContextInfo(toolManager, opts, enabled).Sections
+	} else {
+		sections = gatherSections(context.Background(), newEnvironment(toolManager, opts), enabled)
+	}
+	if len(sections) == 0 {
+		return ""
+	}
 
-	return contextInfo, nil
+	var body strings.Builder
+	body.WriteString("Context gathered just now for this goal (it supersedes the same sections of the system prompt):\n\n")
+	for _, section := range sections {
+		fmt.Fprintf(&body, "%s:\n%s\n\n", section.Title, strings.TrimRight(section.Content, "\n"))
+	}
+	return strings.TrimRight(body.String(), "\n")
 }
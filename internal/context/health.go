@@ -0,0 +1,374 @@
+package context
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// maxHealthIssues limits how many issues the health summary lists
+const maxHealthIssues = 20
+
+// maxWarningEvents limits how many warning events the health snapshot keeps
+const maxWarningEvents = 10
+
+// warningEventWindow is how far back warning events are collected
+const warningEventWindow = time.Hour
+
+// maxEventMessageLength truncates long event messages
+const maxEventMessageLength = 120
+
+// Issue severities, most severe first. Issues equally relevant to the goal
+// are listed in this order.
+const (
+	severityNode = iota
+	severityDeployment
+	severityPod
+	severityVolume
+	severityEvent
+)
+
+// goalStopWords are ignored when matching issues against the goal
+var goalStopWords = map[string]struct{}{
+	"the": {}, "and": {}, "for": {}, "with": {}, "why": {}, "what": {}, "how": {},
+	"are": {}, "not": {}, "all": {}, "show": {}, "list": {}, "get": {}, "check": {},
+	"cluster": {}, "kubernetes": {}, "k8s": {}, "from": {}, "this": {}, "that": {},
+}
+
+// HealthIssue is a problem found in the cluster
+type HealthIssue struct {
+	// Kind is the lowercase resource kind, e.g. pod or node
+	Kind      string
+	Namespace string
+	Name      string
+	Reason    string
+
+	severity int
+}
+
+// String formats the issue like a kubectl resource reference
+func (i HealthIssue) String() string {
+	if i.Namespace == "" {
+		return fmt.Sprintf("%s/%s: %s", i.Kind, i.Name, i.Reason)
+	}
+	return fmt.Sprintf("%s/%s -n %s: %s", i.Kind, i.Name, i.Namespace, i.Reason)
+}
+
+// HealthSnapshot lists the problems found in the cluster
+type HealthSnapshot struct {
+	Issues []HealthIssue
+	// Errors describes the resources that couldn't be listed
+	Errors []string
+}
+
+// healthSummary collects a health snapshot and summarizes it for the goal
+func (c *KubeCollector) healthSummary(ctx context.Context) (string, error) {
+	snapshot, err := c.healthSnapshot(ctx)
+	if err != nil {
+		return "", err
+	}
+	return snapshot.Summary(c.goal), nil
+}
+
+// healthSnapshot lists non-running and non-ready pods, failing
+// deployments, nodes under pressure, pending PVCs and recent warning
+// events, in the configured namespace or all namespaces. It fails only if
+// none of them can be listed.
+func (c *KubeCollector) healthSnapshot(ctx context.Context) (*HealthSnapshot, error) {
+	snapshot := &HealthSnapshot{}
+	var lastErr error
+	listed := 0
+	record := func(resource string, err error) {
+		snapshot.Errors = append(snapshot.Errors, fmt.Sprintf("could not list %s: %v", resource, err))
+		lastErr = err
+	}
+
+	if nodes, err := c.client.CoreV1().Nodes().List(ctx, metav1.ListOptions{}); err != nil {
+		record("nodes", err)
+	} else {
+		listed++
+		for _, node := range nodes.Items {
+			if reason := nodeProblem(node); reason != "" {
+				snapshot.add("node", "", node.Name, reason, severityNode)
+			}
+		}
+	}
+
+	if deployments, err := c.client.AppsV1().Deployments(c.namespace).List(ctx, metav1.ListOptions{}); err != nil {
+		record("deployments", err)
+	} else {
+		listed++
+		for _, deployment := range deployments.Items {
+			if reason := deploymentProblem(deployment); reason != "" {
+				snapshot.add("deployment", deployment.Namespace, deployment.Name, reason, severityDeployment)
+			}
+		}
+	}
+
+	if pods, err := c.client.CoreV1().Pods(c.namespace).List(ctx, metav1.ListOptions{}); err != nil {
+		record("pods", err)
+	} else {
+		listed++
+		for _, pod := range pods.Items {
+			if reason := podProblem(pod); reason != "" {
+				snapshot.add("pod", pod.Namespace, pod.Name, reason, severityPod)
+			}
+		}
+	}
+
+	if claims, err := c.client.CoreV1().PersistentVolumeClaims(c.namespace).List(ctx, metav1.ListOptions{}); err != nil {
+		record("persistent volume claims", err)
+	} else {
+		listed++
+		for _, claim := range claims.Items {
+			if claim.Status.Phase != corev1.ClaimBound {
+				snapshot.add("pvc", claim.Namespace, claim.Name, string(claim.Status.Phase), severityVolume)
+			}
+		}
+	}
+
+	if events, err := c.client.CoreV1().Events(c.namespace).List(ctx, metav1.ListOptions{FieldSelector: "type=Warning"}); err != nil {
+		record("events", err)
+	} else {
+		listed++
+		snapshot.addWarningEvents(events.Items, time.Now())
+	}
+
+	if listed == 0 {
+		return nil, lastErr
+	}
+	return snapshot, nil
+}
+
+// add appends an issue to the snapshot
+func (s *HealthSnapshot) add(kind, namespace, name, reason string, severity int) {
+	s.Issues = append(s.Issues, HealthIssue{
+		Kind:      kind,
+		Namespace: namespace,
+		Name:      name,
+		Reason:    reason,
+		severity:  severity,
+	})
+}
+
+// addWarningEvents adds the most recent warning events seen within
+// warningEventWindow of now, keeping one event per object and reason
+func (s *HealthSnapshot) addWarningEvents(events []corev1.Event, now time.Time) {
+	latest := make(map[string]corev1.Event)
+	for _, event := range events {
+		if event.Type != corev1.EventTypeWarning || now.Sub(eventTime(event)) > warningEventWindow {
+			continue
+		}
+		key := strings.Join([]string{event.Namespace, event.InvolvedObject.Kind, event.InvolvedObject.Name, event.Reason}, "/")
+		if existing, seen := latest[key]; !seen || eventTime(event).After(eventTime(existing)) {
+			latest[key] = event
+		}
+	}
+
+	recent := make([]corev1.Event, 0, len(latest))
+	for _, event := range latest {
+		recent = append(recent, event)
+	}
+	sort.Slice(recent, func(i, j int) bool {
+		return eventTime(recent[i]).After(eventTime(recent[j]))
+	})
+	if len(recent) > maxWarningEvents {
+		recent = recent[:maxWarningEvents]
+	}
+
+	for _, event := range recent {
+		message := strings.Join(strings.Fields(event.Message), " ")
+		if len(message) > maxEventMessageLength {
+			message = message[:maxEventMessageLength] + "..."
+		}
+		reason := fmt.Sprintf("%s %s", event.Reason, message)
+		if event.Count > 1 {
+			reason += fmt.Sprintf(" (x%d)", event.Count)
+		}
+		kind := "event " + strings.ToLower(event.InvolvedObject.Kind)
+		s.add(kind, event.Namespace, event.InvolvedObject.Name, reason, severityEvent)
+	}
+}
+
+// eventTime returns when an event was last seen
+func eventTime(event corev1.Event) time.Time {
+	switch {
+	case !event.LastTimestamp.IsZero():
+		return event.LastTimestamp.Time
+	case !event.EventTime.IsZero():
+		return event.EventTime.Time
+	}
+	return event.CreationTimestamp.Time
+}
+
+// Summary formats the snapshot compactly for the prompt. Issues mentioning
+// terms from goal, such as a namespace or workload name, are listed first;
+// when any issue matches, unrelated issues are only counted.
+func (s *HealthSnapshot) Summary(goal string) string {
+	var lines []string
+	for _, err := range s.Errors {
+		lines = append(lines, "("+err+")")
+	}
+	if len(s.Issues) == 0 {
+		return strings.Join(append([]string{"No problems found"}, lines...), "\n")
+	}
+
+	terms := goalTerms(goal)
+	scores := make(map[int]int, len(s.Issues))
+	relevant := 0
+	for i, issue := range s.Issues {
+		scores[i] = relevanceScore(issue, terms)
+		if scores[i] > 0 {
+			relevant++
+		}
+	}
+
+	order := make([]int, len(s.Issues))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		ia, ib := s.Issues[order[a]], s.Issues[order[b]]
+		if scores[order[a]] != scores[order[b]] {
+			return scores[order[a]] > scores[order[b]]
+		}
+		if ia.severity != ib.severity {
+			return ia.severity < ib.severity
+		}
+		return ia.String() < ib.String()
+	})
+
+	shown := len(order)
+	if relevant > 0 {
+		shown = relevant
+	}
+	if shown > maxHealthIssues {
+		shown = maxHealthIssues
+	}
+	for _, i := range order[:shown] {
+		lines = append(lines, s.Issues[i].String())
+	}
+
+	if omitted := len(order) - shown; omitted > 0 {
+		if relevant > 0 && relevant <= maxHealthIssues {
+			lines = append(lines, fmt.Sprintf("... and %d other issues unrelated to the goal", omitted))
+		} else {
+			lines = append(lines, fmt.Sprintf("... and %d more", omitted))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// goalTerms splits a goal into lowercase terms worth matching
+func goalTerms(goal string) []string {
+	words := strings.FieldsFunc(strings.ToLower(goal), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-' && r != '.'
+	})
+
+	var terms []string
+	for _, word := range words {
+		word = strings.Trim(word, "-.")
+		if len(word) < 3 {
+			continue
+		}
+		if _, stop := goalStopWords[word]; stop {
+			continue
+		}
+		terms = append(terms, word)
+	}
+	return terms
+}
+
+// relevanceScore counts the goal terms an issue mentions
+func relevanceScore(issue HealthIssue, terms []string) int {
+	text := strings.ToLower(issue.String())
+	score := 0
+	for _, term := range terms {
+		if strings.Contains(text, term) {
+			score++
+		}
+	}
+	return score
+}
+
+// nodeProblem describes the pressure conditions of a node, or returns an
+// empty string if it has none
+func nodeProblem(node corev1.Node) string {
+	var problems []string
+	for _, condition := range node.Status.Conditions {
+		switch condition.Type {
+		case corev1.NodeReady:
+			if condition.Status != corev1.ConditionTrue {
+				problems = append(problems, "NotReady")
+			}
+		case corev1.NodeMemoryPressure, corev1.NodeDiskPressure, corev1.NodePIDPressure, corev1.NodeNetworkUnavailable:
+			if condition.Status == corev1.ConditionTrue {
+				problems = append(problems, string(condition.Type))
+			}
+		}
+	}
+	if node.Spec.Unschedulable {
+		problems = append(problems, "SchedulingDisabled")
+	}
+	return strings.Join(problems, ", ")
+}
+
+// deploymentProblem describes why a deployment is unhealthy, or returns an
+// empty string if it is healthy
+func deploymentProblem(deployment appsv1.Deployment) string {
+	desired := int32(1)
+	if deployment.Spec.Replicas != nil {
+		desired = *deployment.Spec.Replicas
+	}
+	if deployment.Status.ReadyReplicas < desired {
+		return fmt.Sprintf("%d/%d replicas ready", deployment.Status.ReadyReplicas, desired)
+	}
+	return ""
+}
+
+// podProblem describes why a pod is not running or not ready, or returns an
+// empty string if it is healthy
+func podProblem(pod corev1.Pod) string {
+	switch pod.Status.Phase {
+	case corev1.PodSucceeded:
+		return ""
+	case corev1.PodFailed, corev1.PodUnknown:
+		if pod.Status.Reason != "" {
+			return fmt.Sprintf("%s (%s)", pod.Status.Phase, pod.Status.Reason)
+		}
+		return string(pod.Status.Phase)
+	}
+
+	for _, status := range pod.Status.ContainerStatuses {
+		if waiting := status.State.Waiting; waiting != nil && waiting.Reason != "" && waiting.Reason != "ContainerCreating" {
+			return fmt.Sprintf("container %s %s, %d restarts", status.Name, waiting.Reason, status.RestartCount)
+		}
+		if terminated := status.State.Terminated; terminated != nil && terminated.ExitCode != 0 {
+			return fmt.Sprintf("container %s terminated with exit code %d", status.Name, terminated.ExitCode)
+		}
+	}
+
+	for _, condition := range pod.Status.Conditions {
+		if condition.Status != corev1.ConditionFalse {
+			continue
+		}
+		switch {
+		case condition.Type == corev1.PodScheduled:
+			return fmt.Sprintf("Pending (%s)", condition.Reason)
+		case condition.Type == corev1.PodReady && pod.Status.Phase == corev1.PodRunning:
+			return "Running but not ready"
+		}
+	}
+
+	if pod.Status.Phase == corev1.PodPending {
+		return "Pending"
+	}
+	return ""
+}
@@ -0,0 +1,167 @@
+package context
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestHealthSnapshot(t *testing.T) {
+	recent := metav1.NewTime(time.Now().Add(-5 * time.Minute))
+	stale := metav1.NewTime(time.Now().Add(-3 * time.Hour))
+
+	client := fake.NewSimpleClientset(
+		&corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "node-a"},
+			Status: corev1.NodeStatus{Conditions: []corev1.NodeCondition{
+				{Type: corev1.NodeReady, Status: corev1.ConditionTrue},
+				{Type: corev1.NodeDiskPressure, Status: corev1.ConditionTrue},
+				{Type: corev1.NodeMemoryPressure, Status: corev1.ConditionFalse},
+			}},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "api-0", Namespace: "shop"},
+			Status: corev1.PodStatus{
+				Phase:      corev1.PodRunning,
+				Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionFalse}},
+			},
+		},
+		&corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: "data", Namespace: "shop"},
+			Status:     corev1.PersistentVolumeClaimStatus{Phase: corev1.ClaimPending},
+		},
+		&corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: "bound", Namespace: "shop"},
+			Status:     corev1.PersistentVolumeClaimStatus{Phase: corev1.ClaimBound},
+		},
+		&corev1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Name: "e1", Namespace: "shop"},
+			InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "api-0"},
+			Type:           corev1.EventTypeWarning,
+			Reason:         "Unhealthy",
+			Message:        "Readiness probe failed:\n connection refused",
+			Count:          4,
+			LastTimestamp:  recent,
+		},
+		&corev1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Name: "e2", Namespace: "shop"},
+			InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "old"},
+			Type:           corev1.EventTypeWarning,
+			Reason:         "BackOff",
+			LastTimestamp:  stale,
+		},
+		&corev1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Name: "e3", Namespace: "shop"},
+			InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "api-0"},
+			Type:           corev1.EventTypeNormal,
+			Reason:         "Pulled",
+			LastTimestamp:  recent,
+		},
+	)
+
+	snapshot, err := newKubeCollector(client, "", "").healthSnapshot(context.Background())
+	if err != nil {
+		t.Fatalf("healthSnapshot() failed: %v", err)
+	}
+
+	var got []string
+	for _, issue := range snapshot.Issues {
+		got = append(got, issue.String())
+	}
+	want := []string{
+		"node/node-a: DiskPressure",
+		"pod/api-0 -n shop: Running but not ready",
+		"pvc/data -n shop: Pending",
+		"event pod/api-0 -n shop: Unhealthy Readiness probe failed: connection refused (x4)",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Issues =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestHealthSnapshotPartialFailure(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	client.PrependReactor("list", "events", func(k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.New("forbidden")
+	})
+
+	snapshot, err := newKubeCollector(client, "", "").healthSnapshot(context.Background())
+	if err != nil {
+		t.Fatalf("healthSnapshot() failed: %v", err)
+	}
+	if summary := snapshot.Summary(""); !strings.Contains(summary, "could not list events: forbidden") {
+		t.Errorf("Summary() = %q, want the events error", summary)
+	}
+}
+
+func TestHealthSnapshotSummary(t *testing.T) {
+	snapshot := &HealthSnapshot{}
+	snapshot.add("pod", "shop", "api-0", "CrashLoopBackOff", severityPod)
+	snapshot.add("pod", "billing", "worker-1", "Pending", severityPod)
+	snapshot.add("node", "", "node-a", "DiskPressure", severityNode)
+
+	tests := []struct {
+		name string
+		goal string
+		want []string
+	}{
+		{
+			name: "no goal orders by severity",
+			goal: "",
+			want: []string{
+				"node/node-a: DiskPressure",
+				"pod/api-0 -n shop: CrashLoopBackOff",
+				"pod/worker-1 -n billing: Pending",
+			},
+		},
+		{
+			name: "goal mentioning a namespace keeps related issues",
+			goal: "Why is the shop API down?",
+			want: []string{
+				"pod/api-0 -n shop: CrashLoopBackOff",
+				"... and 2 other issues unrelated to the goal",
+			},
+		},
+		{
+			name: "unrelated goal keeps everything",
+			goal: "list ingresses",
+			want: []string{
+				"node/node-a: DiskPressure",
+				"pod/api-0 -n shop: CrashLoopBackOff",
+				"pod/worker-1 -n billing: Pending",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := snapshot.Summary(tt.goal); got != strings.Join(tt.want, "\n") {
+				t.Errorf("Summary(%q) =\n%s\nwant\n%s", tt.goal, got, strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestHealthSnapshotSummaryLimit(t *testing.T) {
+	snapshot := &HealthSnapshot{}
+	for i := 0; i < maxHealthIssues+5; i++ {
+		snapshot.add("pod", "default", fmt.Sprintf("pod-%02d", i), "Pending", severityPod)
+	}
+
+	lines := strings.Split(snapshot.Summary(""), "\n")
+	if len(lines) != maxHealthIssues+1 {
+		t.Fatalf("Summary() has %d lines, want %d", len(lines), maxHealthIssues+1)
+	}
+	if lines[maxHealthIssues] != "... and 5 more" {
+		t.Errorf("last line = %q, want ... and 5 more", lines[maxHealthIssues])
+	}
+}
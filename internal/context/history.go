@@ -0,0 +1,287 @@
+package context
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"k8x/internal/kube"
+	"k8x/internal/output"
+)
+
+// maxRecentCommands limits how many shell history examples are included
+const maxRecentCommands = 20
+
+// exampleCommands are the commands taken from shell history as examples
+var exampleCommands = map[string]bool{"kubectl": true, "helm": true, "kustomize": true}
+
+// bashTimestamp matches the timestamp lines bash writes with HISTTIMEFORMAT
+var bashTimestamp = regexp.MustCompile(`^#\d+$`)
+
+// DefaultHistoryFiles returns the shell history files searched for examples:
+// $HISTFILE first, then the default zsh, bash and fish history files
+func DefaultHistoryFiles() []string {
+	var files []string
+	if histFile := os.Getenv("HISTFILE"); histFile != "" {
+		files = append(files, histFile)
+	}
+	return append(files, "~/.zsh_history", "~/.bash_history", "~/.local/share/fish/fish_history")
+}
+
+// historyProvider lists kubectl, helm and kustomize commands from the first
+// shell history file found. Commands run against other kube-contexts are
+// left out, credentials are redacted and commands related to the goal are
+// listed first. The files option overrides the history files searched, as
+// a comma-separated list.
+type historyProvider struct{}
+
+func (historyProvider) Name() string { return "history" }
+func (historyProvider) Title() string {
+	return "Recent CLI Examples (may be unoptimized, but useful for context)"
+}
+
+func (historyProvider) Gather(ctx context.Context, env *Environment) (string, error) {
+	files := env.HistoryFiles
+	if option := env.Options["files"]; option != "" {
+		files = strings.Split(option, ",")
+	}
+
+	historyPath := ""
+	for _, file := range files {
+		path := kube.ExpandHome(strings.TrimSpace(file))
+		if _, err := os.Stat(path); err == nil {
+			historyPath = path
+			break
+		}
+	}
+	if historyPath == "" {
+		return "(unavailable)", nil
+	}
+
+	commands, err := readHistory(historyPath)
+	if err != nil {
+		return "(unavailable)", nil
+	}
+	var activeContexts []string
+	if env.Kubernetes != nil && env.Kubernetes.MultiCluster() {
+		activeContexts = env.Kubernetes.SessionContexts()
+	} else if current := CurrentKubeContext(env.Kubernetes); current != "" {
+		activeContexts = []string{current}
+	}
+	examples := selectHistoryExamples(commands, activeContexts, env.Goal, maxRecentCommands)
+	if len(examples) == 0 {
+		return "(unavailable)", nil
+	}
+	return "Recent Examples:\n" + strings.Join(examples, "\n"), nil
+}
+
+// readHistory returns the commands of a bash, zsh or fish history file,
+// oldest first. Multi-line commands are kept together.
+func readHistory(historyPath string) ([]string, error) {
+	file, err := os.Open(historyPath)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	var lines []string
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if filepath.Base(historyPath) == "fish_history" {
+		return parseFishHistory(lines), nil
+	}
+	return parseShellHistoryLines(lines), nil
+}
+
+// parseShellHistoryLines parses bash and zsh history, including zsh extended
+// history (": timestamp:duration;command") and bash timestamp comments.
+// Lines ending with a backslash are joined with the following line.
+func parseShellHistoryLines(lines []string) []string {
+	var commands []string
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		if line == "" || bashTimestamp.MatchString(line) {
+			continue
+		}
+
+		command, ok := stripZshTimestamp(line)
+		if !ok {
+			continue
+		}
+
+		for strings.HasSuffix(command, "\\") && i+1 < len(lines) {
+			next, ok := stripZshTimestamp(lines[i+1])
+			if !ok || next != lines[i+1] {
+				// A new timestamped entry starts; the continuation was cut off
+				break
+			}
+			command += "\n" + next
+			i++
+		}
+		commands = append(commands, command)
+	}
+	return commands
+}
+
+// stripZshTimestamp removes the zsh extended history prefix from a line. It
+// returns false for a timestamp without a command.
+func stripZshTimestamp(line string) (string, bool) {
+	if !strings.HasPrefix(line, ": ") {
+		return line, true
+	}
+	semicolonIndex := strings.Index(line, ";")
+	if semicolonIndex == -1 || semicolonIndex == len(line)-1 {
+		return "", false
+	}
+	return line[semicolonIndex+1:], true
+}
+
+// parseFishHistory parses the "- cmd: ..." entries of a fish history file,
+// whose newlines and backslashes in commands are escaped
+func parseFishHistory(lines []string) []string {
+	var commands []string
+	for _, line := range lines {
+		command, ok := strings.CutPrefix(line, "- cmd: ")
+		if !ok {
+			continue
+		}
+
+		var unescaped strings.Builder
+		for i := 0; i < len(command); i++ {
+			if command[i] == '\\' && i+1 < len(command) {
+				switch command[i+1] {
+				case 'n':
+					unescaped.WriteByte('\n')
+					i++
+					continue
+				case '\\':
+					unescaped.WriteByte('\\')
+					i++
+					continue
+				}
+			}
+			unescaped.WriteByte(command[i])
+		}
+		commands = append(commands, unescaped.String())
+	}
+	return commands
+}
+
+// selectHistoryExamples picks up to limit distinct kubectl, helm and
+// kustomize commands from history (oldest first) with credentials redacted.
+// If activeContexts is set, commands run against other kube-contexts, by
+// flag or after switching with kubectl config use-context or kubectx, are
+// left out. Commands matching more goal terms come first, then the most
+// recent ones.
+func selectHistoryExamples(commands []string, activeContexts []string, goal string, limit int) []string {
+	type example struct {
+		command string
+		index   int
+		score   int
+	}
+
+	terms := goalTerms(goal)
+	seen := make(map[string]int)
+	var examples []example
+
+	// The kube-context history commands ran against, empty when unknown
+	currentContext := ""
+	for i, command := range commands {
+		fields := strings.Fields(command)
+		if len(fields) == 0 {
+			continue
+		}
+		if switched, ok := switchedContext(fields); ok {
+			currentContext = switched
+			continue
+		}
+		if !exampleCommands[fields[0]] {
+			continue
+		}
+
+		commandContext := explicitContext(fields)
+		if commandContext == "" {
+			commandContext = currentContext
+		}
+		if commandContext != "" && len(activeContexts) > 0 && !slices.Contains(activeContexts, commandContext) {
+			continue
+		}
+
+		redacted := output.RedactCommand(command)
+		score := 0
+		lower := strings.ToLower(redacted)
+		for _, term := range terms {
+			if strings.Contains(lower, term) {
+				score++
+			}
+		}
+
+		// Keep the most recent occurrence of repeated commands
+		if j, ok := seen[redacted]; ok {
+			examples[j].index = i
+			continue
+		}
+		seen[redacted] = len(examples)
+		examples = append(examples, example{command: redacted, index: i, score: score})
+	}
+
+	sort.SliceStable(examples, func(a, b int) bool {
+		if examples[a].score != examples[b].score {
+			return examples[a].score > examples[b].score
+		}
+		return examples[a].index > examples[b].index
+	})
+
+	selected := []string{}
+	for _, e := range examples {
+		if len(selected) == limit {
+			break
+		}
+		selected = append(selected, e.command)
+	}
+	return selected
+}
+
+// switchedContext returns the kube-context a history command switches to.
+// Switching to an unknown context, such as kubectx -, returns "".
+func switchedContext(fields []string) (string, bool) {
+	switch {
+	case fields[0] == "kubectl" && len(fields) >= 4 && fields[1] == "config" && fields[2] == "use-context":
+		return fields[3], true
+	case fields[0] == "kubectx" && len(fields) == 2 && !strings.HasPrefix(fields[1], "-"):
+		return fields[1], true
+	case fields[0] == "kubectx" && len(fields) == 2 && fields[1] == "-":
+		return "", true
+	}
+	return "", false
+}
+
+// explicitContext returns the kube-context selected by a --context or
+// --kube-context flag, or "" if the command has none
+func explicitContext(fields []string) string {
+	for i, field := range fields {
+		for _, flag := range []string{"--context", "--kube-context"} {
+			if value, ok := strings.CutPrefix(field, flag+"="); ok {
+				return value
+			}
+			if field == flag && i+1 < len(fields) {
+				return fields[i+1]
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,134 @@
+package context
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadHistory(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		want    []string
+	}{
+		{
+			name:    "bash with timestamps",
+			file:    ".bash_history",
+			content: "#1700000000\nkubectl get pods\nhelm upgrade app ./chart \\\n  --namespace shop\nls\n",
+			want:    []string{"kubectl get pods", "helm upgrade app ./chart \\\n  --namespace shop", "ls"},
+		},
+		{
+			name:    "zsh extended history",
+			file:    ".zsh_history",
+			content: ": 1700000000:0;kubectl get nodes\n: 1700000001:0;\n: 1700000002:0;kubectl logs api \\\n-f\n",
+			want:    []string{"kubectl get nodes", "kubectl logs api \\\n-f"},
+		},
+		{
+			name: "fish",
+			file: "fish_history",
+			content: "- cmd: kubectl get pods -A\n  when: 1700000000\n" +
+				"- cmd: kubectl apply -f - <<EOF\\nkind: Namespace\\nEOF\n  when: 1700000001\n  paths:\n    - ./x\n",
+			want: []string{"kubectl get pods -A", "kubectl apply -f - <<EOF\nkind: Namespace\nEOF"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			if err := os.WriteFile(path, []byte(tt.content), 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := readHistory(path)
+			if err != nil {
+				t.Fatalf("readHistory() failed: %v", err)
+			}
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("readHistory() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSelectHistoryExamples(t *testing.T) {
+	commands := []string{
+		"kubectl get pods",
+		"kubectl config use-context staging",
+		"kubectl delete pod api-0",
+		"kubectx prod",
+		"kubectl get pods --context=staging",
+		"helm list --kube-context prod",
+		"kubectl create secret generic db --from-literal=password=hunter2",
+		"ls -la",
+		"kubectl logs deploy/checkout -n shop",
+		"kubectl get pods",
+	}
+
+	tests := []struct {
+		name     string
+		contexts []string
+		goal     string
+		limit    int
+		want     []string
+	}{
+		{
+			name:     "filters other contexts and redacts",
+			contexts: []string{"prod"},
+			want: []string{
+				"kubectl get pods",
+				"kubectl logs deploy/checkout -n shop",
+				"kubectl create secret generic db --from-literal=password=***REDACTED***",
+				"helm list --kube-context prod",
+			},
+		},
+		{
+			name:     "ranks by goal",
+			contexts: []string{"prod"},
+			goal:     "why is checkout failing in shop",
+			limit:    2,
+			want: []string{
+				"kubectl logs deploy/checkout -n shop",
+				"kubectl get pods",
+			},
+		},
+		{
+			name:     "multi-cluster session keeps all its contexts",
+			contexts: []string{"prod", "staging"},
+			limit:    5,
+			want: []string{
+				"kubectl get pods",
+				"kubectl logs deploy/checkout -n shop",
+				"kubectl create secret generic db --from-literal=password=***REDACTED***",
+				"helm list --kube-context prod",
+				"kubectl get pods --context=staging",
+			},
+		},
+		{
+			name: "unknown active context keeps everything",
+			want: []string{
+				"kubectl get pods",
+				"kubectl logs deploy/checkout -n shop",
+				"kubectl create secret generic db --from-literal=password=***REDACTED***",
+				"helm list --kube-context prod",
+				"kubectl get pods --context=staging",
+				"kubectl delete pod api-0",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limit := tt.limit
+			if limit == 0 {
+				limit = maxRecentCommands
+			}
+			got := selectHistoryExamples(commands, tt.contexts, tt.goal, limit)
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("selectHistoryExamples() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
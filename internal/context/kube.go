@@ -0,0 +1,261 @@
+package context
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"k8x/internal/config"
+	"k8x/internal/kube"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// DefaultCollectTimeout bounds how long cluster context gathering may take
+const DefaultCollectTimeout = 10 * time.Second
+
+// ClusterInfo is the cluster context gathered through the Kubernetes API.
+// Fields that couldn't be fetched hold a description of the error.
+type ClusterInfo struct {
+	Context       string
+	ServerVersion string
+	Namespaces    string
+	Nodes         string
+}
+
+// KubeCollector gathers cluster context directly from the Kubernetes API
+// using the kubeconfig selected by KubernetesConfig
+type KubeCollector struct {
+	client      kubernetes.Interface
+	contextName string
+	namespace   string
+	timeout     time.Duration
+	goal        string
+}
+
+// NewKubeCollector creates a collector for the kubeconfig, context and
+// namespace in kubeConfig, falling back to the default loading rules
+// (KUBECONFIG, ~/.kube/config) for anything not set. It returns an error
+// when no usable kubeconfig is found.
+func NewKubeCollector(kubeConfig *config.KubernetesConfig) (*KubeCollector, error) {
+	clientConfig := kube.ClientConfig(kubeConfig)
+	restConfig, err := clientConfig.ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("no usable kubeconfig: %w", err)
+	}
+	restConfig.Timeout = DefaultCollectTimeout
+
+	client, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+
+	var namespace string
+	if kubeConfig != nil {
+		namespace = kubeConfig.Namespace
+	}
+
+	return newKubeCollector(client, CurrentKubeContext(kubeConfig), namespace), nil
+}
+
+// CurrentKubeContext returns the kube-context selected by kubeConfig, or
+// the kubeconfig's current context if none is configured. It returns an
+// empty string if the kubeconfig can't be read.
+func CurrentKubeContext(kubeConfig *config.KubernetesConfig) string {
+	if kubeConfig != nil && kubeConfig.Context != "" {
+		return kubeConfig.Context
+	}
+	rawConfig, err := kube.ClientConfig(kubeConfig).RawConfig()
+	if err != nil {
+		return ""
+	}
+	return rawConfig.CurrentContext
+}
+
+// CurrentNamespace returns the namespace selected by kubeConfig, or the
+// namespace of its kube-context in the kubeconfig ("default" if unset)
+func CurrentNamespace(kubeConfig *config.KubernetesConfig) string {
+	namespace, _, err := kube.ClientConfig(kubeConfig).Namespace()
+	if err != nil {
+		return ""
+	}
+	return namespace
+}
+
+// KubeContexts returns the sorted names of the contexts defined in the
+// kubeconfig selected by kubeConfig
+func KubeContexts(kubeConfig *config.KubernetesConfig) ([]string, error) {
+	rawConfig, err := kube.ClientConfig(kubeConfig).RawConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read kubeconfig: %w", err)
+	}
+
+	names := make([]string, 0, len(rawConfig.Contexts))
+	for name := range rawConfig.Contexts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// newKubeCollector creates a collector for an existing client
+func newKubeCollector(client kubernetes.Interface, contextName, namespace string) *KubeCollector {
+	return &KubeCollector{
+		client:      client,
+		contextName: contextName,
+		namespace:   namespace,
+		timeout:     DefaultCollectTimeout,
+	}
+}
+
+// SetTimeout changes how long Collect waits for the API server
+func (c *KubeCollector) SetTimeout(timeout time.Duration) {
+	c.timeout = timeout
+}
+
+// SetGoal sets the user's goal, which the health snapshot is filtered against
+func (c *KubeCollector) SetGoal(goal string) {
+	c.goal = goal
+}
+
+// Collect fetches the server version, namespaces and node summary
+// concurrently. Requests still running when the
+// timeout expires are abandoned and reported as timed out.
+func (c *KubeCollector) Collect(ctx context.Context) *ClusterInfo {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	info := &ClusterInfo{Context: c.contextName}
+	if info.Context == "" {
+		info.Context = "(default)"
+	}
+
+	type result struct {
+		field *string
+		value string
+	}
+	fetchers := map[*string]func(context.Context) (string, error){
+		&info.ServerVersion: c.serverVersion,
+		&info.Namespaces:    c.namespaces,
+		&info.Nodes:         c.nodeSummary,
+	}
+
+	results := make(chan result, len(fetchers))
+	for field, fetch := range fetchers {
+		go func() {
+			value, err := fetch(ctx)
+			if err != nil {
+				value = describeError(ctx, err)
+			}
+			results <- result{field: field, value: value}
+		}()
+	}
+
+	for pending := len(fetchers); pending > 0; pending-- {
+		select {
+		case r := <-results:
+			*r.field = r.value
+			delete(fetchers, r.field)
+		case <-ctx.Done():
+			for field := range fetchers {
+				*field = "(timed out)"
+			}
+			return info
+		}
+	}
+
+	return info
+}
+
+// serverVersion returns the API server's git version
+func (c *KubeCollector) serverVersion(ctx context.Context) (string, error) {
+	// The discovery client doesn't accept a context; Collect stops waiting
+	// for it when ctx expires
+	version, err := c.client.Discovery().ServerVersion()
+	if err != nil {
+		return "", err
+	}
+	return version.GitVersion, nil
+}
+
+// namespaces returns the names of all namespaces
+func (c *KubeCollector) namespaces(ctx context.Context) (string, error) {
+	names, err := c.Namespaces(ctx)
+	if err != nil {
+		return "", err
+	}
+	return strings.Join(names, ", "), nil
+}
+
+// Namespaces returns the sorted names of the namespaces in the cluster
+func (c *KubeCollector) Namespaces(ctx context.Context) ([]string, error) {
+	list, err := c.client.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(list.Items))
+	for _, namespace := range list.Items {
+		names = append(names, namespace.Name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// nodeSummary summarizes node readiness and kubelet versions
+func (c *KubeCollector) nodeSummary(ctx context.Context) (string, error) {
+	list, err := c.client.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return "", err
+	}
+	if len(list.Items) == 0 {
+		return "No nodes found", nil
+	}
+
+	ready := 0
+	versions := make(map[string]int)
+	var notReady []string
+	for _, node := range list.Items {
+		if nodeReady(node) {
+			ready++
+		} else {
+			notReady = append(notReady, node.Name)
+		}
+		versions[node.Status.NodeInfo.KubeletVersion]++
+	}
+
+	versionList := make([]string, 0, len(versions))
+	for version, count := range versions {
+		versionList = append(versionList, fmt.Sprintf("%s (%d)", version, count))
+	}
+	sort.Strings(versionList)
+
+	summary := fmt.Sprintf("%d nodes, %d ready; kubelet versions: %s", len(list.Items), ready, strings.Join(versionList, ", "))
+	if len(notReady) > 0 {
+		sort.Strings(notReady)
+		summary += fmt.Sprintf("\nNot ready: %s", strings.Join(notReady, ", "))
+	}
+	return summary, nil
+}
+
+// nodeReady reports whether a node has the Ready condition set
+func nodeReady(node corev1.Node) bool {
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == corev1.NodeReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// describeError turns a collection error into a value for the prompt
+func describeError(ctx context.Context, err error) string {
+	if ctx.Err() == context.DeadlineExceeded {
+		return "(timed out)"
+	}
+	return fmt.Sprintf("(unavailable: %v)", err)
+}
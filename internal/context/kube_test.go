@@ -0,0 +1,190 @@
+package context
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"k8x/internal/config"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/version"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func int32Ptr(i int32) *int32 {
+	return &i
+}
+
+func TestKubeCollectorCollect(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "kube-system"}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}},
+		&corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "node-a"},
+			Status: corev1.NodeStatus{
+				Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}},
+				NodeInfo:   corev1.NodeSystemInfo{KubeletVersion: "v1.30.1"},
+			},
+		},
+		&corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "node-b"},
+			Status: corev1.NodeStatus{
+				Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionFalse}},
+				NodeInfo:   corev1.NodeSystemInfo{KubeletVersion: "v1.30.1"},
+			},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "web-1", Namespace: "default"},
+			Status: corev1.PodStatus{
+				Phase: corev1.PodRunning,
+				ContainerStatuses: []corev1.ContainerStatus{{
+					Name:         "web",
+					RestartCount: 7,
+					State:        corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+				}},
+			},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "healthy", Namespace: "default"},
+			Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "job-done", Namespace: "default"},
+			Status:     corev1.PodStatus{Phase: corev1.PodSucceeded},
+		},
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+			Spec:       appsv1.DeploymentSpec{Replicas: int32Ptr(3)},
+			Status:     appsv1.DeploymentStatus{ReadyReplicas: 1},
+		},
+	)
+	client.Discovery().(*fakediscovery.FakeDiscovery).FakedServerVersion = &version.Info{GitVersion: "v1.30.2"}
+
+	collector := newKubeCollector(client, "kind-dev", "")
+	info := collector.Collect(context.Background())
+
+	if info.Context != "kind-dev" {
+		t.Errorf("Context = %q, want kind-dev", info.Context)
+	}
+	if info.ServerVersion != "v1.30.2" {
+		t.Errorf("ServerVersion = %q, want v1.30.2", info.ServerVersion)
+	}
+	if info.Namespaces != "default, kube-system" {
+		t.Errorf("Namespaces = %q", info.Namespaces)
+	}
+	if !strings.Contains(info.Nodes, "2 nodes, 1 ready") || !strings.Contains(info.Nodes, "Not ready: node-b") {
+		t.Errorf("Nodes = %q", info.Nodes)
+	}
+
+	health, err := collector.healthSummary(context.Background())
+	if err != nil {
+		t.Fatalf("healthSummary() failed: %v", err)
+	}
+	for _, want := range []string{"deployment/web -n default: 1/3 replicas ready", "pod/web-1 -n default: container web CrashLoopBackOff, 7 restarts"} {
+		if !strings.Contains(health, want) {
+			t.Errorf("Health = %q, missing %q", health, want)
+		}
+	}
+	for _, healthy := range []string{"healthy", "job-done"} {
+		if strings.Contains(health, "pod/"+healthy+" ") {
+			t.Errorf("Health lists healthy pod %s", healthy)
+		}
+	}
+}
+
+func TestKubeCollectorNamespaceScope(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "broken", Namespace: "other"},
+			Status:     corev1.PodStatus{Phase: corev1.PodFailed},
+		},
+	)
+
+	collector := newKubeCollector(client, "", "default")
+	info := collector.Collect(context.Background())
+	if health, _ := collector.healthSummary(context.Background()); health != "No problems found" {
+		t.Errorf("Health = %q, want no problems for pods outside the namespace", health)
+	}
+	if info.Context != "(default)" {
+		t.Errorf("Context = %q, want (default)", info.Context)
+	}
+}
+
+func TestKubeCollectorTimeout(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	client.PrependReactor("list", "nodes", func(k8stesting.Action) (bool, runtime.Object, error) {
+		time.Sleep(200 * time.Millisecond)
+		return true, &corev1.NodeList{}, nil
+	})
+
+	collector := newKubeCollector(client, "slow", "")
+	collector.SetTimeout(20 * time.Millisecond)
+
+	start := time.Now()
+	info := collector.Collect(context.Background())
+	if elapsed := time.Since(start); elapsed > 150*time.Millisecond {
+		t.Errorf("Collect() took %v, want it bounded by the timeout", elapsed)
+	}
+	if info.Nodes != "(timed out)" {
+		t.Errorf("Nodes = %q, want (timed out)", info.Nodes)
+	}
+}
+
+func TestNewKubeCollectorWithoutKubeconfig(t *testing.T) {
+	t.Setenv("KUBECONFIG", "")
+	t.Setenv("HOME", t.TempDir())
+
+	_, err := NewKubeCollector(&config.KubernetesConfig{KubeConfigPath: "~/missing-kubeconfig"})
+	if err == nil {
+		t.Fatal("NewKubeCollector() should fail without a usable kubeconfig")
+	}
+}
+
+func TestKubeContexts(t *testing.T) {
+	kubeconfig := filepath.Join(t.TempDir(), "config")
+	content := `apiVersion: v1
+kind: Config
+current-context: staging
+clusters:
+- name: c
+  cluster:
+    server: https://127.0.0.1:6443
+users:
+- name: u
+contexts:
+- name: staging
+  context: {cluster: c, user: u}
+- name: prod
+  context: {cluster: c, user: u, namespace: shop}
+`
+	if err := os.WriteFile(kubeconfig, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	kubeConfig := &config.KubernetesConfig{KubeConfigPath: kubeconfig}
+	contexts, err := KubeContexts(kubeConfig)
+	if err != nil {
+		t.Fatalf("KubeContexts() failed: %v", err)
+	}
+	if strings.Join(contexts, ",") != "prod,staging" {
+		t.Errorf("KubeContexts() = %v, want [prod staging]", contexts)
+	}
+	if current := CurrentKubeContext(kubeConfig); current != "staging" {
+		t.Errorf("CurrentKubeContext() = %q, want staging", current)
+	}
+	if namespace := CurrentNamespace(kubeConfig); namespace != "default" {
+		t.Errorf("CurrentNamespace() = %q, want default", namespace)
+	}
+	if namespace := CurrentNamespace(&config.KubernetesConfig{KubeConfigPath: kubeconfig, Context: "prod"}); namespace != "shop" {
+		t.Errorf("CurrentNamespace() for prod = %q, want shop", namespace)
+	}
+}
@@ -0,0 +1,267 @@
+package context
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"sync"
+	"time"
+
+	"k8x/internal/config"
+	"k8x/internal/llm"
+)
+
+// DefaultProviderTimeout bounds a provider that doesn't set its own timeout
+const DefaultProviderTimeout = 15 * time.Second
+
+// ContextProvider contributes a section to the cluster context in the
+// system prompt. Providers are registered with RegisterProvider and can be
+// enabled, disabled and configured by name under context.providers in
+// config.yaml.
+type ContextProvider interface {
+	// Name identifies the provider in config.yaml
+	Name() string
+	// Title is the heading of the provider's section
+	Title() string
+	// Gather returns the section content. An empty string omits the section.
+	Gather(ctx context.Context, env *Environment) (string, error)
+}
+
+// ProviderDefaults are the settings of a provider not configured in config.yaml
+type ProviderDefaults struct {
+	// Enabled selects whether the provider runs by default
+	Enabled bool
+	// Timeout bounds the provider (default DefaultProviderTimeout)
+	Timeout time.Duration
+	// PerCluster gathers the provider once for each kube-context of a
+	// multi-cluster session instead of once per session
+	PerCluster bool
+	// GoalDependent marks providers whose section depends on the goal, which
+	// BuildGoalContextString gathers again for each goal
+	GoalDependent bool
+}
+
+// registeredProvider is a provider together with its defaults
+type registeredProvider struct {
+	provider ContextProvider
+	defaults ProviderDefaults
+}
+
+var (
+	providersMu sync.RWMutex
+	providers   []registeredProvider
+)
+
+// RegisterProvider adds a context provider. Sections appear in the prompt in
+// registration order. It panics if a provider with the same name is
+// already registered.
+func RegisterProvider(provider ContextProvider, defaults ProviderDefaults) {
+	providersMu.Lock()
+	defer providersMu.Unlock()
+
+	for _, registered := range providers {
+		if registered.provider.Name() == provider.Name() {
+			panic(fmt.Sprintf("context provider %q registered twice", provider.Name()))
+		}
+	}
+	providers = append(providers, registeredProvider{provider: provider, defaults: defaults})
+}
+
+// RegisteredProviders returns the names of all registered providers and
+// whether they are enabled by default
+func RegisteredProviders() map[string]bool {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+
+	names := make(map[string]bool, len(providers))
+	for _, registered := range providers {
+		names[registered.provider.Name()] = registered.defaults.Enabled
+	}
+	return names
+}
+
+// Environment gives providers access to the session's configuration and to
+// resources shared between providers, such as the Kubernetes client
+type Environment struct {
+	// ToolManager runs shell commands
+	ToolManager *llm.ToolManager
+	// Kubernetes selects the kubeconfig, context and namespace
+	Kubernetes *config.KubernetesConfig
+	// Goal is the user's goal, if known
+	Goal string
+	// HistoryFiles are the shell history files searched for examples
+	HistoryFiles []string
+	// Options are the options configured for the provider being gathered
+	Options map[string]string
+	// Cluster is the kube-context a per-cluster provider gathers in a
+	// multi-cluster session, empty otherwise
+	Cluster string
+
+	shared *sharedEnvironment
+}
+
+// sharedEnvironment holds lazily created resources shared by all providers
+type sharedEnvironment struct {
+	collectorOnce sync.Once
+	collector     *KubeCollector
+	collectorErr  error
+
+	toolsOnce sync.Once
+	tools     map[string]string
+}
+
+// newEnvironment creates the environment for gathering opts
+func newEnvironment(toolManager *llm.ToolManager, opts Options) *Environment {
+	return &Environment{
+		ToolManager:  toolManager,
+		Kubernetes:   opts.Kubernetes,
+		Goal:         opts.Goal,
+		HistoryFiles: opts.HistoryFiles,
+		shared:       &sharedEnvironment{},
+	}
+}
+
+// withOptions returns a copy of the environment with provider options
+func (e *Environment) withOptions(options map[string]string) *Environment {
+	copied := *e
+	copied.Options = options
+	return &copied
+}
+
+// Collector returns the Kubernetes API collector, or an error if no
+// kubeconfig is usable
+func (e *Environment) Collector() (*KubeCollector, error) {
+	e.shared.collectorOnce.Do(func() {
+		e.shared.collector, e.shared.collectorErr = NewKubeCollector(e.Kubernetes)
+		if e.shared.collector != nil {
+			e.shared.collector.SetGoal(e.Goal)
+		}
+	})
+	return e.shared.collector, e.shared.collectorErr
+}
+
+// LookPath returns the path of a CLI tool commonly used with Kubernetes
+// (kubectl, helm, kustomize, jq) and whether it is installed
+func (e *Environment) LookPath(tool string) (string, bool) {
+	e.shared.toolsOnce.Do(func() {
+		e.shared.tools = make(map[string]string)
+		for _, name := range commonTools {
+			if path, err := exec.LookPath(name); err == nil {
+				e.shared.tools[name] = path
+			}
+		}
+	})
+	path, ok := e.shared.tools[tool]
+	return path, ok
+}
+
+// Shell runs a read-only command through the shell executor, against
+// Cluster if set
+func (e *Environment) Shell(command string) (string, error) {
+	if e.ToolManager == nil {
+		return "", fmt.Errorf("shell commands are not available")
+	}
+	return e.ToolManager.ExecuteShellCommandInContext(command, e.Cluster)
+}
+
+// enabledProvider is a provider selected for gathering with its settings
+type enabledProvider struct {
+	provider      ContextProvider
+	timeout       time.Duration
+	options       map[string]string
+	perCluster    bool
+	goalDependent bool
+}
+
+// enabledProviders returns the providers enabled by configs, in
+// registration order
+func enabledProviders(configs map[string]config.ContextProviderConfig) []enabledProvider {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+
+	var enabled []enabledProvider
+	for _, registered := range providers {
+		providerConfig := configs[registered.provider.Name()]
+
+		isEnabled := registered.defaults.Enabled
+		if providerConfig.Enabled != nil {
+			isEnabled = *providerConfig.Enabled
+		}
+		if !isEnabled {
+			continue
+		}
+
+		timeout := registered.defaults.Timeout
+		if providerConfig.Timeout > 0 {
+			timeout = providerConfig.Timeout
+		}
+		if timeout <= 0 {
+			timeout = DefaultProviderTimeout
+		}
+
+		enabled = append(enabled, enabledProvider{
+			provider:      registered.provider,
+			timeout:       timeout,
+			options:       providerConfig.Options,
+			perCluster:    registered.defaults.PerCluster,
+			goalDependent: registered.defaults.GoalDependent,
+		})
+	}
+	return enabled
+}
+
+// gatherSections runs providers concurrently, each bounded by its timeout,
+// and returns their non-empty sections in order. Failing providers are
+// reported in their section instead of failing the whole context.
+func gatherSections(ctx context.Context, env *Environment, enabled []enabledProvider) []Section {
+	contents := make([]string, len(enabled))
+
+	var wg sync.WaitGroup
+	for i, p := range enabled {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			contents[i] = gatherSection(ctx, env.withOptions(p.options), p)
+		}()
+	}
+	wg.Wait()
+
+	var sections []Section
+	for i, p := range enabled {
+		if contents[i] == "" {
+			continue
+		}
+		sections = append(sections, Section{
+			Provider: p.provider.Name(),
+			Title:    p.provider.Title(),
+			Content:  contents[i],
+		})
+	}
+	return sections
+}
+
+// gatherSection runs one provider, abandoning it when its timeout expires
+func gatherSection(ctx context.Context, env *Environment, p enabledProvider) string {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	type result struct {
+		content string
+		err     error
+	}
+	done := make(chan result, 1)
+	go func() {
+		content, err := p.provider.Gather(ctx, env)
+		done <- result{content: content, err: err}
+	}()
+
+	select {
+	case <-ctx.Done():
+		return fmt.Sprintf("(timed out after %v)", p.timeout)
+	case r := <-done:
+		if r.err != nil {
+			return fmt.Sprintf("(unavailable: %v)", r.err)
+		}
+		return r.content
+	}
+}
@@ -0,0 +1,246 @@
+package context
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"k8x/internal/config"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// fakeProvider is a context provider returning fixed content
+type fakeProvider struct {
+	name    string
+	content string
+	err     error
+	delay   time.Duration
+	options map[string]string
+}
+
+func (p *fakeProvider) Name() string  { return p.name }
+func (p *fakeProvider) Title() string { return strings.ToUpper(p.name) }
+
+func (p *fakeProvider) Gather(ctx context.Context, env *Environment) (string, error) {
+	time.Sleep(p.delay)
+	p.options = env.Options
+	return p.content, p.err
+}
+
+// withProviders replaces the registered providers for the duration of a test
+func withProviders(t *testing.T, registered ...registeredProvider) {
+	providersMu.Lock()
+	saved := providers
+	providers = registered
+	providersMu.Unlock()
+
+	t.Cleanup(func() {
+		providersMu.Lock()
+		providers = saved
+		providersMu.Unlock()
+	})
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+func TestEnabledProviders(t *testing.T) {
+	withProviders(t,
+		registeredProvider{provider: &fakeProvider{name: "a"}, defaults: ProviderDefaults{Enabled: true, Timeout: time.Second}},
+		registeredProvider{provider: &fakeProvider{name: "b"}, defaults: ProviderDefaults{Enabled: true}},
+		registeredProvider{provider: &fakeProvider{name: "c"}, defaults: ProviderDefaults{Enabled: false}},
+	)
+
+	enabled := enabledProviders(map[string]config.ContextProviderConfig{
+		"b": {Enabled: boolPtr(false)},
+		"c": {Enabled: boolPtr(true), Timeout: 3 * time.Second, Options: map[string]string{"dir": "/tmp"}},
+	})
+
+	var names []string
+	for _, p := range enabled {
+		names = append(names, p.provider.Name())
+	}
+	if !reflect.DeepEqual(names, []string{"a", "c"}) {
+		t.Fatalf("enabledProviders() = %v, want [a c]", names)
+	}
+	if enabled[0].timeout != time.Second {
+		t.Errorf("a timeout = %v, want the default 1s", enabled[0].timeout)
+	}
+	if enabled[1].timeout != 3*time.Second || enabled[1].options["dir"] != "/tmp" {
+		t.Errorf("c = %+v, want the configured timeout and options", enabled[1])
+	}
+}
+
+func TestRegisterProviderRejectsDuplicates(t *testing.T) {
+	withProviders(t)
+
+	RegisterProvider(&fakeProvider{name: "dup"}, ProviderDefaults{Enabled: true})
+	defer func() {
+		if recover() == nil {
+			t.Error("RegisterProvider() should panic on a duplicate name")
+		}
+	}()
+	RegisterProvider(&fakeProvider{name: "dup"}, ProviderDefaults{Enabled: true})
+}
+
+func TestGatherSections(t *testing.T) {
+	withOptions := &fakeProvider{name: "options", content: "with options"}
+	enabled := []enabledProvider{
+		{provider: &fakeProvider{name: "first", content: "one"}, timeout: time.Second},
+		{provider: &fakeProvider{name: "empty"}, timeout: time.Second},
+		{provider: &fakeProvider{name: "failing", err: errors.New("boom")}, timeout: time.Second},
+		{provider: &fakeProvider{name: "slow", content: "late", delay: 200 * time.Millisecond}, timeout: 20 * time.Millisecond},
+		{provider: withOptions, timeout: time.Second, options: map[string]string{"key": "value"}},
+	}
+
+	start := time.Now()
+	sections := gatherSections(context.Background(), newEnvironment(nil, Options{}), enabled)
+	if elapsed := time.Since(start); elapsed > 150*time.Millisecond {
+		t.Errorf("gatherSections() took %v, want slow providers abandoned", elapsed)
+	}
+
+	want := []Section{
+		{Provider: "first", Title: "FIRST", Content: "one"},
+		{Provider: "failing", Title: "FAILING", Content: "(unavailable: boom)"},
+		{Provider: "slow", Title: "SLOW", Content: "(timed out after 20ms)"},
+		{Provider: "options", Title: "OPTIONS", Content: "with options"},
+	}
+	if !reflect.DeepEqual(sections, want) {
+		t.Errorf("gatherSections() =\n%+v\nwant\n%+v", sections, want)
+	}
+	if withOptions.options["key"] != "value" {
+		t.Errorf("provider options = %v, want key=value", withOptions.options)
+	}
+}
+
+// clusterEchoProvider reports the cluster it is gathered for
+type clusterEchoProvider struct{}
+
+func (clusterEchoProvider) Name() string  { return "echo" }
+func (clusterEchoProvider) Title() string { return "Echo" }
+
+func (clusterEchoProvider) Gather(ctx context.Context, env *Environment) (string, error) {
+	return env.Cluster + "/" + env.Kubernetes.Context, nil
+}
+
+func TestBuildMultiClusterContextInfo(t *testing.T) {
+	enabled := []enabledProvider{
+		{provider: &fakeProvider{name: "shared", content: "once"}, timeout: time.Second},
+		{provider: clusterEchoProvider{}, timeout: time.Second, perCluster: true},
+	}
+	opts := Options{Kubernetes: &config.KubernetesConfig{Context: "prod", Contexts: []string{"staging"}}}
+
+	info := buildMultiClusterContextInfo(nil, opts, enabled)
+
+	want := &ContextInfo{
+		Clusters: []string{"prod", "staging"},
+		Sections: []Section{
+			{Provider: "shared", Title: "SHARED", Content: "once"},
+			{Provider: "echo", Title: "Echo [prod]", Content: "prod/prod", Cluster: "prod"},
+			{Provider: "echo", Title: "Echo [staging]", Content: "staging/staging", Cluster: "staging"},
+		},
+	}
+	if !reflect.DeepEqual(info, want) {
+		t.Errorf("buildMultiClusterContextInfo() =\n%+v\nwant\n%+v", info, want)
+	}
+}
+
+// goalEchoProvider reports the goal it is gathered for
+type goalEchoProvider struct{}
+
+func (goalEchoProvider) Name() string  { return "goal" }
+func (goalEchoProvider) Title() string { return "Goal" }
+
+func (goalEchoProvider) Gather(ctx context.Context, env *Environment) (string, error) {
+	return "filtered for " + env.Goal, nil
+}
+
+func TestBuildGoalContextString(t *testing.T) {
+	withProviders(t,
+		registeredProvider{provider: &fakeProvider{name: "static", content: "same for every goal"}, defaults: ProviderDefaults{Enabled: true}},
+		registeredProvider{provider: goalEchoProvider{}, defaults: ProviderDefaults{Enabled: true, GoalDependent: true}},
+	)
+
+	got := BuildGoalContextString(nil, Options{Goal: "why is checkout failing?"})
+	if !strings.Contains(got, "Goal:\nfiltered for why is checkout failing?") {
+		t.Errorf("BuildGoalContextString() = %q, want the goal-dependent section", got)
+	}
+	if strings.Contains(got, "same for every goal") {
+		t.Errorf("BuildGoalContextString() = %q, want only goal-dependent sections", got)
+	}
+	if got := BuildGoalContextString(nil, Options{}); got != "" {
+		t.Errorf("BuildGoalContextString() without a goal = %q, want nothing", got)
+	}
+}
+
+func TestCRDProvider(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	client.Resources = []*metav1.APIResourceList{
+		{GroupVersion: "v1"},
+		{GroupVersion: "apps/v1"},
+		{GroupVersion: "networking.k8s.io/v1"},
+		{GroupVersion: "argoproj.io/v1alpha1"},
+		{GroupVersion: "example.com/v1"},
+		{GroupVersion: "gateway.networking.k8s.io/v1"},
+	}
+
+	env := newEnvironment(nil, Options{})
+	env.shared.collectorOnce.Do(func() {
+		env.shared.collector = newKubeCollector(client, "test", "")
+	})
+
+	got, err := crdProvider{}.Gather(context.Background(), env)
+	if err != nil {
+		t.Fatalf("Gather() failed: %v", err)
+	}
+	want := "argoproj.io (Argo)\nexample.com\ngateway.networking.k8s.io (Gateway API)"
+	if got != want {
+		t.Errorf("Gather() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestRunbookProvider(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"dns.md":          "# CoreDNS troubleshooting\n",
+		"db/postgres.md":  "Intro\n## Postgres failover\n",
+		"notes.txt":       "not a runbook",
+		"certificates.md": "no heading",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	env := newEnvironment(nil, Options{Goal: "postgres pods keep restarting"}).withOptions(map[string]string{"dir": dir})
+	got, err := runbookProvider{}.Gather(context.Background(), env)
+	if err != nil {
+		t.Fatalf("Gather() failed: %v", err)
+	}
+
+	want := strings.Join([]string{
+		"- " + filepath.Join(dir, "db/postgres.md") + ": Postgres failover",
+		"- " + filepath.Join(dir, "certificates.md"),
+		"- " + filepath.Join(dir, "dns.md") + ": CoreDNS troubleshooting",
+	}, "\n")
+	if got != want {
+		t.Errorf("Gather() =\n%s\nwant\n%s", got, want)
+	}
+
+	if _, err := (runbookProvider{}).Gather(context.Background(), newEnvironment(nil, Options{})); err == nil {
+		t.Error("Gather() without the dir option should fail")
+	}
+}
@@ -0,0 +1,308 @@
+package context
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"k8x/internal/kube"
+)
+
+// commonTools are the CLI tools reported by the tools provider
+var commonTools = []string{"kubectl", "helm", "kustomize", "jq"}
+
+// maxRunbooks limits how many runbooks are listed
+const maxRunbooks = 50
+
+// wellKnownAPIGroups names the projects behind common custom API groups
+var wellKnownAPIGroups = map[string]string{
+	"argoproj.io":               "Argo",
+	"fluxcd.io":                 "Flux",
+	"istio.io":                  "Istio",
+	"linkerd.io":                "Linkerd",
+	"cilium.io":                 "Cilium",
+	"cert-manager.io":           "cert-manager",
+	"monitoring.coreos.com":     "Prometheus Operator",
+	"keda.sh":                   "KEDA",
+	"knative.dev":               "Knative",
+	"gateway.networking.k8s.io": "Gateway API",
+}
+
+func init() {
+	RegisterProvider(kubectlProvider{}, ProviderDefaults{Enabled: true})
+	RegisterProvider(clusterProvider{}, ProviderDefaults{Enabled: true, Timeout: DefaultCollectTimeout + time.Second, PerCluster: true})
+	RegisterProvider(healthProvider{}, ProviderDefaults{Enabled: true, Timeout: DefaultCollectTimeout + time.Second, PerCluster: true, GoalDependent: true})
+	RegisterProvider(crdProvider{}, ProviderDefaults{Enabled: true, Timeout: DefaultCollectTimeout, PerCluster: true})
+	RegisterProvider(toolsProvider{}, ProviderDefaults{Enabled: true})
+	RegisterProvider(helmProvider{}, ProviderDefaults{Enabled: true, PerCluster: true})
+	RegisterProvider(runbookProvider{}, ProviderDefaults{Enabled: false, GoalDependent: true})
+	RegisterProvider(historyProvider{}, ProviderDefaults{Enabled: true, GoalDependent: true})
+}
+
+// kubectlProvider reports the kubectl client version
+type kubectlProvider struct{}
+
+func (kubectlProvider) Name() string  { return "kubectl" }
+func (kubectlProvider) Title() string { return "kubectl Version" }
+
+func (kubectlProvider) Gather(ctx context.Context, env *Environment) (string, error) {
+	if _, ok := env.LookPath("kubectl"); !ok {
+		return "kubectl not available", nil
+	}
+
+	version, err := env.Shell("kubectl version --client --output=yaml | grep 'gitVersion:' | head -1 | awk '{print $2}'")
+	if err == nil && strings.TrimSpace(version) != "" {
+		return strings.TrimSpace(version), nil
+	}
+	fallback, errFallback := env.Shell("kubectl version --client --short")
+	if errFallback != nil {
+		return fmt.Sprintf("Error getting kubectl version: %v (fallback error: %v)", err, errFallback), nil
+	}
+	return strings.TrimSpace(fallback), nil
+}
+
+// clusterProvider reports the kube-context, server version, namespaces and
+// nodes, read from the Kubernetes API or through kubectl if no kubeconfig
+// is usable
+type clusterProvider struct{}
+
+func (clusterProvider) Name() string  { return "cluster" }
+func (clusterProvider) Title() string { return "Cluster" }
+
+func (clusterProvider) Gather(ctx context.Context, env *Environment) (string, error) {
+	collector, err := env.Collector()
+	if err == nil {
+		info := collector.Collect(ctx)
+		return fmt.Sprintf("Context: %s\nServer Version: %s\nNamespaces: %s\nNodes: %s",
+			info.Context, info.ServerVersion, info.Namespaces, info.Nodes), nil
+	}
+
+	if _, ok := env.LookPath("kubectl"); !ok {
+		return fmt.Sprintf("No cluster connection available (%v)", err), nil
+	}
+
+	clusterVersion, err := env.Shell("kubectl version --output=yaml 2>/dev/null | grep 'gitVersion:' | tail -1 | awk '{print $2}'")
+	if err != nil || strings.TrimSpace(clusterVersion) == "" {
+		clusterVersion = "No cluster connection available"
+	}
+
+	namespaces, err := env.Shell("kubectl get namespaces --output=name")
+	if err != nil {
+		namespaces = "No cluster connection available"
+	} else {
+		namespaces = strings.Join(strings.Split(strings.TrimSpace(namespaces), "\n"), ", ")
+	}
+
+	return fmt.Sprintf("Server Version: %s\nNamespaces: %s", strings.TrimSpace(clusterVersion), namespaces), nil
+}
+
+// healthProvider reports the cluster health snapshot, filtered by the goal
+type healthProvider struct{}
+
+func (healthProvider) Name() string  { return "health" }
+func (healthProvider) Title() string { return "Cluster Health" }
+
+func (healthProvider) Gather(ctx context.Context, env *Environment) (string, error) {
+	if env.Kubernetes != nil && !env.Kubernetes.HealthSnapshotEnabled() {
+		return "", nil
+	}
+	collector, err := env.Collector()
+	if err != nil {
+		// The cluster section already reports the missing connection
+		return "", nil
+	}
+	return collector.healthSummary(ctx)
+}
+
+// crdProvider lists the custom API groups installed in the cluster, which
+// reveal operators such as Argo CD, Flux or a service mesh
+type crdProvider struct{}
+
+func (crdProvider) Name() string  { return "crds" }
+func (crdProvider) Title() string { return "Custom Resource API Groups" }
+
+func (crdProvider) Gather(ctx context.Context, env *Environment) (string, error) {
+	collector, err := env.Collector()
+	if err != nil {
+		return "", nil
+	}
+
+	groups, err := collector.client.Discovery().ServerGroups()
+	if err != nil {
+		return "", err
+	}
+
+	var custom []string
+	for _, group := range groups.Groups {
+		if !isCustomAPIGroup(group.Name) {
+			continue
+		}
+		if project := wellKnownProject(group.Name); project != "" {
+			custom = append(custom, fmt.Sprintf("%s (%s)", group.Name, project))
+		} else {
+			custom = append(custom, group.Name)
+		}
+	}
+	if len(custom) == 0 {
+		return "None", nil
+	}
+	sort.Strings(custom)
+	return strings.Join(custom, "\n"), nil
+}
+
+// isCustomAPIGroup reports whether an API group is not built into Kubernetes
+func isCustomAPIGroup(name string) bool {
+	if !strings.Contains(name, ".") {
+		return false
+	}
+	if _, known := wellKnownAPIGroups[name]; known {
+		return true
+	}
+	return !strings.HasSuffix(name, ".k8s.io")
+}
+
+// wellKnownProject returns the project providing an API group, if known
+func wellKnownProject(group string) string {
+	for domain, project := range wellKnownAPIGroups {
+		if group == domain || strings.HasSuffix(group, "."+domain) {
+			return project
+		}
+	}
+	return ""
+}
+
+// toolsProvider reports which common CLI tools are installed
+type toolsProvider struct{}
+
+func (toolsProvider) Name() string  { return "tools" }
+func (toolsProvider) Title() string { return "Available CLI Commands" }
+
+func (toolsProvider) Gather(ctx context.Context, env *Environment) (string, error) {
+	var toolsCheck strings.Builder
+	for _, tool := range commonTools {
+		path, ok := env.LookPath(tool)
+		if !ok {
+			fmt.Fprintf(&toolsCheck, "- %s: not available\n", tool)
+			continue
+		}
+		version, _ := env.Shell(fmt.Sprintf("%s version --short 2>/dev/null || %s --version 2>/dev/null || echo 'version unknown'", tool, tool))
+		fmt.Fprintf(&toolsCheck, "- %s: %s (%s)\n", tool, path, strings.TrimSpace(version))
+	}
+	return toolsCheck.String(), nil
+}
+
+// helmProvider lists the Helm releases in all namespaces
+type helmProvider struct{}
+
+func (helmProvider) Name() string  { return "helm" }
+func (helmProvider) Title() string { return "Helm Releases" }
+
+func (helmProvider) Gather(ctx context.Context, env *Environment) (string, error) {
+	if _, ok := env.LookPath("helm"); !ok {
+		return "Helm not available", nil
+	}
+	releases, err := env.Shell("helm list --all-namespaces")
+	if err != nil {
+		return fmt.Sprintf("Error getting Helm releases: %v", err), nil
+	}
+	return releases, nil
+}
+
+// runbookProvider lists the runbooks in the directory set by the "dir"
+// option, those mentioning terms from the goal first
+type runbookProvider struct{}
+
+func (runbookProvider) Name() string  { return "runbooks" }
+func (runbookProvider) Title() string { return "Runbooks (read with cat when relevant)" }
+
+func (runbookProvider) Gather(ctx context.Context, env *Environment) (string, error) {
+	dir := env.Options["dir"]
+	if dir == "" {
+		return "", fmt.Errorf("the runbooks provider requires the dir option")
+	}
+	dir = kube.ExpandHome(dir)
+
+	type runbook struct {
+		path  string
+		title string
+		score int
+	}
+	terms := goalTerms(env.Goal)
+
+	var runbooks []runbook
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if entry.IsDir() || !strings.HasSuffix(strings.ToLower(entry.Name()), ".md") {
+			return nil
+		}
+
+		title := runbookTitle(path)
+		text := strings.ToLower(path + " " + title)
+		score := 0
+		for _, term := range terms {
+			if strings.Contains(text, term) {
+				score++
+			}
+		}
+		runbooks = append(runbooks, runbook{path: path, title: title, score: score})
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to read runbooks: %w", err)
+	}
+	if len(runbooks) == 0 {
+		return "No runbooks found", nil
+	}
+
+	sort.SliceStable(runbooks, func(i, j int) bool {
+		if runbooks[i].score != runbooks[j].score {
+			return runbooks[i].score > runbooks[j].score
+		}
+		return runbooks[i].path < runbooks[j].path
+	})
+
+	var lines []string
+	for i, rb := range runbooks {
+		if i == maxRunbooks {
+			lines = append(lines, fmt.Sprintf("... and %d more", len(runbooks)-maxRunbooks))
+			break
+		}
+		if rb.title != "" {
+			lines = append(lines, fmt.Sprintf("- %s: %s", rb.path, rb.title))
+		} else {
+			lines = append(lines, "- "+rb.path)
+		}
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// runbookTitle returns the first markdown heading of a runbook
+func runbookTitle(path string) string {
+	file, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "#") {
+			return strings.TrimSpace(strings.TrimLeft(line, "#"))
+		}
+	}
+	return ""
+}
@@ -0,0 +1,215 @@
+package history
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"k8x/internal/config"
+	"k8x/internal/llm"
+)
+
+// GoalPrefix starts the user messages the console sends for a goal
+const GoalPrefix = "Goal: "
+
+// ManualCommandPrefix starts the user messages carrying the output of a
+// command the user ran in the console with ! or /run
+const ManualCommandPrefix = "Manual command: "
+
+// manualCommandIntro separates a manual command from its output
+const manualCommandIntro = "\n\nI ran this command myself, use its output as evidence:\n\n"
+
+// ManualCommandMessage is the user message adding a manual command's output
+// to the conversation
+func ManualCommandMessage(command, output string) string {
+	return ManualCommandPrefix + command + manualCommandIntro + output
+}
+
+// ParseManualCommand returns the command and output of a message made by
+// ManualCommandMessage
+func ParseManualCommand(content string) (command, output string, ok bool) {
+	rest, ok := strings.CutPrefix(content, ManualCommandPrefix)
+	if !ok {
+		return "", "", false
+	}
+	command, output, _ = strings.Cut(rest, manualCommandIntro)
+	return command, output, true
+}
+
+// conversationNamePattern restricts conversation names to safe file names
+var conversationNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,63}$`)
+
+// ErrConversationNotFound is returned when no conversation has a name
+var ErrConversationNotFound = errors.New("conversation not found")
+
+// Conversation is a named console conversation with its full message history
+type Conversation struct {
+	Name        string            `json:"name"`
+	Created     time.Time         `json:"created"`
+	Updated     time.Time         `json:"updated"`
+	StepCount   int               `json:"step_count"`
+	Environment map[string]string `json:"environment,omitempty"`
+	Messages    []llm.Message     `json:"messages"`
+}
+
+// Goals returns the goals asked in the conversation, in order
+func (c *Conversation) Goals() []string {
+	var goals []string
+	for _, message := range c.Messages {
+		if message.Role != "user" {
+			continue
+		}
+		if goal, ok := strings.CutPrefix(message.Content, GoalPrefix); ok {
+			goal, _, _ = strings.Cut(goal, "\n")
+			goals = append(goals, goal)
+		}
+	}
+	return goals
+}
+
+// ConversationStore saves named conversations as JSON files
+type ConversationStore struct {
+	dir string
+}
+
+// NewConversationStore creates a store in ~/.k8x/conversations
+func NewConversationStore() (*ConversationStore, error) {
+	dir, err := config.GetConversationsDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get conversations directory: %w", err)
+	}
+
+	// Conversations hold cluster output, so keep them private
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create conversations directory: %w", err)
+	}
+	return &ConversationStore{dir: dir}, nil
+}
+
+// ValidateConversationName checks that name can be used as a file name
+func ValidateConversationName(name string) error {
+	if !conversationNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid conversation name %q: use up to 64 letters, digits, '.', '_' or '-'", name)
+	}
+	return nil
+}
+
+// path returns the file of the conversation with name
+func (s *ConversationStore) path(name string) string {
+	return filepath.Join(s.dir, name+".json")
+}
+
+// Save writes a conversation, replacing one saved under the same name
+func (s *ConversationStore) Save(conversation *Conversation) error {
+	if err := ValidateConversationName(conversation.Name); err != nil {
+		return err
+	}
+	now := time.Now()
+	if conversation.Created.IsZero() {
+		conversation.Created = now
+	}
+	conversation.Updated = now
+
+	data, err := json.MarshalIndent(conversation, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode conversation: %w", err)
+	}
+
+	// Write to a temporary file first so a crash doesn't leave a truncated file
+	path := s.path(conversation.Name)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write conversation: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("failed to write conversation: %w", err)
+	}
+	return nil
+}
+
+// Load reads the conversation with name
+func (s *ConversationStore) Load(name string) (*Conversation, error) {
+	if err := ValidateConversationName(name); err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(s.path(name))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrConversationNotFound, name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read conversation: %w", err)
+	}
+
+	var conversation Conversation
+	if err := json.Unmarshal(data, &conversation); err != nil {
+		return nil, fmt.Errorf("failed to parse conversation %s: %w", name, err)
+	}
+	conversation.Name = name
+	return &conversation, nil
+}
+
+// Names returns the names of the saved conversations from the directory
+// entries, without reading them
+func (s *ConversationStore) Names() ([]string, error) {
+	files, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read conversations directory: %w", err)
+	}
+
+	var names []string
+	for _, file := range files {
+		name, ok := strings.CutSuffix(file.Name(), ".json")
+		if file.IsDir() || !ok || ValidateConversationName(name) != nil {
+			continue
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// List returns the saved conversations, most recently updated first.
+// Conversations that can't be read or parsed are skipped.
+func (s *ConversationStore) List() ([]*Conversation, error) {
+	names, err := s.Names()
+	if err != nil {
+		return nil, err
+	}
+
+	var conversations []*Conversation
+	for _, name := range names {
+		conversation, err := s.Load(name)
+		if err != nil {
+			continue
+		}
+		conversations = append(conversations, conversation)
+	}
+
+	sort.Slice(conversations, func(i, j int) bool {
+		return conversations[i].Updated.After(conversations[j].Updated)
+	})
+	return conversations, nil
+}
+
+// Delete removes the conversation with name
+func (s *ConversationStore) Delete(name string) error {
+	if err := ValidateConversationName(name); err != nil {
+		return err
+	}
+
+	err := os.Remove(s.path(name))
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("%w: %s", ErrConversationNotFound, name)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete conversation: %w", err)
+	}
+	return nil
+}
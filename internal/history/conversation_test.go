@@ -0,0 +1,110 @@
+package history
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"k8x/internal/llm"
+)
+
+func TestValidateConversationName(t *testing.T) {
+	tests := []struct {
+		name    string
+		wantErr bool
+	}{
+		{"incident-42", false},
+		{"api_outage.2025", false},
+		{"", true},
+		{"../secrets", true},
+		{"with space", true},
+		{".hidden", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateConversationName(tt.name); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateConversationName(%q) error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConversationStore(t *testing.T) {
+	store := &ConversationStore{dir: t.TempDir()}
+
+	first := &Conversation{
+		Name:      "incident-42",
+		StepCount: 3,
+		Messages: []llm.Message{
+			{Role: "system", Content: "You are k8x"},
+			{Role: "user", Content: GoalPrefix + "why is api failing?\n\nPlease help me."},
+			{Role: "assistant", Content: "Done"},
+			{Role: "user", Content: GoalPrefix + "and the worker?\n\nPlease help me."},
+		},
+	}
+	if err := store.Save(first); err != nil {
+		t.Fatalf("Save() failed: %v", err)
+	}
+	if err := store.Save(&Conversation{Name: "later"}); err != nil {
+		t.Fatalf("Save() failed: %v", err)
+	}
+
+	info, err := os.Stat(filepath.Join(store.dir, "incident-42.json"))
+	if err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("conversation file mode = %v, %v, want 0600", info.Mode().Perm(), err)
+	}
+
+	loaded, err := store.Load("incident-42")
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if loaded.StepCount != 3 || !reflect.DeepEqual(loaded.Messages, first.Messages) {
+		t.Errorf("Load() = %+v, want %+v", loaded, first)
+	}
+	if want := []string{"why is api failing?", "and the worker?"}; !reflect.DeepEqual(loaded.Goals(), want) {
+		t.Errorf("Goals() = %q, want %q", loaded.Goals(), want)
+	}
+
+	if err := os.WriteFile(filepath.Join(store.dir, "broken.json"), []byte("{not json"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	names, err := store.Names()
+	if err != nil {
+		t.Fatalf("Names() failed: %v", err)
+	}
+	if want := []string{"broken", "incident-42", "later"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("Names() = %q, want %q", names, want)
+	}
+
+	conversations, err := store.List()
+	if err != nil {
+		t.Fatalf("List() failed: %v", err)
+	}
+	if len(conversations) != 2 || conversations[0].Name != "later" {
+		t.Errorf("List() should return both readable conversations, most recent first")
+	}
+
+	if err := store.Delete("incident-42"); err != nil {
+		t.Fatalf("Delete() failed: %v", err)
+	}
+	if _, err := store.Load("incident-42"); !errors.Is(err, ErrConversationNotFound) {
+		t.Errorf("Load() of a deleted conversation error = %v, want ErrConversationNotFound", err)
+	}
+	if err := store.Save(&Conversation{Name: "../escape"}); err == nil {
+		t.Error("Save() with an invalid name succeeded")
+	}
+}
+
+func TestManualCommandMessage(t *testing.T) {
+	content := ManualCommandMessage("kubectl get pods -n foo", "api-1 0/1 Error\n\nmore")
+	command, output, ok := ParseManualCommand(content)
+	if !ok || command != "kubectl get pods -n foo" || output != "api-1 0/1 Error\n\nmore" {
+		t.Errorf("ParseManualCommand() = %q, %q, %v", command, output, ok)
+	}
+	if _, _, ok := ParseManualCommand(GoalPrefix + "why?"); ok {
+		t.Error("ParseManualCommand() accepted a goal")
+	}
+}
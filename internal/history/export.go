@@ -0,0 +1,402 @@
+package history
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"k8x/internal/output"
+)
+
+// ExportFormat is a format session transcripts are exported to
+type ExportFormat string
+
+const (
+	// ExportMarkdown is Markdown for postmortems, tickets and wikis
+	ExportMarkdown ExportFormat = "md"
+	// ExportHTML is a standalone HTML page
+	ExportHTML ExportFormat = "html"
+	// ExportJSON is JSON for tooling
+	ExportJSON ExportFormat = "json"
+)
+
+// ExportFormats lists the supported export formats
+var ExportFormats = []ExportFormat{ExportMarkdown, ExportHTML, ExportJSON}
+
+var (
+	donePattern     = regexp.MustCompile(`(?i)\*\*DONE\*\*\.?`)
+	findingPattern  = regexp.MustCompile(`^\s*(?:[-*+]|\d+[.)])\s+(.*)$`)
+	backtickPattern = regexp.MustCompile("`+")
+)
+
+// ParseExportFormat parses an export format name, accepting "markdown" for md
+func ParseExportFormat(name string) (ExportFormat, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "md", "markdown":
+		return ExportMarkdown, nil
+	case "html", "htm":
+		return ExportHTML, nil
+	case "json":
+		return ExportJSON, nil
+	default:
+		return "", fmt.Errorf("unknown export format %q (use md, html or json)", name)
+	}
+}
+
+// ExportFormatForPath picks the export format from a file's extension,
+// Markdown if it isn't one of the formats
+func ExportFormatForPath(path string) ExportFormat {
+	format, err := ParseExportFormat(strings.TrimPrefix(filepath.Ext(path), "."))
+	if err != nil {
+		return ExportMarkdown
+	}
+	return format
+}
+
+// Transcript is a session prepared for sharing, with credentials redacted
+type Transcript struct {
+	Goals       []string          `json:"goals"`
+	Date        time.Time         `json:"date"`
+	Status      string            `json:"status,omitempty"`
+	Environment map[string]string `json:"environment,omitempty"`
+	Steps       []TranscriptStep  `json:"steps"`
+	Summary     string            `json:"summary,omitempty"`
+	Findings    []string          `json:"findings,omitempty"`
+}
+
+// TranscriptStep is a numbered step of a transcript: a tool call with its
+// output, or the reasoning between calls
+type TranscriptStep struct {
+	Number      int    `json:"number"`
+	Explanation string `json:"explanation,omitempty"`
+	Tool        string `json:"tool,omitempty"`
+	Context     string `json:"context,omitempty"`
+	Command     string `json:"command,omitempty"`
+	Output      string `json:"output,omitempty"`
+	Model       string `json:"model,omitempty"`
+}
+
+// NewTranscript prepares a recorded history entry for sharing. The last
+// planning step saying **DONE** becomes the summary.
+func NewTranscript(entry *Entry) *Transcript {
+	t := &Transcript{
+		Date:        entry.Timestamp,
+		Status:      entry.Status,
+		Environment: entry.Environment,
+	}
+	t.AddGoal(entry.Goal)
+
+	for i, step := range entry.Steps {
+		switch {
+		case step.Type == "command":
+			tool, arguments := strings.TrimPrefix(step.Description, "Executed: "), step.Command
+			t.AddStep(TranscriptStep{Tool: tool, Output: step.Output, Model: step.Model}, arguments)
+		case step.Type == "step" && step.Command == "":
+			if i == len(entry.Steps)-1 && donePattern.MatchString(step.Output) {
+				t.SetSummary(step.Output)
+			} else {
+				t.AddStep(TranscriptStep{Explanation: step.Output, Model: step.Model}, "")
+			}
+		default:
+			t.AddStep(TranscriptStep{Explanation: step.Description, Command: step.Command, Output: step.Output, Model: step.Model}, "")
+		}
+	}
+	return t
+}
+
+// AddGoal appends a goal, redacting credentials
+func (t *Transcript) AddGoal(goal string) {
+	t.Goals = append(t.Goals, output.RedactCommand(strings.TrimSpace(goal)))
+}
+
+// AddStep appends a step, numbering it and redacting credentials. The tool
+// call's JSON arguments, if any, fill in the step's command and context.
+func (t *Transcript) AddStep(step TranscriptStep, arguments string) {
+	if arguments != "" {
+		step.Command, step.Context = describeArguments(step.Tool, arguments)
+	}
+
+	step.Number = len(t.Steps) + 1
+	step.Explanation = output.FilterSecrets(strings.TrimSpace(step.Explanation))
+	step.Command = output.RedactCommand(step.Command)
+	step.Output = output.FilterSecrets(strings.TrimRight(step.Output, "\n"))
+	t.Steps = append(t.Steps, step)
+}
+
+// SetOutput sets the output of the step at index, redacting credentials
+func (t *Transcript) SetOutput(index int, text string) {
+	t.Steps[index].Output = output.FilterSecrets(strings.TrimRight(text, "\n"))
+}
+
+// SetSummary sets the final summary of the session. Its list items become
+// the findings, the remaining text the summary.
+func (t *Transcript) SetSummary(text string) {
+	var summary []string
+	t.Findings = nil
+	for _, line := range strings.Split(donePattern.ReplaceAllString(output.FilterSecrets(text), ""), "\n") {
+		if match := findingPattern.FindStringSubmatch(line); match != nil {
+			t.Findings = append(t.Findings, strings.TrimSpace(match[1]))
+		} else {
+			summary = append(summary, line)
+		}
+	}
+	t.Summary = strings.TrimSpace(strings.Join(summary, "\n"))
+}
+
+// describeArguments returns the command of a shell tool call and the
+// kube-context it ran in. Other tools' arguments are listed as key=value.
+func describeArguments(tool, arguments string) (string, string) {
+	var args map[string]interface{}
+	if err := json.Unmarshal([]byte(arguments), &args); err != nil {
+		return strings.TrimSpace(arguments), ""
+	}
+
+	var kubeContext string
+	if value, ok := args["context"].(string); ok {
+		kubeContext = value
+		delete(args, "context")
+	}
+	if command, ok := args["command"].(string); ok {
+		return command, kubeContext
+	}
+
+	keys := make([]string, 0, len(args))
+	for key := range args {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	parts := []string{tool}
+	for _, key := range keys {
+		parts = append(parts, fmt.Sprintf("%s=%v", key, args[key]))
+	}
+	return strings.Join(parts, " "), kubeContext
+}
+
+// Render renders the transcript in format
+func (t *Transcript) Render(format ExportFormat) ([]byte, error) {
+	switch format {
+	case ExportMarkdown:
+		return []byte(t.markdown()), nil
+	case ExportHTML:
+		return t.html()
+	case ExportJSON:
+		data, err := json.MarshalIndent(t, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode transcript: %w", err)
+		}
+		return append(data, '\n'), nil
+	default:
+		return nil, fmt.Errorf("unknown export format %q (use md, html or json)", format)
+	}
+}
+
+// title names the transcript after its first goal
+func (t *Transcript) title() string {
+	if len(t.Goals) == 0 {
+		return "k8x session"
+	}
+	return "k8x session: " + t.Goals[0]
+}
+
+// environmentKeys returns the environment's keys in a stable order
+func (t *Transcript) environmentKeys() []string {
+	keys := make([]string, 0, len(t.Environment))
+	for key := range t.Environment {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// markdown renders the transcript as Markdown, with step outputs in
+// collapsible <details> blocks
+func (t *Transcript) markdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", t.title())
+
+	if len(t.Goals) == 1 {
+		fmt.Fprintf(&b, "**Goal:** %s\n\n", t.Goals[0])
+	} else if len(t.Goals) > 1 {
+		b.WriteString("**Goals:**\n\n")
+		for i, goal := range t.Goals {
+			fmt.Fprintf(&b, "%d. %s\n", i+1, goal)
+		}
+		b.WriteString("\n")
+	}
+	if !t.Date.IsZero() {
+		fmt.Fprintf(&b, "**Date:** %s\n\n", t.Date.Format("2006-01-02 15:04 MST"))
+	}
+	if t.Status != "" {
+		fmt.Fprintf(&b, "**Status:** %s\n\n", t.Status)
+	}
+
+	if len(t.Environment) > 0 {
+		b.WriteString("## Environment\n\n")
+		for _, key := range t.environmentKeys() {
+			fmt.Fprintf(&b, "- **%s:** %s\n", key, t.Environment[key])
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("## Steps\n\n")
+	if len(t.Steps) == 0 {
+		b.WriteString("No steps were recorded.\n\n")
+	}
+	for _, step := range t.Steps {
+		heading := "Analysis"
+		if step.Tool != "" {
+			heading = "`" + step.Tool + "`"
+		}
+		if step.Context != "" {
+			heading += " in context " + step.Context
+		}
+		if step.Model != "" {
+			heading += " _(" + step.Model + ")_"
+		}
+		fmt.Fprintf(&b, "### %d. %s\n\n", step.Number, heading)
+		if step.Explanation != "" {
+			b.WriteString(step.Explanation + "\n\n")
+		}
+		if step.Command != "" {
+			b.WriteString(fencedBlock("sh", step.Command))
+		}
+		if step.Output != "" {
+			fmt.Fprintf(&b, "<details>\n<summary>Output (%s)</summary>\n\n", lineCount(step.Output))
+			b.WriteString(fencedBlock("text", step.Output))
+			b.WriteString("</details>\n\n")
+		}
+	}
+
+	if t.Summary != "" {
+		fmt.Fprintf(&b, "## Summary\n\n%s\n\n", t.Summary)
+	}
+	if len(t.Findings) > 0 {
+		b.WriteString("## Findings\n\n")
+		for _, finding := range t.Findings {
+			fmt.Fprintf(&b, "- %s\n", finding)
+		}
+		b.WriteString("\n")
+	}
+	return strings.TrimRight(b.String(), "\n") + "\n"
+}
+
+// fencedBlock wraps text in a code fence longer than any backtick run in it
+func fencedBlock(language, text string) string {
+	fence := "```"
+	for _, run := range backtickPattern.FindAllString(text, -1) {
+		if len(run) >= len(fence) {
+			fence = strings.Repeat("`", len(run)+1)
+		}
+	}
+	return fmt.Sprintf("%s%s\n%s\n%s\n\n", fence, language, text, fence)
+}
+
+// lineCount describes how many lines text has, e.g. "12 lines"
+func lineCount(text string) string {
+	if n := strings.Count(text, "\n") + 1; n != 1 {
+		return fmt.Sprintf("%d lines", n)
+	}
+	return "1 line"
+}
+
+var htmlTemplate = template.Must(template.New("transcript").Funcs(template.FuncMap{
+	"lines": lineCount,
+	"date":  func(t time.Time) string { return t.Format("2006-01-02 15:04 MST") },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; max-width: 960px; margin: 2em auto; padding: 0 1em; color: #1f2328; line-height: 1.5; }
+pre { background: #f6f8fa; padding: 0.75em; overflow-x: auto; border-radius: 6px; }
+code { font-family: ui-monospace, Menlo, Consolas, monospace; font-size: 0.9em; }
+.text { white-space: pre-wrap; }
+summary { cursor: pointer; color: #57606a; }
+dt { font-weight: bold; float: left; clear: left; margin-right: 0.5em; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+{{- with .T.Goals}}
+<h2>Goal</h2>
+{{- range .}}
+<p class="text">{{.}}</p>
+{{- end}}
+{{- end}}
+<dl>
+{{- if not .T.Date.IsZero}}
+<dt>Date:</dt><dd>{{date .T.Date}}</dd>
+{{- end}}
+{{- with .T.Status}}
+<dt>Status:</dt><dd>{{.}}</dd>
+{{- end}}
+</dl>
+{{- if .Environment}}
+<h2>Environment</h2>
+<dl>
+{{- range .Environment}}
+<dt>{{.Key}}:</dt><dd>{{.Value}}</dd>
+{{- end}}
+</dl>
+{{- end}}
+<h2>Steps</h2>
+{{- range .T.Steps}}
+<h3>{{.Number}}. {{if .Tool}}<code>{{.Tool}}</code>{{else}}Analysis{{end}}{{with .Context}} in context {{.}}{{end}}{{with .Model}} <small>({{.}})</small>{{end}}</h3>
+{{- with .Explanation}}
+<p class="text">{{.}}</p>
+{{- end}}
+{{- with .Command}}
+<pre><code>{{.}}</code></pre>
+{{- end}}
+{{- with .Output}}
+<details>
+<summary>Output ({{lines .}})</summary>
+<pre><code>{{.}}</code></pre>
+</details>
+{{- end}}
+{{- else}}
+<p>No steps were recorded.</p>
+{{- end}}
+{{- with .T.Summary}}
+<h2>Summary</h2>
+<p class="text">{{.}}</p>
+{{- end}}
+{{- with .T.Findings}}
+<h2>Findings</h2>
+<ul>
+{{- range .}}
+<li>{{.}}</li>
+{{- end}}
+</ul>
+{{- end}}
+</body>
+</html>
+`))
+
+// html renders the transcript as a standalone HTML page
+func (t *Transcript) html() ([]byte, error) {
+	type item struct{ Key, Value string }
+	var environment []item
+	for _, key := range t.environmentKeys() {
+		environment = append(environment, item{key, t.Environment[key]})
+	}
+
+	var buf bytes.Buffer
+	data := struct {
+		Title       string
+		T           *Transcript
+		Environment []item
+	}{t.title(), t, environment}
+	if err := htmlTemplate.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("failed to render transcript: %w", err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,161 @@
+package history
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSaveAndLoadMetadata(t *testing.T) {
+	manager := &Manager{historyDir: t.TempDir()}
+	entry := &Entry{
+		Goal:        "why is api failing?",
+		Timestamp:   time.Date(2025, 3, 1, 14, 30, 0, 0, time.Local),
+		Status:      "completed",
+		Environment: map[string]string{"context": "prod", "namespace": "shop"},
+		Steps: []Step{
+			{Description: "Executed: execute_shell_command", Command: `{"command":"kubectl get pods"}`, Output: "api-1 CrashLoopBackOff", Type: "command", Model: "openai/o3-mini"},
+		},
+	}
+	if err := manager.Save(entry); err != nil {
+		t.Fatalf("Save() failed: %v", err)
+	}
+
+	loaded, err := manager.Load("20250301-143000.k8x")
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if loaded.Status != "completed" || !reflect.DeepEqual(loaded.Environment, entry.Environment) {
+		t.Errorf("Load() status = %q, environment = %v, want %q, %v", loaded.Status, loaded.Environment, entry.Status, entry.Environment)
+	}
+	if !loaded.Timestamp.Equal(entry.Timestamp) {
+		t.Errorf("Load() timestamp = %v, want %v", loaded.Timestamp, entry.Timestamp)
+	}
+	if len(loaded.Steps) != 1 || loaded.Steps[0].Command != entry.Steps[0].Command || loaded.Steps[0].Model != entry.Steps[0].Model {
+		t.Errorf("Load() steps = %+v, want %+v", loaded.Steps, entry.Steps)
+	}
+}
+
+func TestParseExportFormat(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    ExportFormat
+		wantErr bool
+	}{
+		{"md", ExportMarkdown, false},
+		{"Markdown", ExportMarkdown, false},
+		{"html", ExportHTML, false},
+		{"json", ExportJSON, false},
+		{"pdf", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseExportFormat(tt.name)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("ParseExportFormat(%q) = %q, %v, want %q", tt.name, got, err, tt.want)
+			}
+		})
+	}
+
+	if got := ExportFormatForPath("notes/incident.HTML"); got != ExportHTML {
+		t.Errorf("ExportFormatForPath() = %q, want html", got)
+	}
+	if got := ExportFormatForPath("incident.txt"); got != ExportMarkdown {
+		t.Errorf("ExportFormatForPath() = %q, want md", got)
+	}
+}
+
+func testEntry() *Entry {
+	return &Entry{
+		Goal:        "why is api failing?",
+		Timestamp:   time.Date(2025, 3, 1, 14, 30, 0, 0, time.UTC),
+		Status:      "completed",
+		Environment: map[string]string{"context": "prod", "namespace": "shop"},
+		Steps: []Step{
+			{Description: "Planning Step 1", Output: "I'll list the pods first.", Type: "step"},
+			{Description: "Executed: execute_shell_command", Command: `{"command":"kubectl get pods","context":"prod"}`, Output: "NAME READY\napi-1 0/1", Type: "command"},
+			{Description: "Executed: execute_shell_command", Command: `{"command":"kubectl create secret generic db --from-literal=password=hunter2"}`, Output: "secret/db created", Type: "command"},
+			{Description: "Executed: get_logs", Command: `{"name":"api-1","tail":50}`, Output: "panic: ```boom```", Type: "command"},
+			{Description: "Planning Step 5", Output: "The api pod crashes on startup.\n- api-1 panics on start\n- the database secret exists\n**DONE**", Type: "step"},
+		},
+	}
+}
+
+func TestNewTranscript(t *testing.T) {
+	transcript := NewTranscript(testEntry())
+
+	if len(transcript.Steps) != 4 {
+		t.Fatalf("got %d steps, want 4: %+v", len(transcript.Steps), transcript.Steps)
+	}
+	if step := transcript.Steps[1]; step.Number != 2 || step.Command != "kubectl get pods" || step.Context != "prod" {
+		t.Errorf("shell step = %+v, want its command and context", step)
+	}
+	if command := transcript.Steps[2].Command; strings.Contains(command, "hunter2") {
+		t.Errorf("credential not redacted from %q", command)
+	}
+	if command := transcript.Steps[3].Command; command != "get_logs name=api-1 tail=50" {
+		t.Errorf("typed tool command = %q", command)
+	}
+	if want := []string{"why is api failing?"}; !reflect.DeepEqual(transcript.Goals, want) {
+		t.Errorf("goals = %q, want %q", transcript.Goals, want)
+	}
+
+	entry := testEntry()
+	entry.Goal = "why does mysql -u root --password=hunter2 fail?"
+	if goals := NewTranscript(entry).Goals; len(goals) != 1 || strings.Contains(goals[0], "hunter2") {
+		t.Errorf("credential not redacted from goals %q", goals)
+	}
+	if transcript.Summary != "The api pod crashes on startup." {
+		t.Errorf("summary = %q", transcript.Summary)
+	}
+	if want := []string{"api-1 panics on start", "the database secret exists"}; !reflect.DeepEqual(transcript.Findings, want) {
+		t.Errorf("findings = %q, want %q", transcript.Findings, want)
+	}
+}
+
+func TestTranscriptRender(t *testing.T) {
+	transcript := NewTranscript(testEntry())
+
+	markdown, err := transcript.Render(ExportMarkdown)
+	if err != nil {
+		t.Fatalf("Render(md) failed: %v", err)
+	}
+	for _, want := range []string{
+		"# k8x session: why is api failing?",
+		"- **context:** prod",
+		"### 2. `execute_shell_command` in context prod",
+		"```sh\nkubectl get pods\n```",
+		"<summary>Output (2 lines)</summary>",
+		"````text\npanic: ```boom```\n````",
+		"## Findings\n\n- api-1 panics on start",
+	} {
+		if !strings.Contains(string(markdown), want) {
+			t.Errorf("Markdown is missing %q:\n%s", want, markdown)
+		}
+	}
+
+	html, err := transcript.Render(ExportHTML)
+	if err != nil {
+		t.Fatalf("Render(html) failed: %v", err)
+	}
+	for _, want := range []string{"<details>", "<dt>namespace:</dt><dd>shop</dd>", "<li>api-1 panics on start</li>"} {
+		if !strings.Contains(string(html), want) {
+			t.Errorf("HTML is missing %q", want)
+		}
+	}
+
+	data, err := transcript.Render(ExportJSON)
+	if err != nil {
+		t.Fatalf("Render(json) failed: %v", err)
+	}
+	var decoded Transcript
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Render(json) isn't valid JSON: %v", err)
+	}
+	if !reflect.DeepEqual(decoded.Findings, transcript.Findings) || len(decoded.Steps) != len(transcript.Steps) {
+		t.Errorf("decoded JSON = %+v, want %+v", decoded, transcript)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
@@ -18,6 +19,8 @@ type Entry struct {
 	Steps     []Step    `json:"steps"`
 	Timestamp time.Time `json:"timestamp"`
 	Status    string    `json:"status"` // "success", "error", "pending"
+	// Environment describes where the session ran, e.g. its kube-context
+	Environment map[string]string `json:"environment,omitempty"`
 }
 
 // Step represents a single step in a k8x session
@@ -27,8 +30,17 @@ type Step struct {
 	Output      string `json:"output,omitempty"`
 	UndoCommand string `json:"undo_command,omitempty"`
 	Type        string `json:"type"` // "step", "exploratory", "question"
+	// Model is the LLM that produced the step, e.g. "openai/o3-mini"
+	Model string `json:"model,omitempty"`
 }
 
+const (
+	// historyTimeFormat names history files after the session's start
+	historyTimeFormat = "20060102-150405"
+	// maxHistoryLine is the longest line read from a history file
+	maxHistoryLine = 10 * 1024 * 1024
+)
+
 // Manager handles command history operations
 type Manager struct {
 	historyDir string
@@ -60,14 +72,28 @@ func (m *Manager) Save(entry *Entry) error {
 		entry.Timestamp = time.Now()
 	}
 
-	filename := fmt.Sprintf("%s.k8x", entry.Timestamp.Format("20060102-150405"))
+	filename := fmt.Sprintf("%s.k8x", entry.Timestamp.Format(historyTimeFormat))
 	filepath := filepath.Join(m.historyDir, filename)
 
 	var content strings.Builder
 
 	// Write shebang and goal
 	content.WriteString("#!/bin/k8x\n")
-	content.WriteString(fmt.Sprintf("#$ %s\n\n", entry.Goal))
+	content.WriteString(fmt.Sprintf("#$ %s\n", entry.Goal))
+
+	// Write metadata, which older versions skip as comments
+	if entry.Status != "" {
+		content.WriteString(fmt.Sprintf("#@ status: %s\n", entry.Status))
+	}
+	keys := make([]string, 0, len(entry.Environment))
+	for key := range entry.Environment {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		content.WriteString(fmt.Sprintf("#@ %s: %s\n", key, entry.Environment[key]))
+	}
+	content.WriteString("\n")
 
 	// Write steps
 	for i, step := range entry.Steps {
@@ -80,6 +106,10 @@ func (m *Manager) Save(entry *Entry) error {
 			content.WriteString(fmt.Sprintf("# %d. %s\n", i+1, step.Description))
 		}
 
+		if step.Model != "" {
+			content.WriteString(fmt.Sprintf("#@ model: %s\n", step.Model))
+		}
+
 		if step.Command != "" {
 			content.WriteString(step.Command + "\n")
 		}
@@ -109,9 +139,12 @@ func (m *Manager) Save(entry *Entry) error {
 
 // Load loads a history entry by filename from .k8x format
 func (m *Manager) Load(filename string) (*Entry, error) {
-	filepath := filepath.Join(m.historyDir, filename)
+	return LoadFile(filepath.Join(m.historyDir, filename))
+}
 
-	file, err := os.Open(filepath)
+// LoadFile loads a history entry from a .k8x file at path
+func LoadFile(path string) (*Entry, error) {
+	file, err := os.Open(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open history file: %w", err)
 	}
@@ -126,8 +159,12 @@ func (m *Manager) Load(filename string) (*Entry, error) {
 		ID:    generateID(),
 		Steps: []Step{},
 	}
+	if timestamp, err := time.ParseInLocation(historyTimeFormat, strings.TrimSuffix(filepath.Base(path), ".k8x"), time.Local); err == nil {
+		entry.Timestamp = timestamp
+	}
 
 	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxHistoryLine)
 	var currentStep *Step
 
 	for scanner.Scan() {
@@ -135,6 +172,21 @@ func (m *Manager) Load(filename string) (*Entry, error) {
 
 		if strings.HasPrefix(line, "#$ ") {
 			entry.Goal = strings.TrimPrefix(line, "#$ ")
+		} else if strings.HasPrefix(line, "#@ ") {
+			// Metadata of the entry, or of the step it follows
+			key, value, _ := strings.Cut(strings.TrimPrefix(line, "#@ "), ": ")
+			if currentStep != nil {
+				if key == "model" {
+					currentStep.Model = value
+				}
+			} else if key == "status" {
+				entry.Status = value
+			} else {
+				if entry.Environment == nil {
+					entry.Environment = make(map[string]string)
+				}
+				entry.Environment[key] = value
+			}
 		} else if strings.HasPrefix(line, "# ") {
 			// New step
 			if currentStep != nil {
@@ -0,0 +1,110 @@
+// Package kube reads cluster state through the Kubernetes API for k8x's
+// read-only Kubernetes tools
+package kube
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"k8x/internal/config"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/restmapper"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// DefaultRequestTimeout bounds a single request to the API server
+const DefaultRequestTimeout = 15 * time.Second
+
+// Client reads resources of any kind from a cluster. It only offers
+// read operations.
+type Client struct {
+	typed   kubernetes.Interface
+	dynamic dynamic.Interface
+	mapper  meta.RESTMapper
+	// namespace is the session's namespace, used when a call doesn't set one
+	namespace string
+}
+
+// NewClient creates a client for the kubeconfig, kube-context and namespace
+// in kubeConfig, falling back to the default loading rules for anything not
+// set. kubeContext overrides kubeConfig.Context if not empty.
+func NewClient(kubeConfig *config.KubernetesConfig, kubeContext string) (*Client, error) {
+	selected := config.KubernetesConfig{}
+	if kubeConfig != nil {
+		selected = *kubeConfig
+	}
+	if kubeContext != "" {
+		selected.Context = kubeContext
+	}
+
+	clientConfig := ClientConfig(&selected)
+	restConfig, err := clientConfig.ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("no usable kubeconfig: %w", err)
+	}
+	restConfig.Timeout = DefaultRequestTimeout
+
+	typed, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	dynamicClient, err := dynamic.NewForConfig(restConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+
+	// Resolve kinds, plurals and short names like "deploy" through discovery
+	discovery := memory.NewMemCacheClient(typed.Discovery())
+	mapper := restmapper.NewShortcutExpander(restmapper.NewDeferredDiscoveryRESTMapper(discovery), discovery, nil)
+
+	namespace, _, err := clientConfig.Namespace()
+	if err != nil || namespace == "" {
+		namespace = "default"
+	}
+
+	return newClient(typed, dynamicClient, mapper, namespace), nil
+}
+
+// newClient creates a client from existing clients
+func newClient(typed kubernetes.Interface, dynamicClient dynamic.Interface, mapper meta.RESTMapper, namespace string) *Client {
+	return &Client{
+		typed:     typed,
+		dynamic:   dynamicClient,
+		mapper:    mapper,
+		namespace: namespace,
+	}
+}
+
+// ClientConfig applies kubeConfig on top of the default kubeconfig loading
+// rules (KUBECONFIG, ~/.kube/config)
+func ClientConfig(kubeConfig *config.KubernetesConfig) clientcmd.ClientConfig {
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	overrides := &clientcmd.ConfigOverrides{}
+	if kubeConfig != nil {
+		if kubeConfig.KubeConfigPath != "" {
+			loadingRules.ExplicitPath = ExpandHome(kubeConfig.KubeConfigPath)
+		}
+		overrides.CurrentContext = kubeConfig.Context
+		overrides.Context.Namespace = kubeConfig.Namespace
+	}
+	return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, overrides)
+}
+
+// ExpandHome replaces a leading ~ with the user's home directory
+func ExpandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(homeDir, path[1:])
+}
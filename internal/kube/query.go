@@ -0,0 +1,587 @@
+package kube
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// MaxOutputBytes caps the output of a single query
+	MaxOutputBytes = 16 * 1024
+	// maxListItems limits how many resources a list returns
+	maxListItems = 200
+	// maxEvents limits how many events are returned, most recent first
+	maxEvents = 100
+	// DefaultLogTail is how many log lines are returned by default
+	DefaultLogTail = 200
+	// maxLogTail limits how many log lines can be requested
+	maxLogTail = 2000
+)
+
+// metricsGroupVersion is the API of metrics-server
+var metricsGroupVersion = schema.GroupVersion{Group: "metrics.k8s.io", Version: "v1beta1"}
+
+// ResourceQuery selects the resources listed by GetResources
+type ResourceQuery struct {
+	// Kind is a kind, resource or short name, e.g. Deployment, pods or
+	// svc, optionally qualified by its API group, e.g. certificates.cert-manager.io
+	Kind string
+	// Namespace defaults to the session's namespace
+	Namespace string
+	// AllNamespaces lists namespaced resources in all namespaces
+	AllNamespaces bool
+	// Selector is a label selector, e.g. app=api
+	Selector string
+	// FieldSelector is a field selector, e.g. status.phase=Running
+	FieldSelector string
+	// Fields are dotted paths shown as extra columns, e.g. spec.nodeName
+	Fields []string
+}
+
+// LogQuery selects the log lines returned by Logs
+type LogQuery struct {
+	Pod       string
+	Namespace string
+	// Container is required for pods with more than one container
+	Container string
+	// Since only returns lines newer than this duration
+	Since time.Duration
+	// Previous returns the logs of the previous, terminated container
+	Previous bool
+	// Tail is the number of lines from the end (default DefaultLogTail)
+	Tail int64
+}
+
+// EventQuery selects the events returned by Events
+type EventQuery struct {
+	Namespace     string
+	AllNamespaces bool
+	// Kind and Name select the involved object
+	Kind string
+	Name string
+	// WarningsOnly leaves out Normal events
+	WarningsOnly bool
+}
+
+// GetResources lists resources with their status, age and requested fields
+func (c *Client) GetResources(ctx context.Context, query ResourceQuery) (string, error) {
+	gvr, namespaced, err := c.resolve(query.Kind)
+	if err != nil {
+		return "", err
+	}
+
+	namespace := ""
+	if namespaced && !query.AllNamespaces {
+		namespace = c.namespaceOrDefault(query.Namespace)
+	}
+
+	list, err := c.dynamic.Resource(gvr).Namespace(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: query.Selector,
+		FieldSelector: query.FieldSelector,
+		Limit:         maxListItems,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to list %s: %w", gvr.Resource, err)
+	}
+	if len(list.Items) == 0 {
+		if namespace != "" {
+			return fmt.Sprintf("No %s found in namespace %s", gvr.Resource, namespace), nil
+		}
+		return fmt.Sprintf("No %s found", gvr.Resource), nil
+	}
+
+	showNamespace := namespaced && namespace == ""
+	var out strings.Builder
+	w := tabwriter.NewWriter(&out, 0, 0, 2, ' ', 0)
+
+	header := []string{"NAME", "STATUS", "AGE"}
+	if showNamespace {
+		header = append([]string{"NAMESPACE"}, header...)
+	}
+	for _, field := range query.Fields {
+		header = append(header, strings.ToUpper(strings.TrimPrefix(field, ".")))
+	}
+	fmt.Fprintln(w, strings.Join(header, "\t"))
+
+	for i := range list.Items {
+		item := &list.Items[i]
+		if isSecretResource(gvr) {
+			redactSecretValues(item.Object)
+		}
+		row := []string{item.GetName(), orNone(resourceStatus(item)), age(item.GetCreationTimestamp().Time)}
+		if showNamespace {
+			row = append([]string{item.GetNamespace()}, row...)
+		}
+		for _, field := range query.Fields {
+			row = append(row, orNone(fieldValue(item.Object, field)))
+		}
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	_ = w.Flush()
+
+	if list.GetContinue() != "" {
+		fmt.Fprintf(&out, "... more than %d %s, narrow the query with a namespace or selector\n", maxListItems, gvr.Resource)
+	}
+	return truncate(out.String()), nil
+}
+
+// DescribeResource returns a resource as YAML, without managed fields, and
+// the events involving it. The values of Secrets are replaced by their sizes.
+func (c *Client) DescribeResource(ctx context.Context, kind, name, namespace string) (string, error) {
+	gvr, namespaced, err := c.resolve(kind)
+	if err != nil {
+		return "", err
+	}
+	if namespaced {
+		namespace = c.namespaceOrDefault(namespace)
+	} else {
+		namespace = ""
+	}
+
+	obj, err := c.dynamic.Resource(gvr).Namespace(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to get %s %s: %w", gvr.Resource, name, err)
+	}
+
+	obj.SetManagedFields(nil)
+	annotations := obj.GetAnnotations()
+	delete(annotations, corev1.LastAppliedConfigAnnotation)
+	obj.SetAnnotations(annotations)
+	if isSecretResource(gvr) {
+		redactSecretValues(obj.Object)
+	}
+
+	data, err := yaml.Marshal(obj.Object)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode %s %s: %w", gvr.Resource, name, err)
+	}
+
+	events, err := c.Events(ctx, EventQuery{Namespace: namespace, Kind: obj.GetKind(), Name: name})
+	if err != nil {
+		events = fmt.Sprintf("(unavailable: %v)", err)
+	}
+	return truncate(fmt.Sprintf("%s\nEvents:\n%s", data, events)), nil
+}
+
+// Logs returns the last lines of a container's logs. Output over the size
+// cap keeps the most recent lines.
+func (c *Client) Logs(ctx context.Context, query LogQuery) (string, error) {
+	tail := query.Tail
+	if tail <= 0 {
+		tail = DefaultLogTail
+	}
+	if tail > maxLogTail {
+		tail = maxLogTail
+	}
+
+	options := &corev1.PodLogOptions{
+		Container: query.Container,
+		Previous:  query.Previous,
+		TailLines: &tail,
+	}
+	if query.Since > 0 {
+		seconds := int64(query.Since.Seconds())
+		options.SinceSeconds = &seconds
+	}
+
+	namespace := c.namespaceOrDefault(query.Namespace)
+	data, err := c.typed.CoreV1().Pods(namespace).GetLogs(query.Pod, options).DoRaw(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get logs of pod %s: %w", query.Pod, err)
+	}
+	if len(data) == 0 {
+		return "(no log lines)", nil
+	}
+	if len(data) > MaxOutputBytes {
+		omitted := len(data) - MaxOutputBytes
+		return fmt.Sprintf("... %d earlier bytes truncated\n%s", omitted, strings.ToValidUTF8(string(data[omitted:]), "")), nil
+	}
+	return string(data), nil
+}
+
+// Events returns events, most recent first, optionally only those involving
+// one object
+func (c *Client) Events(ctx context.Context, query EventQuery) (string, error) {
+	namespace := ""
+	if !query.AllNamespaces {
+		namespace = c.namespaceOrDefault(query.Namespace)
+	}
+
+	selector := fields.Set{}
+	if query.Kind != "" {
+		selector["involvedObject.kind"] = query.Kind
+	}
+	if query.Name != "" {
+		selector["involvedObject.name"] = query.Name
+	}
+	if query.WarningsOnly {
+		selector["type"] = corev1.EventTypeWarning
+	}
+
+	list, err := c.typed.CoreV1().Events(namespace).List(ctx, metav1.ListOptions{
+		FieldSelector: selector.AsSelector().String(),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to list events: %w", err)
+	}
+
+	events := list.Items[:0]
+	for _, event := range list.Items {
+		// Field selectors aren't guaranteed to be applied, e.g. by aggregated APIs
+		if (query.Kind != "" && !strings.EqualFold(event.InvolvedObject.Kind, query.Kind)) ||
+			(query.Name != "" && event.InvolvedObject.Name != query.Name) ||
+			(query.WarningsOnly && event.Type != corev1.EventTypeWarning) {
+			continue
+		}
+		events = append(events, event)
+	}
+	if len(events) == 0 {
+		return "No events found", nil
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return eventTime(events[i]).After(eventTime(events[j]))
+	})
+
+	var out strings.Builder
+	w := tabwriter.NewWriter(&out, 0, 0, 2, ' ', 0)
+	header := "LAST SEEN\tTYPE\tREASON\tOBJECT\tMESSAGE"
+	if namespace == "" {
+		header = "NAMESPACE\t" + header
+	}
+	fmt.Fprintln(w, header)
+	for i, event := range events {
+		if i == maxEvents {
+			break
+		}
+		object := strings.ToLower(event.InvolvedObject.Kind) + "/" + event.InvolvedObject.Name
+		message := strings.Join(strings.Fields(event.Message), " ")
+		if event.Count > 1 {
+			message += fmt.Sprintf(" (x%d)", event.Count)
+		}
+		row := fmt.Sprintf("%s\t%s\t%s\t%s\t%s", age(eventTime(event)), event.Type, event.Reason, object, message)
+		if namespace == "" {
+			row = event.Namespace + "\t" + row
+		}
+		fmt.Fprintln(w, row)
+	}
+	_ = w.Flush()
+
+	if len(events) > maxEvents {
+		fmt.Fprintf(&out, "... and %d older events\n", len(events)-maxEvents)
+	}
+	return truncate(out.String()), nil
+}
+
+// Top returns the CPU and memory usage of pods or nodes from metrics-server,
+// highest CPU first
+func (c *Client) Top(ctx context.Context, kind, namespace string, allNamespaces bool) (string, error) {
+	var resource string
+	switch strings.ToLower(kind) {
+	case "pod", "pods", "po", "":
+		resource = "pods"
+		if !allNamespaces {
+			namespace = c.namespaceOrDefault(namespace)
+		} else {
+			namespace = ""
+		}
+	case "node", "nodes", "no":
+		resource = "nodes"
+		namespace = ""
+	default:
+		return "", fmt.Errorf("top supports pods and nodes, not %q", kind)
+	}
+
+	list, err := c.dynamic.Resource(metricsGroupVersion.WithResource(resource)).Namespace(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return "", fmt.Errorf("metrics API not available (is metrics-server installed?): %w", err)
+	}
+
+	type usage struct {
+		namespace, name string
+		cpu, memory     int64
+	}
+	var usages []usage
+	for _, item := range list.Items {
+		u := usage{namespace: item.GetNamespace(), name: item.GetName()}
+		if resource == "nodes" {
+			u.cpu, u.memory = usageOf(item.Object, "usage")
+		} else {
+			containers, _, _ := unstructured.NestedSlice(item.Object, "containers")
+			for _, container := range containers {
+				if m, ok := container.(map[string]interface{}); ok {
+					cpu, memory := usageOf(m, "usage")
+					u.cpu += cpu
+					u.memory += memory
+				}
+			}
+		}
+		usages = append(usages, u)
+	}
+	if len(usages) == 0 {
+		return fmt.Sprintf("No %s metrics found", resource), nil
+	}
+
+	sort.SliceStable(usages, func(i, j int) bool { return usages[i].cpu > usages[j].cpu })
+
+	var out strings.Builder
+	w := tabwriter.NewWriter(&out, 0, 0, 2, ' ', 0)
+	showNamespace := resource == "pods" && namespace == ""
+	if showNamespace {
+		fmt.Fprintln(w, "NAMESPACE\tNAME\tCPU\tMEMORY")
+	} else {
+		fmt.Fprintln(w, "NAME\tCPU\tMEMORY")
+	}
+	for i, u := range usages {
+		if i == maxListItems {
+			fmt.Fprintf(w, "... and %d more\n", len(usages)-maxListItems)
+			break
+		}
+		row := fmt.Sprintf("%s\t%dm\t%dMi", u.name, u.cpu, u.memory/(1024*1024))
+		if showNamespace {
+			row = u.namespace + "\t" + row
+		}
+		fmt.Fprintln(w, row)
+	}
+	_ = w.Flush()
+	return truncate(out.String()), nil
+}
+
+// resolve maps a kind, resource or short name to its resource and whether
+// it is namespaced
+func (c *Client) resolve(kind string) (schema.GroupVersionResource, bool, error) {
+	if kind == "" {
+		return schema.GroupVersionResource{}, false, fmt.Errorf("kind is required")
+	}
+
+	partial := schema.ParseGroupResource(strings.ToLower(kind)).WithVersion("")
+	gvr, err := c.mapper.ResourceFor(partial)
+	if err != nil {
+		return schema.GroupVersionResource{}, false, fmt.Errorf("unknown resource kind %q: %w", kind, err)
+	}
+	gvk, err := c.mapper.KindFor(gvr)
+	if err != nil {
+		return schema.GroupVersionResource{}, false, fmt.Errorf("unknown resource kind %q: %w", kind, err)
+	}
+	mapping, err := c.mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return schema.GroupVersionResource{}, false, fmt.Errorf("unknown resource kind %q: %w", kind, err)
+	}
+	return gvr, mapping.Scope.Name() == meta.RESTScopeNameNamespace, nil
+}
+
+// namespaceOrDefault returns namespace, or the session's namespace if empty
+func (c *Client) namespaceOrDefault(namespace string) string {
+	if namespace != "" {
+		return namespace
+	}
+	return c.namespace
+}
+
+// resourceStatus summarizes the status of common kinds
+func resourceStatus(obj *unstructured.Unstructured) string {
+	switch obj.GetKind() {
+	case "Pod":
+		phase, _, _ := unstructured.NestedString(obj.Object, "status", "phase")
+		statuses, _, _ := unstructured.NestedSlice(obj.Object, "status", "containerStatuses")
+		ready, restarts := 0, int64(0)
+		for _, status := range statuses {
+			m, ok := status.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if isReady, _, _ := unstructured.NestedBool(m, "ready"); isReady {
+				ready++
+			}
+			count, _, _ := unstructured.NestedInt64(m, "restartCount")
+			restarts += count
+			if reason, _, _ := unstructured.NestedString(m, "state", "waiting", "reason"); reason != "" {
+				phase = reason
+			}
+		}
+		return fmt.Sprintf("%s, ready %d/%d, restarts %d", phase, ready, len(statuses), restarts)
+	case "Deployment", "StatefulSet", "ReplicaSet":
+		desired, _, _ := unstructured.NestedInt64(obj.Object, "spec", "replicas")
+		ready, _, _ := unstructured.NestedInt64(obj.Object, "status", "readyReplicas")
+		return fmt.Sprintf("ready %d/%d", ready, desired)
+	case "DaemonSet":
+		desired, _, _ := unstructured.NestedInt64(obj.Object, "status", "desiredNumberScheduled")
+		ready, _, _ := unstructured.NestedInt64(obj.Object, "status", "numberReady")
+		return fmt.Sprintf("ready %d/%d", ready, desired)
+	}
+
+	if phase, _, _ := unstructured.NestedString(obj.Object, "status", "phase"); phase != "" {
+		return phase
+	}
+	conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	for _, condition := range conditions {
+		m, ok := condition.(map[string]interface{})
+		if !ok || m["type"] != "Ready" {
+			continue
+		}
+		if m["status"] == "True" {
+			return "Ready"
+		}
+		return "NotReady"
+	}
+	return ""
+}
+
+// fieldValue returns the value at a dotted path such as spec.nodeName.
+// Lists along the path are expanded, so spec.containers.image returns the
+// image of every container.
+func fieldValue(obj map[string]interface{}, path string) string {
+	values := []interface{}{obj}
+	for _, key := range strings.Split(strings.TrimPrefix(path, "."), ".") {
+		var next []interface{}
+		for _, value := range values {
+			next = append(next, lookup(value, key)...)
+		}
+		values = next
+	}
+
+	parts := make([]string, 0, len(values))
+	for _, value := range values {
+		switch v := value.(type) {
+		case string:
+			parts = append(parts, v)
+		case map[string]interface{}, []interface{}:
+			data, _ := json.Marshal(v)
+			parts = append(parts, string(data))
+		default:
+			parts = append(parts, fmt.Sprint(v))
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// lookup returns the values of key in a map, or in each map of a list
+func lookup(value interface{}, key string) []interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if found, ok := v[key]; ok {
+			return []interface{}{found}
+		}
+	case []interface{}:
+		var found []interface{}
+		for _, item := range v {
+			found = append(found, lookup(item, key)...)
+		}
+		return found
+	}
+	return nil
+}
+
+// usageOf returns CPU in millicores and memory in bytes of a metrics usage map
+func usageOf(obj map[string]interface{}, field string) (int64, int64) {
+	usage, _, _ := unstructured.NestedStringMap(obj, field)
+	var cpu, memory int64
+	if q, err := resource.ParseQuantity(usage["cpu"]); err == nil {
+		cpu = q.MilliValue()
+	}
+	if q, err := resource.ParseQuantity(usage["memory"]); err == nil {
+		memory = q.Value()
+	}
+	return cpu, memory
+}
+
+// eventTime returns when an event was last seen
+func eventTime(event corev1.Event) time.Time {
+	switch {
+	case !event.LastTimestamp.IsZero():
+		return event.LastTimestamp.Time
+	case !event.EventTime.IsZero():
+		return event.EventTime.Time
+	default:
+		return event.FirstTimestamp.Time
+	}
+}
+
+// age formats the time since t like kubectl does, e.g. 5m or 3d
+func age(t time.Time) string {
+	if t.IsZero() {
+		return "<unknown>"
+	}
+	return duration.HumanDuration(time.Since(t))
+}
+
+// orNone returns "<none>" for empty values, as kubectl does
+func orNone(value string) string {
+	if value == "" {
+		return "<none>"
+	}
+	return value
+}
+
+// RedactSecretData replaces the values of a Secret's data and stringData
+// with their sizes, as kubectl describe does, e.g. "password: 7 bytes", and
+// removes the last applied configuration, which holds them too. Other
+// objects are left alone.
+func RedactSecretData(obj map[string]interface{}) {
+	if kind, _ := obj["kind"].(string); kind == "Secret" {
+		redactSecretValues(obj)
+	}
+}
+
+// isSecretResource returns true for core Secrets
+func isSecretResource(gvr schema.GroupVersionResource) bool {
+	return gvr.Group == "" && gvr.Resource == "secrets"
+}
+
+// redactSecretValues replaces the values of a Secret object with their
+// sizes and removes its last applied configuration
+func redactSecretValues(obj map[string]interface{}) {
+	if metadata, ok := obj["metadata"].(map[string]interface{}); ok {
+		if annotations, ok := metadata["annotations"].(map[string]interface{}); ok {
+			delete(annotations, corev1.LastAppliedConfigAnnotation)
+		}
+	}
+	for _, field := range []string{"data", "stringData", "binaryData"} {
+		values, ok := obj[field].(map[string]interface{})
+		if !ok {
+			if _, exists := obj[field]; exists {
+				obj[field] = "(redacted)"
+			}
+			continue
+		}
+		for key, value := range values {
+			text, ok := value.(string)
+			if !ok {
+				values[key] = "(redacted)"
+				continue
+			}
+			size := len(text)
+			if field != "stringData" {
+				if decoded, err := base64.StdEncoding.DecodeString(text); err == nil {
+					size = len(decoded)
+				}
+			}
+			values[key] = fmt.Sprintf("%d bytes", size)
+		}
+	}
+}
+
+// truncate caps output at MaxOutputBytes, noting how much was left out
+func truncate(output string) string {
+	if len(output) <= MaxOutputBytes {
+		return output
+	}
+	return strings.ToValidUTF8(output[:MaxOutputBytes], "") +
+		fmt.Sprintf("\n... output truncated (%d more bytes), narrow the query", len(output)-MaxOutputBytes)
+}
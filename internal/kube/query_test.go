@@ -0,0 +1,279 @@
+package kube
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// newTestClient creates a client backed by fake clientsets holding objects
+func newTestClient(t *testing.T, typedObjects []runtime.Object, objects ...*unstructured.Unstructured) *Client {
+	t.Helper()
+
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Pod"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Node"}, meta.RESTScopeRoot)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Secret"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}, meta.RESTScopeNamespace)
+
+	listKinds := map[schema.GroupVersionResource]string{
+		{Version: "v1", Resource: "pods"}:                                "PodList",
+		{Version: "v1", Resource: "nodes"}:                               "NodeList",
+		{Version: "v1", Resource: "secrets"}:                             "SecretList",
+		{Group: "apps", Version: "v1", Resource: "deployments"}:          "DeploymentList",
+		{Group: "metrics.k8s.io", Version: "v1beta1", Resource: "pods"}:  "PodMetricsList",
+		{Group: "metrics.k8s.io", Version: "v1beta1", Resource: "nodes"}: "NodeMetricsList",
+	}
+	dynamicObjects := make([]runtime.Object, 0, len(objects))
+	for _, obj := range objects {
+		dynamicObjects = append(dynamicObjects, obj)
+	}
+	dynamicClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), listKinds, dynamicObjects...)
+
+	return newClient(fake.NewSimpleClientset(typedObjects...), dynamicClient, mapper, "default")
+}
+
+// object creates an unstructured object
+func object(apiVersion, kind, namespace, name string, fields map[string]interface{}) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	for key, value := range fields {
+		obj.Object[key] = value
+	}
+	obj.SetAPIVersion(apiVersion)
+	obj.SetKind(kind)
+	obj.SetNamespace(namespace)
+	obj.SetName(name)
+	obj.SetCreationTimestamp(metav1.NewTime(time.Now().Add(-5 * 24 * time.Hour)))
+	return obj
+}
+
+func TestGetResources(t *testing.T) {
+	client := newTestClient(t, nil,
+		object("v1", "Pod", "shop", "api-0", map[string]interface{}{
+			"spec": map[string]interface{}{
+				"nodeName":   "node-a",
+				"containers": []interface{}{map[string]interface{}{"image": "api:1"}, map[string]interface{}{"image": "proxy:2"}},
+			},
+			"status": map[string]interface{}{
+				"phase": "Running",
+				"containerStatuses": []interface{}{
+					map[string]interface{}{"ready": true, "restartCount": int64(0)},
+					map[string]interface{}{"ready": false, "restartCount": int64(3), "state": map[string]interface{}{"waiting": map[string]interface{}{"reason": "CrashLoopBackOff"}}},
+				},
+			},
+		}),
+		object("v1", "Pod", "billing", "worker-0", nil),
+		object("apps/v1", "Deployment", "shop", "api", map[string]interface{}{
+			"spec":   map[string]interface{}{"replicas": int64(3)},
+			"status": map[string]interface{}{"readyReplicas": int64(2)},
+		}),
+		object("v1", "Node", "", "node-a", map[string]interface{}{
+			"status": map[string]interface{}{"conditions": []interface{}{map[string]interface{}{"type": "Ready", "status": "True"}}},
+		}),
+	)
+
+	tests := []struct {
+		name  string
+		query ResourceQuery
+		want  []string
+	}{
+		{
+			name:  "pods in a namespace with fields",
+			query: ResourceQuery{Kind: "pods", Namespace: "shop", Fields: []string{"spec.nodeName", ".spec.containers.image"}},
+			want: []string{
+				"NAME   STATUS                                   AGE  SPEC.NODENAME  SPEC.CONTAINERS.IMAGE",
+				"api-0  CrashLoopBackOff, ready 1/2, restarts 3  5d   node-a         api:1,proxy:2",
+			},
+		},
+		{
+			name:  "kind name",
+			query: ResourceQuery{Kind: "Deployment", Namespace: "shop"},
+			want: []string{
+				"NAME  STATUS     AGE",
+				"api   ready 2/3  5d",
+			},
+		},
+		{
+			name:  "cluster-scoped",
+			query: ResourceQuery{Kind: "node"},
+			want: []string{
+				"NAME    STATUS  AGE",
+				"node-a  Ready   5d",
+			},
+		},
+		{
+			name:  "empty namespace",
+			query: ResourceQuery{Kind: "pods"},
+			want:  []string{"No pods found in namespace default"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := client.GetResources(context.Background(), tt.query)
+			if err != nil {
+				t.Fatalf("GetResources() failed: %v", err)
+			}
+			if strings.TrimRight(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("GetResources() =\n%s\nwant\n%s", got, strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+
+	if _, err := client.GetResources(context.Background(), ResourceQuery{Kind: "widgets"}); err == nil {
+		t.Error("GetResources() with an unknown kind should fail")
+	}
+}
+
+func TestDescribeResource(t *testing.T) {
+	pod := object("v1", "Pod", "shop", "api-0", nil)
+	pod.SetManagedFields([]metav1.ManagedFieldsEntry{{Manager: "kubectl"}})
+	pod.SetAnnotations(map[string]string{corev1.LastAppliedConfigAnnotation: "{}", "team": "payments"})
+
+	client := newTestClient(t, []runtime.Object{
+		&corev1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Name: "e1", Namespace: "shop"},
+			InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "api-0"},
+			Type:           corev1.EventTypeWarning,
+			Reason:         "BackOff",
+			Message:        "Back-off restarting failed container",
+			LastTimestamp:  metav1.NewTime(time.Now().Add(-time.Minute)),
+		},
+	}, pod)
+
+	got, err := client.DescribeResource(context.Background(), "pod", "api-0", "shop")
+	if err != nil {
+		t.Fatalf("DescribeResource() failed: %v", err)
+	}
+	for _, want := range []string{"name: api-0", "team: payments", "BackOff", "pod/api-0"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("DescribeResource() missing %q:\n%s", want, got)
+		}
+	}
+	for _, unwanted := range []string{"managedFields", corev1.LastAppliedConfigAnnotation} {
+		if strings.Contains(got, unwanted) {
+			t.Errorf("DescribeResource() contains %q:\n%s", unwanted, got)
+		}
+	}
+}
+
+func TestSecretValuesAreRedacted(t *testing.T) {
+	secret := object("v1", "Secret", "shop", "db", map[string]interface{}{
+		"type":       "Opaque",
+		"data":       map[string]interface{}{"db-password": "aHVudGVyMg==", "tls.key": "c2VjcmV0LWtleQ=="},
+		"stringData": map[string]interface{}{"api_token": "ghp_abcdef"},
+	})
+	// As set by kubectl apply
+	secret.SetAnnotations(map[string]string{
+		corev1.LastAppliedConfigAnnotation: `{"apiVersion":"v1","data":{"db-password":"aHVudGVyMg=="},"kind":"Secret"}`,
+		"team":                             "payments",
+	})
+	client := newTestClient(t, nil, secret)
+
+	described, err := client.DescribeResource(context.Background(), "secret", "db", "shop")
+	if err != nil {
+		t.Fatalf("DescribeResource() failed: %v", err)
+	}
+	listed, err := client.GetResources(context.Background(), ResourceQuery{Kind: "secrets", Namespace: "shop", Fields: []string{"data.db-password", "type", "metadata.annotations"}})
+	if err != nil {
+		t.Fatalf("GetResources() failed: %v", err)
+	}
+
+	for _, want := range []string{"db-password: 7 bytes", "tls.key: 10 bytes", "api_token: 10 bytes"} {
+		if !strings.Contains(described, want) {
+			t.Errorf("DescribeResource() missing %q:\n%s", want, described)
+		}
+	}
+	if !strings.Contains(listed, "7 bytes") || !strings.Contains(listed, "Opaque") || !strings.Contains(listed, "payments") {
+		t.Errorf("GetResources() =\n%s", listed)
+	}
+	for _, secret := range []string{"aHVudGVyMg==", "hunter2", "ghp_abcdef", "c2VjcmV0LWtleQ=="} {
+		if strings.Contains(described, secret) || strings.Contains(listed, secret) {
+			t.Errorf("a secret value %q was shown:\n%s\n%s", secret, described, listed)
+		}
+	}
+}
+
+func TestEvents(t *testing.T) {
+	event := func(name, kind, object, eventType string, ago time.Duration) *corev1.Event {
+		return &corev1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Name: name, Namespace: "shop"},
+			InvolvedObject: corev1.ObjectReference{Kind: kind, Name: object},
+			Type:           eventType,
+			Reason:         name,
+			LastTimestamp:  metav1.NewTime(time.Now().Add(-ago)),
+		}
+	}
+	client := newTestClient(t, []runtime.Object{
+		event("Old", "Pod", "api-0", corev1.EventTypeWarning, time.Hour),
+		event("New", "Pod", "api-0", corev1.EventTypeWarning, time.Minute),
+		event("Pulled", "Pod", "api-0", corev1.EventTypeNormal, time.Minute),
+		event("Other", "Pod", "web-0", corev1.EventTypeWarning, time.Minute),
+	})
+
+	got, err := client.Events(context.Background(), EventQuery{Namespace: "shop", Kind: "Pod", Name: "api-0", WarningsOnly: true})
+	if err != nil {
+		t.Fatalf("Events() failed: %v", err)
+	}
+	lines := strings.Split(strings.TrimRight(got, "\n"), "\n")
+	if len(lines) != 3 || !strings.Contains(lines[1], "New") || !strings.Contains(lines[2], "Old") {
+		t.Errorf("Events() =\n%s\nwant New then Old", got)
+	}
+}
+
+func TestTop(t *testing.T) {
+	client := newTestClient(t, nil)
+
+	// The fake dynamic client can't map metrics kinds to their resources
+	podMetrics := metricsGroupVersion.WithResource("pods")
+	for _, obj := range []*unstructured.Unstructured{
+		object("metrics.k8s.io/v1beta1", "PodMetrics", "shop", "api-0", map[string]interface{}{
+			"containers": []interface{}{
+				map[string]interface{}{"usage": map[string]interface{}{"cpu": "150m", "memory": "64Mi"}},
+				map[string]interface{}{"usage": map[string]interface{}{"cpu": "50m", "memory": "64Mi"}},
+			},
+		}),
+		object("metrics.k8s.io/v1beta1", "PodMetrics", "shop", "web-0", map[string]interface{}{
+			"containers": []interface{}{
+				map[string]interface{}{"usage": map[string]interface{}{"cpu": "1", "memory": "1Gi"}},
+			},
+		}),
+	} {
+		if _, err := client.dynamic.Resource(podMetrics).Namespace("shop").Create(context.Background(), obj, metav1.CreateOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := client.Top(context.Background(), "pods", "shop", false)
+	if err != nil {
+		t.Fatalf("Top() failed: %v", err)
+	}
+	want := "NAME   CPU    MEMORY\nweb-0  1000m  1024Mi\napi-0  200m   128Mi\n"
+	if got != want {
+		t.Errorf("Top() =\n%s\nwant\n%s", got, want)
+	}
+
+	if _, err := client.Top(context.Background(), "services", "", false); err == nil {
+		t.Error("Top() for services should fail")
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	if got := truncate("short"); got != "short" {
+		t.Errorf("truncate() = %q, want short", got)
+	}
+	got := truncate(strings.Repeat("x", MaxOutputBytes+10))
+	if !strings.HasSuffix(got, "... output truncated (10 more bytes), narrow the query") {
+		t.Errorf("truncate() suffix = %q", got[len(got)-60:])
+	}
+}
@@ -0,0 +1,311 @@
+package llm
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"k8x/internal/config"
+)
+
+// ApprovalRequest describes a tool call awaiting the user's approval
+type ApprovalRequest struct {
+	// Tool is the tool's registered name
+	Tool string
+	// Subject is what session patterns are matched against: the command of
+	// shell tool calls, the tool name otherwise
+	Subject string
+	// Display describes the call to the user
+	Display string
+	// Editable is the text the user may edit before running the call: the
+	// command of shell tool calls, the JSON arguments otherwise
+	Editable string
+	// Destructive marks calls that may modify their environment. They are
+	// asked for in every mode and can't be allowed by a pattern.
+	Destructive bool
+}
+
+// ApprovalChoice is the user's answer to an approval request
+type ApprovalChoice int
+
+const (
+	// ApprovalDeny cancels the call
+	ApprovalDeny ApprovalChoice = iota
+	// ApprovalApprove runs the call once
+	ApprovalApprove
+	// ApprovalEdit runs the call with the edited text
+	ApprovalEdit
+	// ApprovalAllowPattern runs the call and every later call matching the
+	// pattern for the rest of the session
+	ApprovalAllowPattern
+)
+
+// ApprovalDecision is the user's answer to an approval request
+type ApprovalDecision struct {
+	Choice ApprovalChoice
+	// Edited replaces the request's Editable text for ApprovalEdit
+	Edited string
+	// Pattern is the glob pattern to allow for ApprovalAllowPattern
+	Pattern string
+}
+
+// Approver asks the user to approve a tool call
+type Approver func(request ApprovalRequest) ApprovalDecision
+
+// LineReader reads a line of input after showing prompt, with initial as
+// editable default text
+type LineReader func(prompt, initial string) (string, error)
+
+// NewPromptApprover returns an approver asking on the terminal through
+// readLine
+func NewPromptApprover(readLine LineReader) Approver {
+	return func(request ApprovalRequest) ApprovalDecision {
+		fmt.Printf("\n🔍 About to execute: %s\n", request.Display)
+
+		prompt := "Approve? [y]es / [n]o / [e]dit / [a]lways allow matching calls: "
+		if request.Destructive {
+			prompt = "Approve? [y]es / [n]o / [e]dit: "
+		}
+		answer, err := readLine(prompt, "")
+		if err != nil {
+			return ApprovalDecision{Choice: ApprovalDeny}
+		}
+
+		switch strings.ToLower(strings.TrimSpace(answer)) {
+		case "y", "yes":
+			return ApprovalDecision{Choice: ApprovalApprove}
+		case "e", "edit":
+			edited, err := readLine("Edit: ", request.Editable)
+			if err != nil || strings.TrimSpace(edited) == "" {
+				return ApprovalDecision{Choice: ApprovalDeny}
+			}
+			return ApprovalDecision{Choice: ApprovalEdit, Edited: strings.TrimSpace(edited)}
+		case "a", "always":
+			if request.Destructive {
+				return ApprovalDecision{Choice: ApprovalDeny}
+			}
+			pattern, err := readLine("Allow pattern: ", SuggestApprovalPattern(request.Subject))
+			if err != nil || strings.TrimSpace(pattern) == "" {
+				return ApprovalDecision{Choice: ApprovalDeny}
+			}
+			return ApprovalDecision{Choice: ApprovalAllowPattern, Pattern: strings.TrimSpace(pattern)}
+		default:
+			return ApprovalDecision{Choice: ApprovalDeny}
+		}
+	}
+}
+
+// readStdinLine reads a line from standard input. It can't offer initial
+// text for editing, so an empty answer keeps it.
+func readStdinLine(prompt, initial string) (string, error) {
+	if initial != "" {
+		fmt.Printf("%s[%s] ", prompt, initial)
+	} else {
+		fmt.Print(prompt)
+	}
+
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	if line = strings.TrimSpace(line); line == "" {
+		return initial, nil
+	}
+	return line, nil
+}
+
+// SuggestApprovalPattern proposes a pattern allowing calls like subject,
+// e.g. "kubectl get *" for "kubectl get pods -A"
+func SuggestApprovalPattern(subject string) string {
+	words := strings.Fields(subject)
+	switch {
+	case len(words) == 0:
+		return subject
+	case len(words) == 1:
+		return words[0]
+	default:
+		return words[0] + " " + words[1] + " *"
+	}
+}
+
+// MatchApprovalPattern reports whether subject matches a glob pattern in
+// which * stands for any text, including spaces and slashes. Command lines
+// match when their first command does and the rest of the pipeline only
+// runs commands matching too or text filters. Command lines chaining
+// commands with ;, &&, || or &, or using substitutions or redirections,
+// never match.
+func MatchApprovalPattern(pattern, subject string) bool {
+	if containsAny(subject, shellSequences) || containsAny(subject, shellSubstitutions) {
+		return false
+	}
+	segments := splitShellSegments(subject)
+	if len(segments) == 0 {
+		return false
+	}
+
+	expr := strings.ReplaceAll(regexp.QuoteMeta(strings.TrimSpace(pattern)), `\*`, ".*")
+	re, err := regexp.Compile("^" + expr + "$")
+	if err != nil {
+		return false
+	}
+	for i, segment := range segments {
+		if i > 0 && kubectlPipelineFilters[segment.words[0]] {
+			continue
+		}
+		if !re.MatchString(strings.Join(segment.words, " ")) {
+			return false
+		}
+	}
+	return true
+}
+
+// kubectlPipelineFilters are commands that may follow kubectl in a pipeline
+// without making a command "non-kubectl"
+var kubectlPipelineFilters = map[string]bool{
+	"grep": true, "egrep": true, "jq": true, "yq": true, "head": true,
+	"tail": true, "wc": true, "sort": true, "uniq": true, "cut": true,
+	"column": true,
+}
+
+// shellSequences chain commands on a command line besides pipes
+var shellSequences = []string{";", "&", "||", "\n"}
+
+// shellSubstitutions run further commands or read and write files from
+// within a command line
+var shellSubstitutions = []string{"$(", "`", "<", ">"}
+
+// containsAny reports whether s contains any of substrings, quoted or not
+func containsAny(s string, substrings []string) bool {
+	for _, substring := range substrings {
+		if strings.Contains(s, substring) {
+			return true
+		}
+	}
+	return false
+}
+
+// isKubectlCommand reports whether a command line only runs kubectl,
+// possibly piped through text filters, without substitutions or
+// redirections
+func isKubectlCommand(command string) bool {
+	if containsAny(command, shellSubstitutions) {
+		return false
+	}
+	segments := splitShellSegments(command)
+	if len(segments) == 0 || segments[0].words[0] != "kubectl" {
+		return false
+	}
+	for _, segment := range segments[1:] {
+		if segment.words[0] != "kubectl" && !kubectlPipelineFilters[segment.words[0]] {
+			return false
+		}
+	}
+	return true
+}
+
+// SetApprovalMode sets which tool calls need the user's approval
+func (tm *ToolManager) SetApprovalMode(mode config.ApprovalMode) {
+	tm.approvalMu.Lock()
+	defer tm.approvalMu.Unlock()
+	tm.approvalMode = mode
+}
+
+// ApprovalMode returns which tool calls need the user's approval
+func (tm *ToolManager) ApprovalMode() config.ApprovalMode {
+	tm.approvalMu.Lock()
+	defer tm.approvalMu.Unlock()
+	return tm.approvalMode
+}
+
+// SetApprover sets how the user is asked to approve tool calls
+func (tm *ToolManager) SetApprover(approver Approver) {
+	tm.approvalMu.Lock()
+	defer tm.approvalMu.Unlock()
+	tm.approver = approver
+}
+
+// AllowPattern approves calls matching a glob pattern for the rest of the
+// session
+func (tm *ToolManager) AllowPattern(pattern string) {
+	tm.approvalMu.Lock()
+	defer tm.approvalMu.Unlock()
+	tm.allowedPatterns = append(tm.allowedPatterns, pattern)
+}
+
+// AllowedPatterns returns the patterns approved for the session
+func (tm *ToolManager) AllowedPatterns() []string {
+	tm.approvalMu.Lock()
+	defer tm.approvalMu.Unlock()
+	return append([]string(nil), tm.allowedPatterns...)
+}
+
+// ClearAllowedPatterns forgets the patterns approved for the session
+func (tm *ToolManager) ClearAllowedPatterns() {
+	tm.approvalMu.Lock()
+	defer tm.approvalMu.Unlock()
+	tm.allowedPatterns = nil
+}
+
+// needsApproval reports whether a call needs the user's approval under the
+// current mode and session patterns
+func (tm *ToolManager) needsApproval(request ApprovalRequest) bool {
+	if request.Destructive {
+		return true
+	}
+
+	tm.approvalMu.Lock()
+	defer tm.approvalMu.Unlock()
+
+	switch tm.approvalMode {
+	case config.ApprovalAlways:
+	case config.ApprovalNonKubectl:
+		if request.Tool == "execute_shell_command" && isKubectlCommand(request.Subject) {
+			return false
+		}
+		if kubeToolNames[request.Tool] {
+			return false
+		}
+	default:
+		return false
+	}
+
+	for _, pattern := range tm.allowedPatterns {
+		if MatchApprovalPattern(pattern, request.Subject) {
+			return false
+		}
+	}
+	return true
+}
+
+// approve asks the user to approve a call if needed. It returns the text
+// to run, which differs from request.Editable if the user edited it, or an
+// error if the call was denied.
+func (tm *ToolManager) approve(request ApprovalRequest) (string, error) {
+	if !tm.needsApproval(request) {
+		return request.Editable, nil
+	}
+
+	tm.approvalMu.Lock()
+	approver := tm.approver
+	tm.approvalMu.Unlock()
+	if approver == nil {
+		approver = NewPromptApprover(readStdinLine)
+	}
+
+	decision := approver(request)
+	switch decision.Choice {
+	case ApprovalApprove:
+		return request.Editable, nil
+	case ApprovalEdit:
+		return decision.Edited, nil
+	case ApprovalAllowPattern:
+		if !request.Destructive {
+			tm.AllowPattern(decision.Pattern)
+		}
+		return request.Editable, nil
+	default:
+		return "", fmt.Errorf("tool execution cancelled by user")
+	}
+}
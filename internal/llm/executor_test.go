@@ -144,3 +144,235 @@ func TestToolManagerKubernetesConfig(t *testing.T) {
 		t.Errorf("Namespace = %q, want %q", tm.executor.k8sConfig.Namespace, "test-namespace")
 	}
 }
+
+func TestMultiClusterContexts(t *testing.T) {
+	executor := NewShellExecutor(".")
+	executor.SetKubernetesConfig(&config.KubernetesConfig{
+		Context:  "prod",
+		Contexts: []string{"staging"},
+	})
+
+	tests := []struct {
+		name        string
+		command     string
+		kubeContext string
+		expectedCmd string
+		expectedErr string
+	}{
+		{
+			name:        "default context",
+			command:     "kubectl get pods",
+			expectedCmd: "kubectl get pods --context=prod",
+		},
+		{
+			name:        "selected context",
+			command:     "kubectl get pods",
+			kubeContext: "staging",
+			expectedCmd: "kubectl get pods --context=staging",
+		},
+		{
+			name:        "helm follows selected context",
+			command:     "helm list -A",
+			kubeContext: "staging",
+			expectedCmd: "helm list -A --kube-context=staging",
+		},
+		{
+			name:        "unknown context",
+			command:     "kubectl get pods",
+			kubeContext: "dev",
+			expectedErr: `kube-context "dev" is not part of this session`,
+		},
+		{
+			name:        "unknown context flag",
+			command:     "kubectl get pods --context=dev",
+			expectedErr: `kube-context "dev" is not part of this session`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.expectedErr != "" {
+				_, err := executor.ExecuteInContext(tt.command, tt.kubeContext)
+				if err == nil || !strings.Contains(err.Error(), tt.expectedErr) {
+					t.Errorf("ExecuteInContext() error = %v, want %q", err, tt.expectedErr)
+				}
+				return
+			}
+
+			if cmd := executor.applyKubernetesConfig(tt.command, tt.kubeContext); cmd != tt.expectedCmd {
+				t.Errorf("Command = %q, want %q", cmd, tt.expectedCmd)
+			}
+		})
+	}
+}
+
+func TestShellToolContextArgument(t *testing.T) {
+	tm := NewToolManager(".")
+	for _, tool := range tm.GetTools() {
+		if _, ok := tool.Function.Parameters.Properties["context"]; ok {
+			t.Errorf("single-cluster tool %s has a context argument", tool.Function.Name)
+		}
+	}
+
+	tm.SetKubernetesConfig(&config.KubernetesConfig{Context: "prod", Contexts: []string{"staging"}})
+	tools := tm.GetTools()
+	if len(tools) != 6 {
+		t.Fatalf("GetTools() returned %d tools, want 6", len(tools))
+	}
+	for _, tool := range tools {
+		spec, ok := tool.Function.Parameters.Properties["context"]
+		if !ok {
+			t.Errorf("multi-cluster tool %s has no context argument", tool.Function.Name)
+			continue
+		}
+		if strings.Join(spec.Enum, ",") != "prod,staging" {
+			t.Errorf("%s context enum = %v, want [prod staging]", tool.Function.Name, spec.Enum)
+		}
+	}
+}
+
+func TestKubeTools(t *testing.T) {
+	tm := NewToolManager(".")
+
+	var names []string
+	for _, tool := range tm.GetTools() {
+		names = append(names, tool.Function.Name)
+	}
+	want := "describe_resource,execute_shell_command,get_events,get_logs,get_resources,top"
+	if got := strings.Join(names, ","); got != want {
+		t.Errorf("GetTools() = %s, want %s", got, want)
+	}
+
+	tm.SetKubernetesConfig(&config.KubernetesConfig{Context: "prod", Contexts: []string{"staging"}})
+	tests := []struct {
+		name        string
+		tool        string
+		args        string
+		expectedErr string
+	}{
+		{"unknown context", "get_resources", `{"kind": "pods", "context": "dev"}`, "is not part of this session"},
+		{"missing pod", "get_logs", `{}`, "pod parameter is required"},
+		{"invalid since", "get_logs", `{"pod": "api-0", "since": "yesterday"}`, "invalid since duration"},
+		{"missing name", "describe_resource", `{"kind": "pod"}`, "name parameter is required"},
+		{"invalid arguments", "top", `{"kind": 1}`, "failed to parse arguments"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tm.ExecuteTool(tt.tool, tt.args)
+			if err == nil || !strings.Contains(err.Error(), tt.expectedErr) {
+				t.Errorf("ExecuteTool() error = %v, want %q", err, tt.expectedErr)
+			}
+		})
+	}
+}
+
+func TestApplyKubernetesConfig(t *testing.T) {
+	executor := NewShellExecutor(".")
+	executor.SetKubernetesConfig(&config.KubernetesConfig{Context: "prod", Namespace: "shop"})
+
+	tests := []struct {
+		name        string
+		command     string
+		expectedCmd string
+	}{
+		{
+			name:        "simple command",
+			command:     "kubectl get pods",
+			expectedCmd: "kubectl get pods --context=prod --namespace=shop",
+		},
+		{
+			name:        "flags go into the kubectl segment of a pipeline",
+			command:     "kubectl get pods | grep api",
+			expectedCmd: "kubectl get pods --context=prod --namespace=shop | grep api",
+		},
+		{
+			name:        "grep -n does not count as a namespace flag",
+			command:     "kubectl get pods -o wide | grep -n api",
+			expectedCmd: "kubectl get pods -o wide --context=prod --namespace=shop | grep -n api",
+		},
+		{
+			name:        "every kubectl segment",
+			command:     "kubectl get pods && kubectl get events -n other; echo done",
+			expectedCmd: "kubectl get pods --context=prod --namespace=shop && kubectl get events -n other --context=prod; echo done",
+		},
+		{
+			name:        "all namespaces",
+			command:     "kubectl get pods -A",
+			expectedCmd: "kubectl get pods -A --context=prod",
+		},
+		{
+			name:        "attached namespace forms",
+			command:     "kubectl get pods -nkube-system | kubectl get svc --namespace=kube-system",
+			expectedCmd: "kubectl get pods -nkube-system --context=prod | kubectl get svc --namespace=kube-system --context=prod",
+		},
+		{
+			name:        "cluster-scoped resources",
+			command:     "kubectl get nodes,pv && kubectl describe node/worker-1",
+			expectedCmd: "kubectl get nodes,pv --context=prod && kubectl describe node/worker-1 --context=prod",
+		},
+		{
+			name:        "cluster-scoped verbs",
+			command:     "kubectl api-resources",
+			expectedCmd: "kubectl api-resources --context=prod",
+		},
+		{
+			name:        "flags before the verb",
+			command:     "kubectl --context=staging -o json get nodes",
+			expectedCmd: "kubectl --context=staging -o json get nodes",
+		},
+		{
+			name:        "quoted pipe",
+			command:     `kubectl get pods -o jsonpath='{range .items[*]}{.metadata.name}|{end}' | wc -l`,
+			expectedCmd: `kubectl get pods -o jsonpath='{range .items[*]}{.metadata.name}|{end}' --context=prod --namespace=shop | wc -l`,
+		},
+		{
+			name:        "stderr redirected to stdout",
+			command:     "kubectl get pods 2>&1 | grep api",
+			expectedCmd: "kubectl get pods --context=prod --namespace=shop 2>&1 | grep api",
+		},
+		{
+			name:        "output redirected to a file",
+			command:     "kubectl get pods &>pods.txt",
+			expectedCmd: "kubectl get pods --context=prod --namespace=shop &>pods.txt",
+		},
+		{
+			name:        "redirection before arguments",
+			command:     "kubectl get 2>/dev/null pods",
+			expectedCmd: "kubectl get --context=prod --namespace=shop 2>/dev/null pods",
+		},
+		{
+			name:        "arguments after --",
+			command:     "kubectl exec api -- ls -la",
+			expectedCmd: "kubectl exec api --context=prod --namespace=shop -- ls -la",
+		},
+		{
+			name:        "background command",
+			command:     "kubectl get pods & kubectl get svc",
+			expectedCmd: "kubectl get pods --context=prod --namespace=shop & kubectl get svc --context=prod --namespace=shop",
+		},
+		{
+			name:        "helm",
+			command:     "helm list | grep api",
+			expectedCmd: "helm list --kube-context=prod --namespace=shop | grep api",
+		},
+		{
+			name:        "helm all namespaces",
+			command:     "helm list --all-namespaces",
+			expectedCmd: "helm list --all-namespaces --kube-context=prod",
+		},
+		{
+			name:        "helm local command",
+			command:     "helm repo list",
+			expectedCmd: "helm repo list",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if cmd := executor.applyKubernetesConfig(tt.command, ""); cmd != tt.expectedCmd {
+				t.Errorf("applyKubernetesConfig(%q) =\n%q\nwant\n%q", tt.command, cmd, tt.expectedCmd)
+			}
+		})
+	}
+}
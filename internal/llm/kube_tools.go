@@ -0,0 +1,347 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"k8x/internal/config"
+	"k8x/internal/kube"
+)
+
+// kubeToolNames are the names of the typed Kubernetes tools
+var kubeToolNames = map[string]bool{
+	"get_resources": true, "describe_resource": true, "get_logs": true,
+	"get_events": true, "top": true,
+}
+
+// kubeTools provides the typed, read-only Kubernetes tools. They read the
+// cluster through the API using the shell executor's Kubernetes
+// configuration, so that they follow context and namespace switches.
+type kubeTools struct {
+	executor *ShellExecutor
+
+	mu      sync.Mutex
+	clients map[string]*kube.Client
+}
+
+// newKubeTools creates the Kubernetes tools for an executor's configuration
+func newKubeTools(executor *ShellExecutor) *kubeTools {
+	return &kubeTools{
+		executor: executor,
+		clients:  make(map[string]*kube.Client),
+	}
+}
+
+// client returns the API client for a kube-context of the session, the
+// default one if kubeContext is empty
+func (k *kubeTools) client(kubeContext string) (*kube.Client, error) {
+	if err := k.executor.validateContext(kubeContext); err != nil {
+		return nil, err
+	}
+
+	var kubeConfig config.KubernetesConfig
+	if k.executor.k8sConfig != nil {
+		kubeConfig = *k.executor.k8sConfig
+	}
+	if kubeContext == "" {
+		if contexts := kubeConfig.SessionContexts(); len(contexts) > 0 {
+			kubeContext = contexts[0]
+		}
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	key := strings.Join([]string{kubeConfig.KubeConfigPath, kubeContext, kubeConfig.Namespace}, "\x00")
+	if client, ok := k.clients[key]; ok {
+		return client, nil
+	}
+	client, err := kube.NewClient(&kubeConfig, kubeContext)
+	if err != nil {
+		return nil, err
+	}
+	k.clients[key] = client
+	return client, nil
+}
+
+// tools returns the tool definitions
+func (k *kubeTools) tools() []Tool {
+	contexts := k.executor.SessionContexts()
+
+	withContext := func(properties map[string]ToolParameterSpec) map[string]ToolParameterSpec {
+		if len(contexts) > 1 {
+			properties["context"] = contextParameter(contexts)
+		}
+		return properties
+	}
+	namespace := ToolParameterSpec{Type: "string", Description: "Namespace (default: the session's namespace)"}
+	allNamespaces := ToolParameterSpec{Type: "boolean", Description: "Query all namespaces"}
+
+	return []Tool{
+		{
+			Type: "function",
+			Function: ToolFunction{
+				Name:        "get_resources",
+				Description: fmt.Sprintf("List Kubernetes resources of any kind, including custom resources, with their status and age, like 'kubectl get' with custom columns. Output is capped at %d bytes.", kube.MaxOutputBytes),
+				Parameters: ToolParameters{
+					Type: "object",
+					Properties: withContext(map[string]ToolParameterSpec{
+						"kind":           {Type: "string", Description: "Kind, resource or short name, e.g. pods, Deployment, svc or certificates.cert-manager.io"},
+						"namespace":      namespace,
+						"all_namespaces": allNamespaces,
+						"selector":       {Type: "string", Description: "Label selector, e.g. app=api,tier!=cache"},
+						"field_selector": {Type: "string", Description: "Field selector, e.g. status.phase!=Running"},
+						"fields":         {Type: "string", Description: "Comma-separated dotted paths shown as extra columns, e.g. spec.nodeName,spec.containers.image"},
+					}),
+					Required: []string{"kind"},
+				},
+			},
+			Handler: k.getResources,
+		},
+		{
+			Type: "function",
+			Function: ToolFunction{
+				Name:        "describe_resource",
+				Description: "Show one Kubernetes resource as YAML, without managed fields, followed by the events involving it.",
+				Parameters: ToolParameters{
+					Type: "object",
+					Properties: withContext(map[string]ToolParameterSpec{
+						"kind":      {Type: "string", Description: "Kind, resource or short name, e.g. pod, deployment or ingress"},
+						"name":      {Type: "string", Description: "Resource name"},
+						"namespace": namespace,
+					}),
+					Required: []string{"kind", "name"},
+				},
+			},
+			Handler: k.describeResource,
+		},
+		{
+			Type: "function",
+			Function: ToolFunction{
+				Name:        "get_logs",
+				Description: fmt.Sprintf("Get the last log lines of a pod's container. The most recent %d bytes are returned.", kube.MaxOutputBytes),
+				Parameters: ToolParameters{
+					Type: "object",
+					Properties: withContext(map[string]ToolParameterSpec{
+						"pod":       {Type: "string", Description: "Pod name"},
+						"namespace": namespace,
+						"container": {Type: "string", Description: "Container name, required for pods with several containers"},
+						"since":     {Type: "string", Description: "Only lines newer than this duration, e.g. 10m or 1h"},
+						"previous":  {Type: "boolean", Description: "Logs of the previous, crashed container instance"},
+						"tail":      {Type: "integer", Description: fmt.Sprintf("Number of lines from the end (default %d)", kube.DefaultLogTail)},
+					}),
+					Required: []string{"pod"},
+				},
+			},
+			Handler: k.getLogs,
+		},
+		{
+			Type: "function",
+			Function: ToolFunction{
+				Name:        "get_events",
+				Description: "List Kubernetes events, most recent first, optionally only those of one involved object.",
+				Parameters: ToolParameters{
+					Type: "object",
+					Properties: withContext(map[string]ToolParameterSpec{
+						"namespace":      namespace,
+						"all_namespaces": allNamespaces,
+						"kind":           {Type: "string", Description: "Kind of the involved object, e.g. Pod"},
+						"name":           {Type: "string", Description: "Name of the involved object"},
+						"warnings_only":  {Type: "boolean", Description: "Only Warning events"},
+					}),
+					Required: []string{},
+				},
+			},
+			Handler: k.getEvents,
+		},
+		{
+			Type: "function",
+			Function: ToolFunction{
+				Name:        "top",
+				Description: "Show CPU and memory usage of pods or nodes from metrics-server, highest CPU first.",
+				Parameters: ToolParameters{
+					Type: "object",
+					Properties: withContext(map[string]ToolParameterSpec{
+						"kind":           {Type: "string", Description: "pods or nodes", Enum: []string{"pods", "nodes"}},
+						"namespace":      namespace,
+						"all_namespaces": allNamespaces,
+					}),
+					Required: []string{"kind"},
+				},
+			},
+			Handler: k.top,
+		},
+	}
+}
+
+// contextParameter describes the kube-context argument of multi-cluster sessions
+func contextParameter(contexts []string) ToolParameterSpec {
+	return ToolParameterSpec{
+		Type:        "string",
+		Description: fmt.Sprintf("The kube-context (cluster) to run against (default: %s)", contexts[0]),
+		Enum:        contexts,
+	}
+}
+
+// requestContext bounds a tool's API requests
+func requestContext() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), kube.DefaultRequestTimeout)
+}
+
+func (k *kubeTools) getResources(args string) (string, error) {
+	var params struct {
+		Kind          string `json:"kind"`
+		Namespace     string `json:"namespace"`
+		AllNamespaces bool   `json:"all_namespaces"`
+		Selector      string `json:"selector"`
+		FieldSelector string `json:"field_selector"`
+		Fields        string `json:"fields"`
+		Context       string `json:"context"`
+	}
+	if err := json.Unmarshal([]byte(args), &params); err != nil {
+		return "", fmt.Errorf("failed to parse arguments: %w", err)
+	}
+
+	client, err := k.client(params.Context)
+	if err != nil {
+		return "", err
+	}
+
+	var fields []string
+	for _, field := range strings.Split(params.Fields, ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			fields = append(fields, field)
+		}
+	}
+
+	ctx, cancel := requestContext()
+	defer cancel()
+	return client.GetResources(ctx, kube.ResourceQuery{
+		Kind:          params.Kind,
+		Namespace:     params.Namespace,
+		AllNamespaces: params.AllNamespaces,
+		Selector:      params.Selector,
+		FieldSelector: params.FieldSelector,
+		Fields:        fields,
+	})
+}
+
+func (k *kubeTools) describeResource(args string) (string, error) {
+	var params struct {
+		Kind      string `json:"kind"`
+		Name      string `json:"name"`
+		Namespace string `json:"namespace"`
+		Context   string `json:"context"`
+	}
+	if err := json.Unmarshal([]byte(args), &params); err != nil {
+		return "", fmt.Errorf("failed to parse arguments: %w", err)
+	}
+	if params.Name == "" {
+		return "", fmt.Errorf("name parameter is required")
+	}
+
+	client, err := k.client(params.Context)
+	if err != nil {
+		return "", err
+	}
+
+	ctx, cancel := requestContext()
+	defer cancel()
+	return client.DescribeResource(ctx, params.Kind, params.Name, params.Namespace)
+}
+
+func (k *kubeTools) getLogs(args string) (string, error) {
+	var params struct {
+		Pod       string `json:"pod"`
+		Namespace string `json:"namespace"`
+		Container string `json:"container"`
+		Since     string `json:"since"`
+		Previous  bool   `json:"previous"`
+		Tail      int64  `json:"tail"`
+		Context   string `json:"context"`
+	}
+	if err := json.Unmarshal([]byte(args), &params); err != nil {
+		return "", fmt.Errorf("failed to parse arguments: %w", err)
+	}
+	if params.Pod == "" {
+		return "", fmt.Errorf("pod parameter is required")
+	}
+
+	var since time.Duration
+	if params.Since != "" {
+		var err error
+		if since, err = time.ParseDuration(params.Since); err != nil {
+			return "", fmt.Errorf("invalid since duration %q: %w", params.Since, err)
+		}
+	}
+
+	client, err := k.client(params.Context)
+	if err != nil {
+		return "", err
+	}
+
+	ctx, cancel := requestContext()
+	defer cancel()
+	return client.Logs(ctx, kube.LogQuery{
+		Pod:       params.Pod,
+		Namespace: params.Namespace,
+		Container: params.Container,
+		Since:     since,
+		Previous:  params.Previous,
+		Tail:      params.Tail,
+	})
+}
+
+func (k *kubeTools) getEvents(args string) (string, error) {
+	var params struct {
+		Namespace     string `json:"namespace"`
+		AllNamespaces bool   `json:"all_namespaces"`
+		Kind          string `json:"kind"`
+		Name          string `json:"name"`
+		WarningsOnly  bool   `json:"warnings_only"`
+		Context       string `json:"context"`
+	}
+	if err := json.Unmarshal([]byte(args), &params); err != nil {
+		return "", fmt.Errorf("failed to parse arguments: %w", err)
+	}
+
+	client, err := k.client(params.Context)
+	if err != nil {
+		return "", err
+	}
+
+	ctx, cancel := requestContext()
+	defer cancel()
+	return client.Events(ctx, kube.EventQuery{
+		Namespace:     params.Namespace,
+		AllNamespaces: params.AllNamespaces,
+		Kind:          params.Kind,
+		Name:          params.Name,
+		WarningsOnly:  params.WarningsOnly,
+	})
+}
+
+func (k *kubeTools) top(args string) (string, error) {
+	var params struct {
+		Kind          string `json:"kind"`
+		Namespace     string `json:"namespace"`
+		AllNamespaces bool   `json:"all_namespaces"`
+		Context       string `json:"context"`
+	}
+	if err := json.Unmarshal([]byte(args), &params); err != nil {
+		return "", fmt.Errorf("failed to parse arguments: %w", err)
+	}
+
+	client, err := k.client(params.Context)
+	if err != nil {
+		return "", err
+	}
+
+	ctx, cancel := requestContext()
+	defer cancel()
+	return client.Top(ctx, params.Kind, params.Namespace, params.AllNamespaces)
+}
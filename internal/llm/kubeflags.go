@@ -0,0 +1,314 @@
+package llm
+
+import (
+	"fmt"
+	"strings"
+)
+
+// kubectlValueFlags are kubectl flags whose value may follow as a separate
+// argument, needed to tell flag values from the verb and resource
+var kubectlValueFlags = map[string]bool{
+	"-n": true, "--namespace": true, "--context": true, "--cluster": true,
+	"--user": true, "--kubeconfig": true, "-s": true, "--server": true,
+	"--token": true, "--as": true, "--as-group": true, "--request-timeout": true,
+	"-v": true, "-l": true, "--selector": true, "-o": true, "--output": true,
+	"-c": true, "--container": true, "-f": true, "--filename": true,
+	"--field-selector": true, "--sort-by": true, "--since": true, "--tail": true,
+}
+
+// helmValueFlags are helm flags whose value may follow as a separate argument
+var helmValueFlags = map[string]bool{
+	"-n": true, "--namespace": true, "--kube-context": true, "--kubeconfig": true,
+	"-o": true, "--output": true, "--revision": true, "--max": true, "-f": true,
+	"--values": true,
+}
+
+// kubectlUnscopedVerbs are kubectl commands that don't target a namespace
+var kubectlUnscopedVerbs = map[string]bool{
+	"version": true, "api-resources": true, "api-versions": true,
+	"cluster-info": true, "config": true, "explain": true, "plugin": true,
+	"completion": true, "options": true, "certificate": true, "kustomize": true,
+	"help": true,
+}
+
+// kubectlClusterScopedResources are resource types that don't live in a
+// namespace, with their short names
+var kubectlClusterScopedResources = map[string]bool{
+	"node": true, "nodes": true, "no": true,
+	"namespace": true, "namespaces": true, "ns": true,
+	"persistentvolume": true, "persistentvolumes": true, "pv": true,
+	"storageclass": true, "storageclasses": true, "sc": true,
+	"clusterrole": true, "clusterroles": true,
+	"clusterrolebinding": true, "clusterrolebindings": true,
+	"customresourcedefinition": true, "customresourcedefinitions": true, "crd": true, "crds": true,
+	"priorityclass": true, "priorityclasses": true, "pc": true,
+	"ingressclass": true, "ingressclasses": true,
+	"runtimeclass": true, "runtimeclasses": true,
+	"csidriver": true, "csidrivers": true, "csinode": true, "csinodes": true,
+	"volumeattachment": true, "volumeattachments": true,
+	"mutatingwebhookconfiguration": true, "mutatingwebhookconfigurations": true,
+	"validatingwebhookconfiguration": true, "validatingwebhookconfigurations": true,
+	"apiservice": true, "apiservices": true,
+	"certificatesigningrequest": true, "certificatesigningrequests": true, "csr": true,
+	"componentstatus": true, "componentstatuses": true, "cs": true,
+}
+
+// helmLocalVerbs are helm commands that don't talk to the cluster
+var helmLocalVerbs = map[string]bool{
+	"repo": true, "search": true, "version": true, "env": true,
+	"completion": true, "plugin": true, "show": true, "inspect": true,
+	"package": true, "lint": true, "dependency": true, "dep": true,
+	"create": true, "verify": true, "help": true, "pull": true,
+	"push": true, "registry": true,
+}
+
+// shellSegment is a simple command of a shell command line, such as one
+// side of a pipe
+type shellSegment struct {
+	// end is the offset just past the segment's last non-blank character
+	end int
+	// insert is where flags can be added: just past the last argument
+	// before any redirection or "--"
+	insert int
+	// words are the command and its arguments, without redirections
+	words []string
+}
+
+// splitShellSegments splits a command line into its simple commands at
+// unquoted |, ||, &, &&, ; and newlines, and each of them into words with
+// quotes removed. Redirections such as > file, 2>&1 and &> file are left
+// out of the words and don't split commands.
+func splitShellSegments(command string) []shellSegment {
+	var (
+		segments []shellSegment
+		current  = shellSegment{insert: -1}
+		word     strings.Builder
+		inWord   bool
+		quote    byte
+		// wordStart is the segment's end before the current word began
+		wordStart int
+		// target is set when the next word is a redirection's target
+		target bool
+	)
+
+	startWord := func() {
+		if !inWord {
+			wordStart = current.end
+			inWord = true
+		}
+	}
+	markInsert := func(offset int) {
+		if current.insert < 0 {
+			current.insert = offset
+		}
+	}
+	endWord := func() {
+		if !inWord {
+			return
+		}
+		text := word.String()
+		word.Reset()
+		inWord = false
+		if target {
+			target = false
+			return
+		}
+		if text == "--" && len(current.words) > 0 {
+			markInsert(wordStart)
+		}
+		current.words = append(current.words, text)
+	}
+	endSegment := func() {
+		endWord()
+		target = false
+		if len(current.words) > 0 {
+			markInsert(current.end)
+			segments = append(segments, current)
+		}
+		current = shellSegment{insert: -1}
+	}
+
+	for i := 0; i < len(command); i++ {
+		c := command[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			} else if c == '\\' && quote == '"' && i+1 < len(command) {
+				i++
+				word.WriteByte(command[i])
+			} else {
+				word.WriteByte(c)
+			}
+			current.end = i + 1
+		case c == '\'' || c == '"':
+			quote = c
+			startWord()
+			current.end = i + 1
+		case c == '\\' && i+1 < len(command):
+			i++
+			if command[i] != '\n' {
+				word.WriteByte(command[i])
+				startWord()
+			}
+			current.end = i + 1
+		case c == '>' || c == '<' || (c == '&' && i+1 < len(command) && command[i+1] == '>'):
+			// A redirection, whose file descriptor, e.g. the 2 of 2>&1,
+			// is part of it rather than a word
+			if inWord && c != '&' && isDigits(word.String()) {
+				markInsert(wordStart)
+				word.Reset()
+				inWord = false
+			} else {
+				endWord()
+				markInsert(current.end)
+			}
+			for i+1 < len(command) && strings.IndexByte("<>&|", command[i+1]) >= 0 {
+				i++
+			}
+			current.end = i + 1
+			target = true
+		case c == '|' || c == '&' || c == ';' || c == '\n':
+			endSegment()
+		case c == ' ' || c == '\t':
+			endWord()
+		default:
+			word.WriteByte(c)
+			startWord()
+			current.end = i + 1
+		}
+	}
+	endSegment()
+	return segments
+}
+
+// isDigits reports whether s is a non-empty string of decimal digits
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// positionalArgs returns the arguments of a command that aren't flags or
+// flag values, skipping the command name
+func positionalArgs(words []string, valueFlags map[string]bool) []string {
+	var positional []string
+	for i := 1; i < len(words); i++ {
+		word := words[i]
+		if word == "--" {
+			return append(positional, words[i+1:]...)
+		}
+		if strings.HasPrefix(word, "-") && len(word) > 1 {
+			if valueFlags[word] {
+				i++
+			}
+			continue
+		}
+		positional = append(positional, word)
+	}
+	return positional
+}
+
+// hasFlag reports whether words set one of the given flags, as "--flag",
+// "--flag=value" or, for short flags, "-fvalue"
+func hasFlag(words []string, flags ...string) bool {
+	for _, word := range words[1:] {
+		if word == "--" {
+			return false
+		}
+		for _, flag := range flags {
+			if word == flag || strings.HasPrefix(word, flag+"=") {
+				return true
+			}
+			if len(flag) == 2 && strings.HasPrefix(word, flag) && !strings.HasPrefix(word, "--") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// kubectlNamespaced reports whether a kubectl command targets a namespace
+func kubectlNamespaced(words []string) bool {
+	positional := positionalArgs(words, kubectlValueFlags)
+	if len(positional) == 0 {
+		return false
+	}
+	verb := positional[0]
+	if kubectlUnscopedVerbs[verb] {
+		return false
+	}
+	if verb == "top" || verb == "get" || verb == "describe" {
+		if len(positional) < 2 {
+			return true
+		}
+		// All resource types of "get nodes,pv" or "get node/a" are cluster-scoped
+		for _, resource := range strings.Split(positional[1], ",") {
+			resource, _, _ = strings.Cut(resource, "/")
+			resource, _, _ = strings.Cut(resource, ".")
+			if !kubectlClusterScopedResources[strings.ToLower(resource)] {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// applyKubernetesConfig adds the session's kube-context and namespace flags
+// to every kubectl and helm command of a command line, including each side
+// of a pipeline, before any redirection or "--". Flags already given are respected, as are -A and
+// --all-namespaces, and cluster-scoped commands get no namespace. An empty
+// kubeContext selects the session's default context.
+func (se *ShellExecutor) applyKubernetesConfig(command, kubeContext string) string {
+	if se.k8sConfig == nil {
+		return command
+	}
+	if kubeContext == "" {
+		if contexts := se.k8sConfig.SessionContexts(); len(contexts) > 0 {
+			kubeContext = contexts[0]
+		}
+	}
+	namespace := se.k8sConfig.Namespace
+
+	segments := splitShellSegments(command)
+	// Insert from the end so that earlier offsets stay valid
+	for i := len(segments) - 1; i >= 0; i-- {
+		segment := segments[i]
+
+		var flags []string
+		switch segment.words[0] {
+		case "kubectl":
+			if kubeContext != "" && !hasFlag(segment.words, "--context") {
+				flags = append(flags, "--context="+kubeContext)
+			}
+			if namespace != "" && kubectlNamespaced(segment.words) &&
+				!hasFlag(segment.words, "-n", "--namespace", "-A", "--all-namespaces") {
+				flags = append(flags, "--namespace="+namespace)
+			}
+		case "helm":
+			positional := positionalArgs(segment.words, helmValueFlags)
+			if len(positional) == 0 || helmLocalVerbs[positional[0]] {
+				continue
+			}
+			if kubeContext != "" && !hasFlag(segment.words, "--kube-context") {
+				flags = append(flags, "--kube-context="+kubeContext)
+			}
+			if namespace != "" && !hasFlag(segment.words, "-n", "--namespace", "-A", "--all-namespaces") {
+				flags = append(flags, "--namespace="+namespace)
+			}
+		}
+		if len(flags) == 0 {
+			continue
+		}
+
+		command = fmt.Sprintf("%s %s%s", command[:segment.insert], strings.Join(flags, " "), command[segment.insert:])
+	}
+	return command
+}
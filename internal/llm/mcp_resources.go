@@ -0,0 +1,155 @@
+package llm
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8x/internal/mcp"
+)
+
+// ReadMCPResourceToolName is the name of the generic tool that lets the
+// agent read resources served by MCP servers
+const ReadMCPResourceToolName = "read_mcp_resource"
+
+// maxListedResources caps how many resources are listed in the
+// read_mcp_resource tool description
+const maxListedResources = 50
+
+// maxResourceBytes caps the text of a resource added to the conversation,
+// like kube.MaxOutputBytes does for queries
+const maxResourceBytes = 16 * 1024
+
+// ListMCPResources returns the resources of all connected MCP servers
+func (mtm *MCPToolManager) ListMCPResources(ctx context.Context) map[string][]mcp.Resource {
+	if !mtm.config.MCP.Enabled {
+		return nil
+	}
+	return mtm.mcpManager.ListResources(ctx)
+}
+
+// ReadMCPResource reads a resource from an MCP server and returns its text,
+// truncated to maxResourceBytes
+func (mtm *MCPToolManager) ReadMCPResource(ctx context.Context, serverName, uri string) (string, error) {
+	contents, err := mtm.mcpManager.ReadResource(ctx, serverName, uri)
+	if err != nil {
+		return "", fmt.Errorf("failed to read MCP resource: %w", err)
+	}
+
+	var parts []string
+	for _, content := range contents {
+		if content.Blob != "" {
+			size := base64.StdEncoding.DecodedLen(len(content.Blob))
+			parts = append(parts, fmt.Sprintf("[binary resource %s (%s), %d bytes]", content.URI, content.MIMEType, size))
+			continue
+		}
+		parts = append(parts, content.Text)
+	}
+
+	return truncateResource(strings.Join(parts, "\n")), nil
+}
+
+// truncateResource caps text at maxResourceBytes, noting how much was left out
+func truncateResource(text string) string {
+	if len(text) <= maxResourceBytes {
+		return text
+	}
+	return strings.ToValidUTF8(text[:maxResourceBytes], "") +
+		fmt.Sprintf("\n... resource truncated (%d more bytes)", len(text)-maxResourceBytes)
+}
+
+// ListMCPPrompts returns the prompt templates of all connected MCP servers
+func (mtm *MCPToolManager) ListMCPPrompts(ctx context.Context) map[string][]mcp.Prompt {
+	if !mtm.config.MCP.Enabled {
+		return nil
+	}
+	return mtm.mcpManager.ListPrompts(ctx)
+}
+
+// GetMCPPrompt renders a prompt template from an MCP server
+func (mtm *MCPToolManager) GetMCPPrompt(ctx context.Context, serverName, name string, args map[string]string) (*mcp.PromptResult, error) {
+	prompt, err := mtm.mcpManager.GetPrompt(ctx, serverName, name, args)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get MCP prompt: %w", err)
+	}
+	return prompt, nil
+}
+
+// getResourceTool returns the read_mcp_resource tool, or false when no
+// connected server offers resources
+func (mtm *MCPToolManager) getResourceTool(ctx context.Context) (Tool, bool) {
+	resources := mtm.mcpManager.ListResources(ctx)
+	if len(resources) == 0 {
+		return Tool{}, false
+	}
+
+	servers := make([]string, 0, len(resources))
+	for name := range resources {
+		servers = append(servers, name)
+	}
+	sort.Strings(servers)
+
+	var description strings.Builder
+	description.WriteString("Read a resource (such as a runbook or document) served by an MCP server. Available resources:")
+	listed := 0
+	for _, server := range servers {
+		for _, resource := range resources[server] {
+			if listed == maxListedResources {
+				break
+			}
+			listed++
+			description.WriteString(fmt.Sprintf("\n- server=%s uri=%s", server, resource.URI))
+			if resource.Name != "" {
+				description.WriteString(fmt.Sprintf(" (%s)", resource.Name))
+			}
+			if resource.Description != "" {
+				description.WriteString(": " + resource.Description)
+			}
+		}
+	}
+
+	return Tool{
+		Type: "function",
+		Function: ToolFunction{
+			Name:        ReadMCPResourceToolName,
+			Description: description.String(),
+			Parameters: ToolParameters{
+				Type: "object",
+				Properties: map[string]ToolParameterSpec{
+					"server": {
+						Type:        "string",
+						Description: "Name of the MCP server that serves the resource",
+						Enum:        servers,
+					},
+					"uri": {
+						Type:        "string",
+						Description: "URI of the resource to read",
+					},
+				},
+				Required: []string{"server", "uri"},
+			},
+		},
+		Handler: func(args string) (string, error) {
+			return mtm.executeReadResource(context.Background(), args)
+		},
+	}, true
+}
+
+// executeReadResource handles a read_mcp_resource tool call
+func (mtm *MCPToolManager) executeReadResource(ctx context.Context, arguments string) (string, error) {
+	var args struct {
+		Server string `json:"server"`
+		URI    string `json:"uri"`
+	}
+	if err := json.Unmarshal([]byte(arguments), &args); err != nil {
+		return "", fmt.Errorf("failed to parse tool arguments: %w", err)
+	}
+	if args.Server == "" || args.URI == "" {
+		return "", fmt.Errorf("both server and uri are required")
+	}
+
+	return mtm.ReadMCPResource(ctx, args.Server, args.URI)
+}
@@ -0,0 +1,19 @@
+package llm
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTruncateResource(t *testing.T) {
+	short := "# Runbook\nRestart the api."
+	if got := truncateResource(short); got != short {
+		t.Errorf("truncateResource() changed a short resource: %q", got)
+	}
+
+	long := strings.Repeat("a", maxResourceBytes+100)
+	got := truncateResource(long)
+	if !strings.HasPrefix(got, strings.Repeat("a", maxResourceBytes)+"\n") || !strings.HasSuffix(got, "resource truncated (100 more bytes)") {
+		t.Errorf("truncateResource() = ...%q", got[maxResourceBytes-10:])
+	}
+}
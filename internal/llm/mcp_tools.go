@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"log"
 	"strings"
+	"sync"
 
 	"k8x/internal/config"
 	"k8x/internal/mcp"
@@ -13,11 +14,17 @@ import (
 	mcpTypes "github.com/mark3labs/mcp-go/mcp"
 )
 
-// MCPToolManager extends ToolManager with MCP server integration
+// MCPToolManager extends ToolManager with MCP server integration. MCP tools
+// are kept in the tool registry alongside the built-in tools and follow the
+// servers as they connect, reconnect and change their tool lists.
 type MCPToolManager struct {
 	*ToolManager
 	mcpManager *mcp.Manager
 	config     *config.Config
+
+	filterMu sync.RWMutex
+	// toolFilter restricts the tools exposed in this session, nil exposes all
+	toolFilter ToolFilter
 }
 
 // NewMCPToolManager creates a new MCP-aware tool manager
@@ -37,17 +44,21 @@ func NewMCPToolManager(workDir string, cfg *config.Config) (*MCPToolManager, err
 	}, nil
 }
 
-// ConnectMCPServers connects to all configured MCP servers
-func (mtm *MCPToolManager) ConnectMCPServers(ctx context.Context) error {
+// ConnectMCPServers connects to all configured MCP servers and starts
+// background health checks. Servers are connected independently; the
+// returned map holds the error of each server that failed to connect.
+func (mtm *MCPToolManager) ConnectMCPServers(ctx context.Context) map[string]error {
 	if !mtm.config.MCP.Enabled {
 		return nil
 	}
 
-	if err := mtm.mcpManager.ConnectAll(ctx); err != nil {
-		log.Printf("[DEBUG] Error connecting to MCP servers: %v", err)
-		return err
+	failures := mtm.mcpManager.ConnectAll(ctx)
+	for name, err := range failures {
+		log.Printf("[DEBUG] Error connecting to MCP server '%s': %v", name, err)
 	}
-	return nil
+
+	mtm.mcpManager.StartHealthChecks()
+	return failures
 }
 
 // DisconnectMCPServers disconnects from all MCP servers
@@ -55,42 +66,61 @@ func (mtm *MCPToolManager) DisconnectMCPServers() error {
 	return mtm.mcpManager.DisconnectAll()
 }
 
-// GetAllTools returns all available tools including MCP tools
+// GetAllTools returns the tools exposed in this session, including MCP
+// tools. The registry is synchronized with the connected MCP servers first;
+// MCP tool lists are cached by the MCP manager and refreshed when a server
+// reports changes or reconnects, so this is cheap to call before every LLM
+// request.
 func (mtm *MCPToolManager) GetAllTools(ctx context.Context) ([]Tool, error) {
-	// Start with base shell tools
-	tools := mtm.GetTools()
-
-	// Add MCP tools if enabled
 	if mtm.config.MCP.Enabled {
-		mcpTools, err := mtm.getMCPTools(ctx)
-		if err != nil {
+		if err := mtm.syncMCPTools(ctx); err != nil {
 			return nil, fmt.Errorf("failed to get MCP tools: %w", err)
 		}
-		for _, tool := range mcpTools {
-			log.Printf("[DEBUG] MCP tool: %s - %s", tool.Function.Name, tool.Function.Description)
-		}
-		tools = append(tools, mcpTools...)
 	}
 
-	return tools, nil
+	mtm.filterMu.RLock()
+	filter := mtm.toolFilter
+	mtm.filterMu.RUnlock()
+
+	return mtm.Registry().Tools(filter), nil
+}
+
+// SetToolFilter restricts the tools exposed to the LLM, e.g. for a single
+// goal. A nil filter exposes all tools again.
+func (mtm *MCPToolManager) SetToolFilter(filter ToolFilter) {
+	mtm.filterMu.Lock()
+	defer mtm.filterMu.Unlock()
+	mtm.toolFilter = filter
 }
 
-// getMCPTools converts MCP tools to LLM tools
-func (mtm *MCPToolManager) getMCPTools(ctx context.Context) ([]Tool, error) {
+// syncMCPTools registers the tools of connected MCP servers and unregisters
+// tools of servers that disconnected or no longer provide them
+func (mtm *MCPToolManager) syncMCPTools(ctx context.Context) error {
 	allMCPTools, err := mtm.mcpManager.GetAllTools(ctx)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	var tools []Tool
+	registry := mtm.Registry()
+	current := make(map[ToolOrigin]bool)
 	for serverName, serverTools := range allMCPTools {
 		for _, mcpTool := range serverTools {
-			tool := mtm.convertMCPTool(serverName, mcpTool)
-			tools = append(tools, tool)
+			origin := ToolOrigin{Source: ToolSourceMCP, Server: serverName, Name: mcpTool.Name}
+			current[origin] = true
+			registry.Register(mtm.convertMCPTool(serverName, mcpTool), origin)
 		}
 	}
 
-	return tools, nil
+	resourceOrigin := ToolOrigin{Source: ToolSourceMCP, Name: ReadMCPResourceToolName}
+	if resourceTool, ok := mtm.getResourceTool(ctx); ok {
+		current[resourceOrigin] = true
+		registry.Register(resourceTool, resourceOrigin)
+	}
+
+	registry.UnregisterMatching(func(origin ToolOrigin) bool {
+		return origin.Source == ToolSourceMCP && !current[origin]
+	})
+	return nil
 }
 
 // convertMCPTool converts an MCP tool to an LLM tool
@@ -101,8 +131,6 @@ func (mtm *MCPToolManager) convertMCPTool(serverName string, mcpTool mcp.Tool) T
 	// Convert MCP input schema to LLM tool parameters
 	parameters := mtm.convertInputSchema(mcpTool.InputSchema)
 
-	log.Printf("Loaded MCP tool: %s (server: %s)", toolName, serverName) // Debug log
-
 	return Tool{
 		Type: "function",
 		Function: ToolFunction{
@@ -160,6 +188,35 @@ func (mtm *MCPToolManager) executeMCPTool(ctx context.Context, serverName, toolN
 		return "", fmt.Errorf("failed to parse tool arguments: %w", err)
 	}
 
+	// MCP tools bypass the shell executor's read-only checks, so tools that
+	// may modify their environment always need the user's approval.
+	// Patterns match the name the LLM sees, which the registry may have
+	// sanitized or suffixed.
+	registeredName, ok := mtm.Registry().NameOf(ToolOrigin{Source: ToolSourceMCP, Server: serverName, Name: toolName})
+	if !ok {
+		registeredName = SanitizeToolName(fmt.Sprintf("mcp_%s_%s", serverName, toolName))
+	}
+	request := ApprovalRequest{
+		Tool:        registeredName,
+		Subject:     registeredName,
+		Display:     fmt.Sprintf("MCP tool %s (server %s) with args: %s", toolName, serverName, arguments),
+		Editable:    arguments,
+		Destructive: mtm.mcpManager.RequiresConfirmation(serverName, toolName),
+	}
+	if request.Destructive {
+		request.Display = "⚠️  destructive " + request.Display
+	}
+	approved, err := mtm.approve(request)
+	if err != nil {
+		return "", err
+	}
+	if approved != arguments {
+		args = nil
+		if err := json.Unmarshal([]byte(approved), &args); err != nil {
+			return "", fmt.Errorf("failed to parse edited tool arguments: %w", err)
+		}
+	}
+
 	// Create MCP tool call
 	toolCall := mcp.ToolCall{
 		Name:      toolName,
@@ -200,23 +257,26 @@ func (mtm *MCPToolManager) formatMCPResult(result *mcp.ToolResult) string {
 	return strings.Join(parts, "\n")
 }
 
-// ExecuteTool executes a tool by name, handling both shell and MCP tools
+// ExecuteTool executes a tool by its registered name, routing MCP tools to
+// their server and everything else to the embedded ToolManager
 func (mtm *MCPToolManager) ExecuteTool(name, arguments string) (string, error) {
-	// Check if it's an MCP tool
-	if strings.HasPrefix(name, "mcp_") {
-		// Extract server name and tool name
-		parts := strings.SplitN(name, "_", 3)
-		if len(parts) != 3 {
-			return "", fmt.Errorf("invalid MCP tool name format: %s", name)
-		}
+	tool, origin, exists := mtm.Registry().Lookup(name)
+	if !exists {
+		return "", fmt.Errorf("tool '%s' not found", name)
+	}
 
-		serverName := parts[1]
-		toolName := parts[2]
+	mtm.filterMu.RLock()
+	filter := mtm.toolFilter
+	mtm.filterMu.RUnlock()
+	if filter != nil && !filter(name, origin) {
+		return "", fmt.Errorf("tool '%s' is not available in this session", name)
+	}
 
-		return mtm.executeMCPTool(context.Background(), serverName, toolName, arguments)
+	// MCP tool handlers ask for confirmation themselves
+	if origin.Source == ToolSourceMCP {
+		return tool.Handler(arguments)
 	}
 
-	// Fall back to shell tools using the embedded ToolManager
 	return mtm.ToolManager.ExecuteTool(name, arguments)
 }
 
@@ -235,3 +295,9 @@ func (mtm *MCPToolManager) GetMCPServerStatus() map[string]bool {
 
 	return status
 }
+
+// GetMCPServerStates returns detailed connection state of all MCP servers,
+// including reconnect progress and the last connection error
+func (mtm *MCPToolManager) GetMCPServerStates() map[string]mcp.ServerStatus {
+	return mtm.mcpManager.Status()
+}
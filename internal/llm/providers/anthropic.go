@@ -55,6 +55,11 @@ func (p *AnthropicProvider) Name() string {
 	return "anthropic"
 }
 
+// Model returns the model the provider uses
+func (p *AnthropicProvider) Model() string {
+	return p.model
+}
+
 // IsConfigured returns true if the provider has the API key and client
 func (p *AnthropicProvider) IsConfigured() bool {
 	return p.apiKey != "" && p.client != nil
@@ -67,6 +67,11 @@ func (p *GoogleProvider) Name() string {
 	return "google"
 }
 
+// Model returns the model the provider uses.
+func (p *GoogleProvider) Model() string {
+	return p.model
+}
+
 // IsConfigured returns true if the underlying GenAI client exists.
 func (p *GoogleProvider) IsConfigured() bool {
 	return p.client != nil
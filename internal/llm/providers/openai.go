@@ -42,6 +42,11 @@ func (p *OpenAIProvider) Name() string {
 	return "openai"
 }
 
+// Model returns the model the provider uses
+func (p *OpenAIProvider) Model() string {
+	return p.model
+}
+
 // IsConfigured returns true if the provider has a valid model
 func (p *OpenAIProvider) IsConfigured() bool {
 	return p.model != ""
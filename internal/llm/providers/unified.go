@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"os"
+	"slices"
 
 	"k8x/internal/llm"
 )
@@ -27,7 +29,35 @@ type Credentials struct {
 	} `yaml:"google"`
 }
 
-// UnifiedProvider wraps a concrete llm.Provider (OpenAI or Anthropic) behind one interface.
+// ProviderNames lists the supported providers
+var ProviderNames = []string{"openai", "anthropic", "google"}
+
+// SuggestedModels lists well-known models of each provider, its default
+// first. Providers accept other models of theirs as well.
+var SuggestedModels = map[string][]string{
+	"openai":    {"o3-mini", "o4-mini", "o3", "gpt-4.1", "gpt-4o"},
+	"anthropic": {"claude-sonnet-4-0", "claude-opus-4-0", "claude-3-7-sonnet-latest", "claude-3-5-haiku-latest"},
+	"google":    {"gemini-2.5-flash", "gemini-2.5-pro"},
+}
+
+// IsProviderConfigured returns true if the named provider has credentials,
+// in creds or in the environment variables its client falls back to.
+func (c Credentials) IsProviderConfigured(name string) bool {
+	switch name {
+	case "openai":
+		return c.OpenAI.APIKey != "" || os.Getenv("OPENAI_API_KEY") != ""
+	case "anthropic":
+		return c.Anthropic.APIKey != ""
+	case "google":
+		return c.Google.APIKey != "" || c.Google.ApplicationCredentials != "" ||
+			os.Getenv("GEMINI_API_KEY") != "" || os.Getenv("GOOGLE_APPLICATION_CREDENTIALS") != ""
+	default:
+		return false
+	}
+}
+
+// UnifiedProvider wraps a concrete llm.Provider (OpenAI, Anthropic or Google) behind one
+// interface. The wrapped provider can be switched mid-conversation.
 type UnifiedProvider struct {
 	provider llm.Provider
 }
@@ -35,28 +65,63 @@ type UnifiedProvider struct {
 // NewUnifiedProvider instantiates a UnifiedProvider based on creds.SelectedProvider.
 // It returns an error if the provider is unsupported or not configured.
 func NewUnifiedProvider(creds Credentials) (*UnifiedProvider, error) {
+	return NewUnifiedProviderWithModel(creds, "")
+}
+
+// NewUnifiedProviderWithModel is like NewUnifiedProvider, using model, or
+// the provider's default model if it's empty
+func NewUnifiedProviderWithModel(creds Credentials, model string) (*UnifiedProvider, error) {
+	provider, err := newProvider(creds, creds.SelectedProvider, model)
+	if err != nil {
+		return nil, err
+	}
+	return &UnifiedProvider{provider: provider}, nil
+}
+
+// newProvider creates the named provider using model, or the provider's
+// default model if it's empty
+func newProvider(creds Credentials, name, model string) (llm.Provider, error) {
 	var provider llm.Provider
 	var err error
 
-	switch creds.SelectedProvider {
+	switch name {
 	case "openai":
-		provider = NewOpenAIProvider(creds.OpenAI.APIKey, "", "")
+		provider = NewOpenAIProvider(creds.OpenAI.APIKey, "", model)
 	case "anthropic":
-		provider = NewAnthropicProvider(creds.Anthropic.APIKey, "", "")
+		provider = NewAnthropicProvider(creds.Anthropic.APIKey, "", model)
 	case "google":
-		provider, err = NewGoogleProvider(creds.Google.APIKey, creds.Google.ApplicationCredentials, "")
+		provider, err = NewGoogleProvider(creds.Google.APIKey, creds.Google.ApplicationCredentials, model)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create Google provider: %w", err)
 		}
 	default:
-		return nil, fmt.Errorf("unsupported provider: %s", creds.SelectedProvider)
+		return nil, fmt.Errorf("unsupported provider: %s", name)
 	}
 
 	if !provider.IsConfigured() {
 		return nil, fmt.Errorf("%s provider not configured", provider.Name())
 	}
 
-	return &UnifiedProvider{provider: provider}, nil
+	return provider, nil
+}
+
+// Switch replaces the active provider with the named one, using model or
+// the provider's default model if it's empty. The active provider is kept
+// if the new one can't be created. Conversations carry over, since tool
+// calls are re-encoded for the active provider on every request.
+func (u *UnifiedProvider) Switch(creds Credentials, name, model string) error {
+	if !slices.Contains(ProviderNames, name) {
+		return fmt.Errorf("unsupported provider: %s", name)
+	}
+	if !creds.IsProviderConfigured(name) {
+		return fmt.Errorf("%s provider not configured", name)
+	}
+	provider, err := newProvider(creds, name, model)
+	if err != nil {
+		return err
+	}
+	u.provider = provider
+	return nil
 }
 
 // Name returns the active provider's name.
@@ -64,6 +129,22 @@ func (u *UnifiedProvider) Name() string {
 	return u.provider.Name()
 }
 
+// Model returns the active provider's model, or "" if it doesn't tell.
+func (u *UnifiedProvider) Model() string {
+	if p, ok := u.provider.(interface{ Model() string }); ok {
+		return p.Model()
+	}
+	return ""
+}
+
+// Label names the active provider and model, e.g. "openai/o3-mini".
+func (u *UnifiedProvider) Label() string {
+	if model := u.Model(); model != "" {
+		return u.Name() + "/" + model
+	}
+	return u.Name()
+}
+
 // IsConfigured returns true if the underlying provider is properly configured.
 func (u *UnifiedProvider) IsConfigured() bool {
 	return u.provider.IsConfigured()
@@ -91,6 +172,9 @@ func (u *UnifiedProvider) ChatWithTools(ctx context.Context, messages []llm.Mess
 		return nil, fmt.Errorf("%s provider not configured", u.Name())
 	}
 
+	// The conversation may hold tool calls of another provider
+	messages = llm.NormalizeToolCalls(messages)
+
 	// Check if the provider supports tools
 	switch p := u.provider.(type) {
 	case *OpenAIProvider:
@@ -0,0 +1,227 @@
+package llm
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// MaxToolNameLength is the longest tool name accepted by all supported
+// providers
+const MaxToolNameLength = 64
+
+// Tool sources recorded in ToolOrigin
+const (
+	ToolSourceBuiltin = "builtin"
+	ToolSourceMCP     = "mcp"
+)
+
+// ToolOrigin records where a registered tool comes from, so that calls can
+// be routed without parsing the tool name
+type ToolOrigin struct {
+	// Source is ToolSourceBuiltin, ToolSourceMCP or a plugin name
+	Source string
+	// Server is the MCP server providing the tool
+	Server string
+	// Name is the tool's original, unsanitized name
+	Name string
+}
+
+// ToolFilter selects the tools exposed to the LLM. A nil filter selects all tools.
+type ToolFilter func(name string, origin ToolOrigin) bool
+
+// registeredTool is a tool together with its origin
+type registeredTool struct {
+	tool   Tool
+	origin ToolOrigin
+}
+
+// ToolRegistry maps provider-safe tool names to tools and their origin.
+// Tools can be registered and unregistered at runtime, e.g. when MCP
+// servers reconnect or change their tool lists.
+type ToolRegistry struct {
+	mu    sync.RWMutex
+	tools map[string]registeredTool
+}
+
+// NewToolRegistry creates an empty tool registry
+func NewToolRegistry() *ToolRegistry {
+	return &ToolRegistry{
+		tools: make(map[string]registeredTool),
+	}
+}
+
+// Register adds a tool under a sanitized version of its function name and
+// returns the name it was registered under. If another origin already uses
+// that name, a short hash of the origin is appended to keep names unique.
+// Registering the same origin again replaces the existing tool.
+func (r *ToolRegistry) Register(tool Tool, origin ToolOrigin) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for name, existing := range r.tools {
+		if existing.origin == origin {
+			delete(r.tools, name)
+		}
+	}
+
+	name := SanitizeToolName(tool.Function.Name)
+	if _, taken := r.tools[name]; taken {
+		name = withSuffix(name, originHash(origin))
+	}
+
+	tool.Function.Name = name
+	r.tools[name] = registeredTool{tool: tool, origin: origin}
+	return name
+}
+
+// Unregister removes a tool by its registered name and reports whether it existed
+func (r *ToolRegistry) Unregister(name string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.tools[name]; !exists {
+		return false
+	}
+	delete(r.tools, name)
+	return true
+}
+
+// UnregisterMatching removes every tool whose origin matches and returns
+// how many were removed
+func (r *ToolRegistry) UnregisterMatching(match func(origin ToolOrigin) bool) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	removed := 0
+	for name, registered := range r.tools {
+		if match(registered.origin) {
+			delete(r.tools, name)
+			removed++
+		}
+	}
+	return removed
+}
+
+// Lookup returns a tool and its origin by registered name
+func (r *ToolRegistry) Lookup(name string) (Tool, ToolOrigin, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	registered, exists := r.tools[name]
+	return registered.tool, registered.origin, exists
+}
+
+// NameOf returns the name a tool was registered under by its origin
+func (r *ToolRegistry) NameOf(origin ToolOrigin) (string, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for name, registered := range r.tools {
+		if registered.origin == origin {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// Origins returns the origin of every registered tool keyed by name
+func (r *ToolRegistry) Origins() map[string]ToolOrigin {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	origins := make(map[string]ToolOrigin, len(r.tools))
+	for name, registered := range r.tools {
+		origins[name] = registered.origin
+	}
+	return origins
+}
+
+// Tools returns the registered tools selected by filter, sorted by name
+func (r *ToolRegistry) Tools(filter ToolFilter) []Tool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.tools))
+	for name, registered := range r.tools {
+		if filter == nil || filter(name, registered.origin) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	tools := make([]Tool, 0, len(names))
+	for _, name := range names {
+		tools = append(tools, r.tools[name].tool)
+	}
+	return tools
+}
+
+// MatchToolNames returns a filter selecting tools whose registered name,
+// original name or MCP server matches one of the glob patterns
+func MatchToolNames(patterns ...string) ToolFilter {
+	return func(name string, origin ToolOrigin) bool {
+		for _, pattern := range patterns {
+			for _, candidate := range []string{name, origin.Name, origin.Server} {
+				if candidate == "" {
+					continue
+				}
+				if matched, _ := path.Match(pattern, candidate); matched {
+					return true
+				}
+			}
+		}
+		return false
+	}
+}
+
+// SanitizeToolName turns name into a tool name accepted by all providers:
+// letters, digits, underscores and dashes, starting with a letter or
+// underscore and at most MaxToolNameLength characters. Truncated names keep
+// a hash of the full name so distinct long names stay distinct.
+func SanitizeToolName(name string) string {
+	var b strings.Builder
+	for _, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_', r == '-':
+			b.WriteRune(r)
+		default:
+			b.WriteRune('_')
+		}
+	}
+
+	sanitized := b.String()
+	if sanitized == "" || !(sanitized[0] == '_' || (sanitized[0]|0x20 >= 'a' && sanitized[0]|0x20 <= 'z')) {
+		sanitized = "_" + sanitized
+	}
+
+	if len(sanitized) > MaxToolNameLength {
+		sanitized = withSuffix(sanitized, shortHash(name))
+	}
+	return sanitized
+}
+
+// withSuffix appends _suffix to name, truncating name to stay within
+// MaxToolNameLength
+func withSuffix(name, suffix string) string {
+	maxBase := MaxToolNameLength - len(suffix) - 1
+	if len(name) > maxBase {
+		name = name[:maxBase]
+	}
+	return name + "_" + suffix
+}
+
+// originHash returns a short hash identifying a tool origin
+func originHash(origin ToolOrigin) string {
+	return shortHash(fmt.Sprintf("%s\x00%s\x00%s", origin.Source, origin.Server, origin.Name))
+}
+
+// shortHash returns the first 8 hex characters of the SHA-256 of s
+func shortHash(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])[:8]
+}
@@ -0,0 +1,127 @@
+package llm
+
+import (
+	"strings"
+	"testing"
+)
+
+func testTool(name string) Tool {
+	return Tool{
+		Type:     "function",
+		Function: ToolFunction{Name: name},
+		Handler: func(args string) (string, error) {
+			return name, nil
+		},
+	}
+}
+
+func TestSanitizeToolName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"execute_shell_command", "execute_shell_command"},
+		{"mcp_github_search.repos", "mcp_github_search_repos"},
+		{"mcp_fs_read file", "mcp_fs_read_file"},
+		{"1password_lookup", "_1password_lookup"},
+		{"", "_"},
+	}
+
+	for _, tt := range tests {
+		if got := SanitizeToolName(tt.name); got != tt.want {
+			t.Errorf("SanitizeToolName(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	long := "mcp_" + strings.Repeat("a", 100)
+	other := "mcp_" + strings.Repeat("a", 99) + "b"
+	if got := SanitizeToolName(long); len(got) > MaxToolNameLength {
+		t.Errorf("SanitizeToolName() length = %d, want <= %d", len(got), MaxToolNameLength)
+	}
+	if SanitizeToolName(long) == SanitizeToolName(other) {
+		t.Error("Distinct long names should stay distinct after truncation")
+	}
+}
+
+func TestToolRegistryCollisions(t *testing.T) {
+	registry := NewToolRegistry()
+
+	// Both tools would be named mcp_my_server_get_pods
+	first := ToolOrigin{Source: ToolSourceMCP, Server: "my_server", Name: "get_pods"}
+	second := ToolOrigin{Source: ToolSourceMCP, Server: "my", Name: "server_get_pods"}
+
+	firstName := registry.Register(testTool("mcp_my_server_get_pods"), first)
+	secondName := registry.Register(testTool("mcp_my_server_get_pods"), second)
+	if firstName == secondName {
+		t.Fatalf("colliding tools registered under the same name %q", firstName)
+	}
+
+	for name, want := range map[string]ToolOrigin{firstName: first, secondName: second} {
+		tool, origin, ok := registry.Lookup(name)
+		if !ok {
+			t.Fatalf("Lookup(%q) found nothing", name)
+		}
+		if origin != want {
+			t.Errorf("Lookup(%q) origin = %+v, want %+v", name, origin, want)
+		}
+		if tool.Function.Name != name {
+			t.Errorf("tool.Function.Name = %q, want %q", tool.Function.Name, name)
+		}
+	}
+
+	if name, ok := registry.NameOf(second); !ok || name != secondName {
+		t.Errorf("NameOf() = %q, %v, want %q", name, ok, secondName)
+	}
+
+	// Registering an origin again keeps its name
+	if name := registry.Register(testTool("mcp_my_server_get_pods"), second); name != secondName {
+		t.Errorf("re-registered name = %q, want %q", name, secondName)
+	}
+	if got := len(registry.Tools(nil)); got != 2 {
+		t.Errorf("registry has %d tools, want 2", got)
+	}
+}
+
+func TestToolRegistryUnregisterAndFilter(t *testing.T) {
+	registry := NewToolRegistry()
+	registry.Register(testTool("execute_shell_command"), ToolOrigin{Source: ToolSourceBuiltin, Name: "execute_shell_command"})
+	registry.Register(testTool("mcp_github_search"), ToolOrigin{Source: ToolSourceMCP, Server: "github", Name: "search"})
+	registry.Register(testTool("mcp_github_get_issue"), ToolOrigin{Source: ToolSourceMCP, Server: "github", Name: "get_issue"})
+	registry.Register(testTool("mcp_fs_read_file"), ToolOrigin{Source: ToolSourceMCP, Server: "fs", Name: "read_file"})
+
+	tests := []struct {
+		name     string
+		patterns []string
+		want     int
+	}{
+		{"by server", []string{"github"}, 2},
+		{"by original tool name", []string{"read_*"}, 1},
+		{"by registered name", []string{"execute_*", "mcp_fs_*"}, 2},
+		{"no match", []string{"nothing"}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := len(registry.Tools(MatchToolNames(tt.patterns...))); got != tt.want {
+				t.Errorf("Tools(MatchToolNames(%v)) returned %d tools, want %d", tt.patterns, got, tt.want)
+			}
+		})
+	}
+
+	removed := registry.UnregisterMatching(func(origin ToolOrigin) bool {
+		return origin.Server == "github"
+	})
+	if removed != 2 {
+		t.Errorf("UnregisterMatching() removed %d tools, want 2", removed)
+	}
+	if !registry.Unregister("mcp_fs_read_file") {
+		t.Error("Unregister() should report an existing tool")
+	}
+	if registry.Unregister("mcp_fs_read_file") {
+		t.Error("Unregister() should report a missing tool")
+	}
+
+	tools := registry.Tools(nil)
+	if len(tools) != 1 || tools[0].Function.Name != "execute_shell_command" {
+		t.Errorf("Tools() = %v, want only execute_shell_command", tools)
+	}
+}
@@ -0,0 +1,98 @@
+package llm
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// toolCallIDPattern matches tool call IDs every provider accepts: Anthropic
+// restricts the characters, OpenAI the length, and Gemini may send none
+var toolCallIDPattern = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,40}$`)
+
+// MissingToolResult is the result given to tool calls the conversation has
+// no result for
+const MissingToolResult = "No result was recorded for this tool call."
+
+// NormalizeToolCalls re-encodes the tool calls in messages so that any
+// provider accepts them, e.g. when the conversation continues with another
+// provider than the one that made the calls:
+//   - IDs that are empty, invalid or duplicated get new ones, and their
+//     results follow
+//   - calls without a result get MissingToolResult
+//   - results of unknown calls are dropped
+//
+// messages isn't modified.
+func NormalizeToolCalls(messages []Message) []Message {
+	type pendingCall struct {
+		original, id string
+	}
+
+	normalized := make([]Message, 0, len(messages))
+	used := make(map[string]bool)
+	next := 0
+	newID := func() string {
+		for {
+			next++
+			id := fmt.Sprintf("call_%d", next)
+			if !used[id] {
+				return id
+			}
+		}
+	}
+
+	// pending holds the calls of the last assistant message without results
+	var pending []pendingCall
+	resolvePending := func() {
+		for _, call := range pending {
+			normalized = append(normalized, Message{Role: "tool", ToolCallID: call.id, Content: MissingToolResult})
+		}
+		pending = nil
+	}
+
+	for _, msg := range messages {
+		if msg.Role == "tool" {
+			// Results match calls by their original ID, in order, since
+			// calls without IDs all share the empty one
+			match := -1
+			for i, call := range pending {
+				if call.original == msg.ToolCallID {
+					match = i
+					break
+				}
+			}
+			if match == -1 {
+				continue
+			}
+			msg.ToolCallID = pending[match].id
+			pending = append(pending[:match], pending[match+1:]...)
+			normalized = append(normalized, msg)
+			continue
+		}
+
+		resolvePending()
+		if msg.Role == "assistant" && len(msg.ToolCalls) > 0 {
+			calls := make([]ToolCall, len(msg.ToolCalls))
+			for i, call := range msg.ToolCalls {
+				id := call.ID
+				if !toolCallIDPattern.MatchString(id) || used[id] {
+					id = newID()
+				}
+				used[id] = true
+				pending = append(pending, pendingCall{original: call.ID, id: id})
+
+				call.ID = id
+				if call.Type == "" {
+					call.Type = "function"
+				}
+				if call.Function.Arguments == "" {
+					call.Function.Arguments = "{}"
+				}
+				calls[i] = call
+			}
+			msg.ToolCalls = calls
+		}
+		normalized = append(normalized, msg)
+	}
+	resolvePending()
+	return normalized
+}
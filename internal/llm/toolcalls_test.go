@@ -0,0 +1,110 @@
+package llm
+
+import (
+	"reflect"
+	"testing"
+)
+
+func toolCall(id, name string) ToolCall {
+	call := ToolCall{ID: id, Type: "function"}
+	call.Function.Name = name
+	call.Function.Arguments = "{}"
+	return call
+}
+
+func TestNormalizeToolCalls(t *testing.T) {
+	tests := []struct {
+		name     string
+		messages []Message
+		want     []Message
+	}{
+		{
+			name: "valid calls are kept",
+			messages: []Message{
+				{Role: "user", Content: "why is web failing?"},
+				{Role: "assistant", ToolCalls: []ToolCall{toolCall("toolu_01A", "kubectl")}},
+				{Role: "tool", ToolCallID: "toolu_01A", Content: "CrashLoopBackOff"},
+			},
+			want: []Message{
+				{Role: "user", Content: "why is web failing?"},
+				{Role: "assistant", ToolCalls: []ToolCall{toolCall("toolu_01A", "kubectl")}},
+				{Role: "tool", ToolCallID: "toolu_01A", Content: "CrashLoopBackOff"},
+			},
+		},
+		{
+			name: "empty IDs get new ones, matched in order",
+			messages: []Message{
+				{Role: "assistant", ToolCalls: []ToolCall{toolCall("", "kubectl"), toolCall("", "shell")}},
+				{Role: "tool", ToolCallID: "", Content: "pods"},
+				{Role: "tool", ToolCallID: "", Content: "logs"},
+			},
+			want: []Message{
+				{Role: "assistant", ToolCalls: []ToolCall{toolCall("call_1", "kubectl"), toolCall("call_2", "shell")}},
+				{Role: "tool", ToolCallID: "call_1", Content: "pods"},
+				{Role: "tool", ToolCallID: "call_2", Content: "logs"},
+			},
+		},
+		{
+			name: "invalid and duplicated IDs are replaced",
+			messages: []Message{
+				{Role: "assistant", ToolCalls: []ToolCall{toolCall("call:1", "kubectl")}},
+				{Role: "tool", ToolCallID: "call:1", Content: "pods"},
+				{Role: "assistant", ToolCalls: []ToolCall{toolCall("call_1", "kubectl")}},
+				{Role: "tool", ToolCallID: "call_1", Content: "events"},
+				{Role: "assistant", ToolCalls: []ToolCall{toolCall("call_1", "kubectl")}},
+				{Role: "tool", ToolCallID: "call_1", Content: "logs"},
+			},
+			want: []Message{
+				{Role: "assistant", ToolCalls: []ToolCall{toolCall("call_1", "kubectl")}},
+				{Role: "tool", ToolCallID: "call_1", Content: "pods"},
+				{Role: "assistant", ToolCalls: []ToolCall{toolCall("call_2", "kubectl")}},
+				{Role: "tool", ToolCallID: "call_2", Content: "events"},
+				{Role: "assistant", ToolCalls: []ToolCall{toolCall("call_3", "kubectl")}},
+				{Role: "tool", ToolCallID: "call_3", Content: "logs"},
+			},
+		},
+		{
+			name: "calls without results get a placeholder",
+			messages: []Message{
+				{Role: "assistant", ToolCalls: []ToolCall{toolCall("a", "kubectl"), toolCall("b", "kubectl")}},
+				{Role: "tool", ToolCallID: "b", Content: "pods"},
+				{Role: "user", Content: "go on"},
+			},
+			want: []Message{
+				{Role: "assistant", ToolCalls: []ToolCall{toolCall("a", "kubectl"), toolCall("b", "kubectl")}},
+				{Role: "tool", ToolCallID: "b", Content: "pods"},
+				{Role: "tool", ToolCallID: "a", Content: MissingToolResult},
+				{Role: "user", Content: "go on"},
+			},
+		},
+		{
+			name: "results of unknown calls are dropped",
+			messages: []Message{
+				{Role: "user", Content: "hi"},
+				{Role: "tool", ToolCallID: "gone", Content: "pods"},
+			},
+			want: []Message{
+				{Role: "user", Content: "hi"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NormalizeToolCalls(tt.messages); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NormalizeToolCalls() =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeToolCallsKeepsInput(t *testing.T) {
+	messages := []Message{
+		{Role: "assistant", ToolCalls: []ToolCall{toolCall("", "kubectl")}},
+		{Role: "tool", Content: "pods"},
+	}
+	NormalizeToolCalls(messages)
+	if messages[0].ToolCalls[0].ID != "" || messages[1].ToolCallID != "" {
+		t.Errorf("NormalizeToolCalls() modified its input: %+v", messages)
+	}
+}
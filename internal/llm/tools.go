@@ -1,13 +1,14 @@
 package llm
 
 import (
-	"bufio"
 	"context"
 	"encoding/json"
 	"fmt"
 	"os"
 	"os/exec"
+	"regexp"
 	"strings"
+	"sync"
 	"time"
 
 	"k8x/internal/config"
@@ -97,8 +98,23 @@ func (se *ShellExecutor) SetKubernetesConfig(k8sConfig *config.KubernetesConfig)
 	se.k8sConfig = k8sConfig
 }
 
-// Execute runs a shell command with safety checks
+// SessionContexts returns the kube-contexts the executor's session spans
+func (se *ShellExecutor) SessionContexts() []string {
+	if se.k8sConfig == nil {
+		return nil
+	}
+	return se.k8sConfig.SessionContexts()
+}
+
+// Execute runs a shell command with safety checks against the session's
+// default kube-context
 func (se *ShellExecutor) Execute(command string) (string, error) {
+	return se.ExecuteInContext(command, "")
+}
+
+// ExecuteInContext runs a shell command with safety checks against one of
+// the session's kube-contexts. An empty kubeContext selects the default one.
+func (se *ShellExecutor) ExecuteInContext(command, kubeContext string) (string, error) {
 	// Parse command to check if it's allowed
 	parts := strings.Fields(command)
 	if len(parts) == 0 {
@@ -120,24 +136,24 @@ func (se *ShellExecutor) Execute(command string) (string, error) {
 		return "", fmt.Errorf("command '%s' is not allowed for security reasons. Allowed commands: %v", baseCmd, se.allowedCommands)
 	}
 
+	if err := se.validateContext(kubeContext); err != nil {
+		return "", err
+	}
+
+	// In multi-cluster sessions, explicit context flags must stay within the session
+	if se.k8sConfig != nil && se.k8sConfig.MultiCluster() {
+		for _, match := range contextFlagPattern.FindAllStringSubmatch(command, -1) {
+			if err := se.validateContext(match[1]); err != nil {
+				return "", err
+			}
+		}
+	}
+
 	// Additional safety checks for kubectl
 	if baseCmd == "kubectl" {
 		if se.containsWriteOperations(command) {
 			return "", fmt.Errorf("kubectl write operations are not allowed in read-only mode. Command: %s", command)
 		}
-
-		// Apply Kubernetes configuration if available
-		if se.k8sConfig != nil {
-			// Add context flag if specified
-			if se.k8sConfig.Context != "" && !strings.Contains(command, "--context") {
-				command = fmt.Sprintf("%s --context=%s", command, se.k8sConfig.Context)
-			}
-
-			// Add namespace flag if specified and not already in command
-			if se.k8sConfig.Namespace != "" && !strings.Contains(command, "--namespace") && !strings.Contains(command, "-n ") {
-				command = fmt.Sprintf("%s --namespace=%s", command, se.k8sConfig.Namespace)
-			}
-		}
 	}
 
 	// Additional safety checks for helm
@@ -154,9 +170,13 @@ func (se *ShellExecutor) Execute(command string) (string, error) {
 		}
 	}
 
-	// Set up environment for kubectl if kubeconfig path is specified
+	// Apply Kubernetes configuration if available
+	command = se.applyKubernetesConfig(command, kubeContext)
+
+	// Set up environment for kubectl if kubeconfig path is specified. Any
+	// segment of a pipeline may run kubectl or helm, so it is always set.
 	env := os.Environ()
-	if se.k8sConfig != nil && se.k8sConfig.KubeConfigPath != "" && (baseCmd == "kubectl" || baseCmd == "helm" || baseCmd == "kustomize") {
+	if se.k8sConfig != nil && se.k8sConfig.KubeConfigPath != "" {
 		env = append(env, fmt.Sprintf("KUBECONFIG=%s", se.k8sConfig.KubeConfigPath))
 	}
 
@@ -180,6 +200,28 @@ func (se *ShellExecutor) Execute(command string) (string, error) {
 	return string(output), nil
 }
 
+// contextFlagPattern matches the kube-context selected by kubectl's
+// --context and helm's --kube-context flags
+var contextFlagPattern = regexp.MustCompile(`--(?:kube-)?context[= ]+([^\s;|&]+)`)
+
+// validateContext checks that a kube-context is one of the session's contexts
+func (se *ShellExecutor) validateContext(kubeContext string) error {
+	if kubeContext == "" {
+		return nil
+	}
+
+	contexts := se.SessionContexts()
+	for _, name := range contexts {
+		if name == kubeContext {
+			return nil
+		}
+	}
+	if len(contexts) == 0 {
+		return fmt.Errorf("kube-context %q is not part of this session, which uses the current kubeconfig context", kubeContext)
+	}
+	return fmt.Errorf("kube-context %q is not part of this session. Available contexts: %v", kubeContext, contexts)
+}
+
 // containsWriteOperations checks if a kubectl command contains write operations
 func (se *ShellExecutor) containsWriteOperations(command string) bool {
 	writeOps := []string{
@@ -250,25 +292,37 @@ func (se *ShellExecutor) containsKustomizeWriteOperations(command string) bool {
 
 // GetShellExecutionTool returns the shell execution tool definition
 func GetShellExecutionTool(executor *ShellExecutor) Tool {
+	properties := map[string]ToolParameterSpec{
+		"command": {
+			Type:        "string",
+			Description: "The shell command to execute. Must be a safe, read-only command like 'kubectl get pods' or 'kubectl describe service myservice'",
+		},
+	}
+
+	// Multi-cluster sessions select the cluster per call
+	if contexts := executor.SessionContexts(); len(contexts) > 1 {
+		properties["context"] = ToolParameterSpec{
+			Type:        "string",
+			Description: fmt.Sprintf("The kube-context (cluster) to run the command against (default: %s). kubectl and helm commands get the matching context flag.", contexts[0]),
+			Enum:        contexts,
+		}
+	}
+
 	return Tool{
 		Type: "function",
 		Function: ToolFunction{
 			Name:        "execute_shell_command",
 			Description: "Execute a safe, read-only shell command. Primarily used for kubectl get, describe, logs commands and other diagnostic operations.",
 			Parameters: ToolParameters{
-				Type: "object",
-				Properties: map[string]ToolParameterSpec{
-					"command": {
-						Type:        "string",
-						Description: "The shell command to execute. Must be a safe, read-only command like 'kubectl get pods' or 'kubectl describe service myservice'",
-					},
-				},
-				Required: []string{"command"},
+				Type:       "object",
+				Properties: properties,
+				Required:   []string{"command"},
 			},
 		},
 		Handler: func(args string) (string, error) {
 			var params struct {
 				Command string `json:"command"`
+				Context string `json:"context"`
 			}
 			if err := json.Unmarshal([]byte(args), &params); err != nil {
 				return "", fmt.Errorf("failed to parse arguments: %w", err)
@@ -278,96 +332,140 @@ func GetShellExecutionTool(executor *ShellExecutor) Tool {
 				return "", fmt.Errorf("command parameter is required")
 			}
 
-			return executor.Execute(params.Command)
+			return executor.ExecuteInContext(params.Command, params.Context)
 		},
 	}
 }
 
 // ToolManager manages available tools
 type ToolManager struct {
-	tools            map[string]Tool
-	executor         *ShellExecutor
-	confirmationMode bool
+	registry *ToolRegistry
+	executor *ShellExecutor
+	kube     *kubeTools
+
+	approvalMu      sync.Mutex
+	approvalMode    config.ApprovalMode
+	approver        Approver
+	allowedPatterns []string
 }
 
 // SetKubernetesConfig sets the Kubernetes configuration for the shell executor
 func (tm *ToolManager) SetKubernetesConfig(k8sConfig *config.KubernetesConfig) {
 	tm.executor.SetKubernetesConfig(k8sConfig)
+
+	// The tools' context argument depends on the session's contexts
+	tm.registerBuiltinTools()
+}
+
+// KubernetesConfig returns the Kubernetes configuration tools run with
+func (tm *ToolManager) KubernetesConfig() *config.KubernetesConfig {
+	return tm.executor.k8sConfig
+}
+
+// registerBuiltinTools registers the shell tool and the typed Kubernetes
+// tools, replacing earlier definitions
+func (tm *ToolManager) registerBuiltinTools() {
+	tools := append([]Tool{GetShellExecutionTool(tm.executor)}, tm.kube.tools()...)
+	for _, tool := range tools {
+		tm.registry.Register(tool, ToolOrigin{Source: ToolSourceBuiltin, Name: tool.Function.Name})
+	}
 }
 
-// SetConfirmationMode enables or disables user confirmation before tool execution
+// SetConfirmationMode enables or disables user confirmation before every
+// tool execution
 func (tm *ToolManager) SetConfirmationMode(confirm bool) {
-	tm.confirmationMode = confirm
+	if confirm {
+		tm.SetApprovalMode(config.ApprovalAlways)
+	} else {
+		tm.SetApprovalMode(config.ApprovalNever)
+	}
 }
 
 // NewToolManager creates a new tool manager
 func NewToolManager(workDir string) *ToolManager {
 	executor := NewShellExecutor(workDir)
 	tm := &ToolManager{
-		tools:            make(map[string]Tool),
-		executor:         executor,
-		confirmationMode: false,
+		registry:     NewToolRegistry(),
+		executor:     executor,
+		kube:         newKubeTools(executor),
+		approvalMode: config.ApprovalNever,
 	}
-
-	// Register shell execution tool
-	shellTool := GetShellExecutionTool(executor)
-	tm.tools[shellTool.Function.Name] = shellTool
+	tm.registerBuiltinTools()
 
 	return tm
 }
 
+// Registry returns the registry holding the manager's tools
+func (tm *ToolManager) Registry() *ToolRegistry {
+	return tm.registry
+}
+
 // GetTools returns all available tools
 func (tm *ToolManager) GetTools() []Tool {
-	tools := make([]Tool, 0, len(tm.tools))
-	for _, tool := range tm.tools {
-		tools = append(tools, tool)
-	}
-	return tools
+	return tm.registry.Tools(nil)
 }
 
 // ExecuteTool executes a tool by name with given arguments
 func (tm *ToolManager) ExecuteTool(name, arguments string) (string, error) {
-	tool, exists := tm.tools[name]
+	tool, _, exists := tm.registry.Lookup(name)
 	if !exists {
 		return "", fmt.Errorf("tool '%s' not found", name)
 	}
 
-	// If confirmation mode is enabled, ask for user permission
-	if tm.confirmationMode {
-		// Extract command from arguments for display
-		var displayCmd string
-		if name == "execute_shell_command" {
-			var params struct {
-				Command string `json:"command"`
-			}
-			if err := json.Unmarshal([]byte(arguments), &params); err == nil {
-				displayCmd = params.Command
-			} else {
-				displayCmd = arguments
-			}
-		} else {
-			displayCmd = fmt.Sprintf("%s with args: %s", name, arguments)
-		}
-
-		if !UserConfirmation(displayCmd) {
-			return "", fmt.Errorf("tool execution cancelled by user")
+	request := ApprovalRequest{
+		Tool:     name,
+		Subject:  name,
+		Display:  fmt.Sprintf("%s with args: %s", name, arguments),
+		Editable: arguments,
+	}
+	var shellParams struct {
+		Command string `json:"command"`
+		Context string `json:"context"`
+	}
+	isShell := name == "execute_shell_command" && json.Unmarshal([]byte(arguments), &shellParams) == nil
+	if isShell {
+		request.Subject = shellParams.Command
+		request.Display = shellParams.Command
+		request.Editable = shellParams.Command
+		if shellParams.Context != "" {
+			request.Display += fmt.Sprintf(" (context %s)", shellParams.Context)
 		}
 	}
 
-	return tool.Handler(arguments)
-}
-
-// UserConfirmation prompts the user for confirmation before executing a command
-func UserConfirmation(command string) bool {
-	fmt.Printf("\n🔍 About to execute command: %s\n", command)
-	fmt.Print("Do you want to proceed? (y/N): ")
+	approved, err := tm.approve(request)
+	if err != nil {
+		return "", err
+	}
+	if approved == request.Editable {
+		return tool.Handler(arguments)
+	}
 
-	reader := bufio.NewReader(os.Stdin)
-	response, err := reader.ReadString('\n')
+	// Run the edited call and tell the model what actually ran
+	editedArgs := approved
+	if isShell {
+		shellParams.Command = approved
+		encoded, err := json.Marshal(shellParams)
+		if err != nil {
+			return "", fmt.Errorf("failed to encode edited arguments: %w", err)
+		}
+		editedArgs = string(encoded)
+	}
+	result, err := tool.Handler(editedArgs)
 	if err != nil {
-		return false
+		return "", fmt.Errorf("the user edited the call to %s: %w", approved, err)
 	}
+	return fmt.Sprintf("The user edited the call to: %s\n\n%s", approved, result), nil
+}
+
+// ExecuteShellCommand runs a command through the shell executor's safety
+// checks without asking for confirmation. It is meant for k8x's own
+// read-only probes, such as gathering cluster context.
+func (tm *ToolManager) ExecuteShellCommand(command string) (string, error) {
+	return tm.executor.Execute(command)
+}
 
-	response = strings.TrimSpace(strings.ToLower(response))
-	return response == "y" || response == "yes"
+// ExecuteShellCommandInContext is ExecuteShellCommand against one of the
+// session's kube-contexts
+func (tm *ToolManager) ExecuteShellCommandInContext(command, kubeContext string) (string, error) {
+	return tm.executor.ExecuteInContext(command, kubeContext)
 }
@@ -0,0 +1,147 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+const (
+	// DefaultConnectTimeout bounds a single connection attempt to a server
+	DefaultConnectTimeout = 30 * time.Second
+	// DefaultHealthCheckInterval is how often connected servers are pinged
+	DefaultHealthCheckInterval = 30 * time.Second
+
+	pingTimeout             = 10 * time.Second
+	initialReconnectBackoff = time.Second
+	maxReconnectBackoff     = time.Minute
+)
+
+// StartHealthChecks periodically pings every connected server. When a ping
+// fails the server is reconnected in the background with exponential
+// backoff. Servers that never connected are not retried.
+func (m *Manager) StartHealthChecks() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.stopHealthChecks != nil || m.healthCheckInterval <= 0 {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	m.stopHealthChecks = cancel
+	interval := m.healthCheckInterval
+
+	m.healthWG.Add(1)
+	go func() {
+		defer m.healthWG.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				m.checkHealth(ctx)
+			}
+		}
+	}()
+}
+
+// StopHealthChecks stops health checks and any reconnect loops in progress
+func (m *Manager) StopHealthChecks() {
+	m.mu.Lock()
+	cancel := m.stopHealthChecks
+	m.stopHealthChecks = nil
+	m.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+		m.healthWG.Wait()
+	}
+}
+
+// checkHealth pings all connected servers and schedules reconnects for those
+// that stopped responding
+func (m *Manager) checkHealth(ctx context.Context) {
+	m.mu.RLock()
+	clients := make(map[string]Client, len(m.clients))
+	for name, client := range m.clients {
+		if !m.reconnecting[name] && client.IsConnected() {
+			clients[name] = client
+		}
+	}
+	m.mu.RUnlock()
+
+	for name, client := range clients {
+		pingCtx, cancel := context.WithTimeout(ctx, pingTimeout)
+		err := client.Ping(pingCtx)
+		cancel()
+		if err == nil || ctx.Err() != nil {
+			continue
+		}
+
+		log.Printf("MCP server '%s' is not responding: %v", name, err)
+		m.startReconnect(ctx, name, client, fmt.Errorf("MCP server '%s' is not responding: %w", name, err))
+	}
+}
+
+// startReconnect launches a background reconnect loop for a server unless
+// one is already running
+func (m *Manager) startReconnect(ctx context.Context, name string, client Client, cause error) {
+	m.mu.Lock()
+	if m.reconnecting[name] {
+		m.mu.Unlock()
+		return
+	}
+	m.reconnecting[name] = true
+	m.lastErrors[name] = cause
+	m.clearCacheLocked(name)
+	timeout := m.connectTimeout
+	m.healthWG.Add(1)
+	m.mu.Unlock()
+
+	go func() {
+		defer m.healthWG.Done()
+		defer func() {
+			m.mu.Lock()
+			delete(m.reconnecting, name)
+			m.mu.Unlock()
+		}()
+
+		backoff := initialReconnectBackoff
+		for attempt := 1; ; attempt++ {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(backoff):
+			}
+
+			connectCtx, cancel := context.WithTimeout(ctx, timeout)
+			err := client.Reconnect(connectCtx)
+			cancel()
+			if ctx.Err() != nil {
+				return
+			}
+
+			if err == nil {
+				log.Printf("Reconnected to MCP server '%s' after %d attempt(s)", name, attempt)
+				m.recordConnectResult(name, nil)
+				return
+			}
+
+			m.recordConnectResult(name, fmt.Errorf("failed to reconnect to MCP server '%s': %w", name, err))
+			backoff = nextBackoff(backoff)
+		}
+	}()
+}
+
+// nextBackoff doubles the reconnect delay up to maxReconnectBackoff
+func nextBackoff(current time.Duration) time.Duration {
+	next := current * 2
+	if next > maxReconnectBackoff {
+		return maxReconnectBackoff
+	}
+	return next
+}
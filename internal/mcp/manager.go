@@ -2,8 +2,11 @@ package mcp
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"os"
+	"log"
+	"sync"
+	"time"
 
 	"k8x/internal/config"
 
@@ -12,11 +15,31 @@ import (
 	"github.com/mark3labs/mcp-go/mcp"
 )
 
+// ErrAuthorizationRequired is returned when an OAuth-protected MCP server
+// needs the user to complete an authorization flow before connecting
+var ErrAuthorizationRequired = errors.New("MCP server requires OAuth authorization")
+
 // MCPClient wraps the mcp-go client to provide our interface
 type MCPClient struct {
+	mu sync.Mutex
+	// newClient creates a fresh, unstarted mcp-go client. A new client is
+	// needed for every connection attempt since stdio processes and network
+	// streams can't be restarted once closed.
+	newClient  func() (*client.Client, error)
 	client     *client.Client
 	serverInfo mcp.Implementation
 	connected  bool
+	// started is true once the underlying transport is running
+	started bool
+
+	// capabilities advertised by the server during initialization
+	capabilities mcp.ServerCapabilities
+
+	// handlerMu guards onListChanged separately from mu: notifications are
+	// delivered from the transport's read loop, which must not block on a
+	// connect in progress
+	handlerMu     sync.RWMutex
+	onListChanged func(method string)
 }
 
 // Tool represents an MCP tool definition (alias to mcp-go type)
@@ -60,6 +83,28 @@ type Client interface {
 
 	// GetServerInfo returns information about the connected server
 	GetServerInfo() ServerInfo
+
+	// Ping checks that the server is still responsive
+	Ping(ctx context.Context) error
+
+	// Reconnect tears down the current connection and connects again
+	Reconnect(ctx context.Context) error
+
+	// OnListChanged registers a handler called with the notification method
+	// when the server announces that its tools or resources changed
+	OnListChanged(handler func(method string))
+
+	// ListResources returns the resources offered by the MCP server
+	ListResources(ctx context.Context) ([]Resource, error)
+
+	// ReadResource reads the contents of a resource by URI
+	ReadResource(ctx context.Context, uri string) ([]ResourceContent, error)
+
+	// ListPrompts returns the prompt templates offered by the MCP server
+	ListPrompts(ctx context.Context) ([]Prompt, error)
+
+	// GetPrompt renders a prompt template with the given arguments
+	GetPrompt(ctx context.Context, name string, args map[string]string) (*PromptResult, error)
 }
 
 // ServerInfo contains information about an MCP server
@@ -69,77 +114,110 @@ type ServerInfo struct {
 	Description string `json:"description,omitempty"`
 }
 
-// NewMCPStdioClient creates a new stdio-based MCP client using mcp-go
-func NewMCPStdioClient(command string, env []string, args ...string) (*MCPClient, error) {
-	c, err := client.NewStdioMCPClient(command, env, args...)
+// newMCPClient wraps a client factory, creating the first client eagerly so
+// that configuration errors such as invalid URLs are reported immediately
+func newMCPClient(newClient func() (*client.Client, error)) (*MCPClient, error) {
+	c, err := newClient()
 	if err != nil {
-		return nil, fmt.Errorf("failed to create stdio client: %w", err)
+		return nil, err
 	}
 
 	return &MCPClient{
+		newClient: newClient,
 		client:    c,
 		connected: false,
 	}, nil
 }
 
+// NewMCPStdioClient creates a new stdio-based MCP client using mcp-go.
+// The server process is started on Connect.
+func NewMCPStdioClient(command string, env []string, args ...string) (*MCPClient, error) {
+	return newMCPClient(func() (*client.Client, error) {
+		return client.NewClient(transport.NewStdio(command, env, args...)), nil
+	})
+}
+
 // NewMCPSSEClient creates a new Server-Sent Events based MCP client
 func NewMCPSSEClient(baseURL string, options ...transport.ClientOption) (*MCPClient, error) {
-	c, err := client.NewSSEMCPClient(baseURL, options...)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create SSE client: %w", err)
-	}
-
-	return &MCPClient{
-		client:    c,
-		connected: false,
-	}, nil
+	return newMCPClient(func() (*client.Client, error) {
+		c, err := client.NewSSEMCPClient(baseURL, options...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create SSE client: %w", err)
+		}
+		return c, nil
+	})
 }
 
 // NewMCPStreamableHTTPClient creates a new streamable HTTP-based MCP client
 func NewMCPStreamableHTTPClient(baseURL string, options ...transport.StreamableHTTPCOption) (*MCPClient, error) {
-	c, err := client.NewStreamableHttpClient(baseURL, options...)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create streamable HTTP client: %w", err)
-	}
-
-	return &MCPClient{
-		client:    c,
-		connected: false,
-	}, nil
+	return newMCPClient(func() (*client.Client, error) {
+		c, err := client.NewStreamableHttpClient(baseURL, options...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create streamable HTTP client: %w", err)
+		}
+		return c, nil
+	})
 }
 
 // NewMCPOAuthSSEClient creates a new OAuth-authenticated SSE-based MCP client
 func NewMCPOAuthSSEClient(baseURL string, oauthConfig client.OAuthConfig, options ...transport.ClientOption) (*MCPClient, error) {
-	c, err := client.NewOAuthSSEClient(baseURL, oauthConfig, options...)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create OAuth SSE client: %w", err)
-	}
-
-	return &MCPClient{
-		client:    c,
-		connected: false,
-	}, nil
+	return newMCPClient(func() (*client.Client, error) {
+		c, err := client.NewOAuthSSEClient(baseURL, oauthConfig, options...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create OAuth SSE client: %w", err)
+		}
+		return c, nil
+	})
 }
 
 // NewMCPOAuthStreamableHTTPClient creates a new OAuth-authenticated streamable HTTP-based MCP client
 func NewMCPOAuthStreamableHTTPClient(baseURL string, oauthConfig client.OAuthConfig, options ...transport.StreamableHTTPCOption) (*MCPClient, error) {
-	c, err := client.NewOAuthStreamableHttpClient(baseURL, oauthConfig, options...)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create OAuth streamable HTTP client: %w", err)
-	}
-
-	return &MCPClient{
-		client:    c,
-		connected: false,
-	}, nil
+	return newMCPClient(func() (*client.Client, error) {
+		c, err := client.NewOAuthStreamableHttpClient(baseURL, oauthConfig, options...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create OAuth streamable HTTP client: %w", err)
+		}
+		return c, nil
+	})
 }
 
 // Connect establishes connection to the MCP server
 func (c *MCPClient) Connect(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.connectLocked(ctx)
+}
+
+// connectLocked starts the transport and performs the initialize handshake.
+// On failure the client is reset so the next attempt starts from scratch.
+func (c *MCPClient) connectLocked(ctx context.Context) error {
 	if c.connected {
 		return nil
 	}
 
+	if c.client == nil {
+		newClient, err := c.newClient()
+		if err != nil {
+			return err
+		}
+		c.client = newClient
+	}
+
+	if !c.started {
+		c.client.OnNotification(c.handleNotification)
+
+		// The transport outlives the connect timeout: stdio processes and
+		// SSE streams are bound to the context passed to Start
+		if err := c.client.Start(context.Background()); err != nil {
+			c.client = nil
+			if client.IsOAuthAuthorizationRequiredError(err) {
+				return fmt.Errorf("%w: %v", ErrAuthorizationRequired, err)
+			}
+			return fmt.Errorf("failed to start MCP transport: %w", err)
+		}
+		c.started = true
+	}
+
 	// Initialize the connection
 	initReq := mcp.InitializeRequest{}
 	initReq.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
@@ -151,32 +229,113 @@ func (c *MCPClient) Connect(ctx context.Context) error {
 
 	result, err := c.client.Initialize(ctx, initReq)
 	if err != nil {
+		c.closeLocked()
+		if client.IsOAuthAuthorizationRequiredError(err) {
+			return fmt.Errorf("%w: %v", ErrAuthorizationRequired, err)
+		}
 		return fmt.Errorf("failed to initialize MCP connection: %w", err)
 	}
 
 	c.serverInfo = result.ServerInfo
+	c.capabilities = result.Capabilities
 	c.connected = true
 	return nil
 }
 
+// closeLocked closes the underlying client and forgets it
+func (c *MCPClient) closeLocked() error {
+	var err error
+	if c.client != nil && c.started {
+		err = c.client.Close()
+	}
+	c.client = nil
+	c.connected = false
+	c.started = false
+	return err
+}
+
+// handleNotification dispatches server notifications we care about
+func (c *MCPClient) handleNotification(notification mcp.JSONRPCNotification) {
+	switch notification.Method {
+	case mcp.MethodNotificationToolsListChanged, mcp.MethodNotificationResourcesListChanged:
+	default:
+		return
+	}
+
+	c.handlerMu.RLock()
+	handler := c.onListChanged
+	c.handlerMu.RUnlock()
+
+	if handler != nil {
+		handler(notification.Method)
+	}
+}
+
 // Disconnect closes the connection to the MCP server
 func (c *MCPClient) Disconnect() error {
-	if !c.connected {
-		return nil
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.closeLocked()
+}
+
+// Reconnect tears down the current connection and connects again
+func (c *MCPClient) Reconnect(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// The old connection is usually already broken, so close errors are expected
+	_ = c.closeLocked()
+	return c.connectLocked(ctx)
+}
+
+// Ping checks that the server is still responsive. A failed ping marks the
+// client as disconnected.
+func (c *MCPClient) Ping(ctx context.Context) error {
+	cl, err := c.connectedClient()
+	if err != nil {
+		return err
 	}
 
-	err := c.client.Close()
-	c.connected = false
-	return err
+	if err := cl.Ping(ctx); err != nil {
+		c.mu.Lock()
+		if c.client == cl {
+			c.connected = false
+		}
+		c.mu.Unlock()
+		return fmt.Errorf("ping failed: %w", err)
+	}
+	return nil
+}
+
+// OnListChanged registers a handler for tools/list_changed and
+// resources/list_changed notifications
+func (c *MCPClient) OnListChanged(handler func(method string)) {
+	c.handlerMu.Lock()
+	defer c.handlerMu.Unlock()
+	c.onListChanged = handler
+}
+
+// connectedClient returns the underlying client if connected
+func (c *MCPClient) connectedClient() (*client.Client, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.connected {
+		return nil, fmt.Errorf("not connected to MCP server")
+	}
+	return c.client, nil
 }
 
 // IsConnected returns true if client is connected to server
 func (c *MCPClient) IsConnected() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	return c.connected
 }
 
 // GetServerInfo returns information about the connected server
 func (c *MCPClient) GetServerInfo() ServerInfo {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	return ServerInfo{
 		Name:        c.serverInfo.Name,
 		Version:     c.serverInfo.Version,
@@ -186,12 +345,13 @@ func (c *MCPClient) GetServerInfo() ServerInfo {
 
 // ListTools returns available tools from the MCP server
 func (c *MCPClient) ListTools(ctx context.Context) ([]Tool, error) {
-	if !c.connected {
-		return nil, fmt.Errorf("not connected to MCP server")
+	cl, err := c.connectedClient()
+	if err != nil {
+		return nil, err
 	}
 
 	req := mcp.ListToolsRequest{}
-	result, err := c.client.ListTools(ctx, req)
+	result, err := cl.ListTools(ctx, req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list tools: %w", err)
 	}
@@ -201,15 +361,16 @@ func (c *MCPClient) ListTools(ctx context.Context) ([]Tool, error) {
 
 // CallTool executes a tool on the MCP server
 func (c *MCPClient) CallTool(ctx context.Context, call ToolCall) (*ToolResult, error) {
-	if !c.connected {
-		return nil, fmt.Errorf("not connected to MCP server")
+	cl, err := c.connectedClient()
+	if err != nil {
+		return nil, err
 	}
 
 	req := mcp.CallToolRequest{}
 	req.Params.Name = call.Name
 	req.Params.Arguments = call.Arguments
 
-	result, err := c.client.CallTool(ctx, req)
+	result, err := cl.CallTool(ctx, req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to call tool: %w", err)
 	}
@@ -251,23 +412,89 @@ func (c *MCPClient) CallTool(ctx context.Context, call ToolCall) (*ToolResult, e
 
 // Manager manages multiple MCP clients
 type Manager struct {
+	mu      sync.RWMutex
 	clients map[string]Client
+	// tools caches each server's tool list until the server sends a
+	// tools/list_changed notification or reconnects
+	tools map[string][]Tool
+	// resources caches each server's resource list the same way
+	resources map[string][]Resource
+	// policies restricts which tools of each server are exposed
+	policies map[string]ToolPolicy
+	// lastErrors holds the most recent connection error of each server
+	lastErrors map[string]error
+	// reconnecting tracks servers with a reconnect loop in progress
+	reconnecting map[string]bool
+
+	connectTimeout      time.Duration
+	healthCheckInterval time.Duration
+	stopHealthChecks    context.CancelFunc
+	healthWG            sync.WaitGroup
+}
+
+// ServerStatus describes the connection state of an MCP server
+type ServerStatus struct {
+	Connected    bool
+	Reconnecting bool
+	LastError    error
 }
 
 // NewManager creates a new MCP manager
 func NewManager() *Manager {
 	return &Manager{
-		clients: make(map[string]Client),
+		clients:             make(map[string]Client),
+		tools:               make(map[string][]Tool),
+		resources:           make(map[string][]Resource),
+		policies:            make(map[string]ToolPolicy),
+		lastErrors:          make(map[string]error),
+		reconnecting:        make(map[string]bool),
+		connectTimeout:      DefaultConnectTimeout,
+		healthCheckInterval: DefaultHealthCheckInterval,
+	}
+}
+
+// SetConnectTimeout sets the per-server timeout for connection attempts
+func (m *Manager) SetConnectTimeout(timeout time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if timeout > 0 {
+		m.connectTimeout = timeout
+	}
+}
+
+// SetHealthCheckInterval sets how often connected servers are pinged.
+// A negative interval disables health checks.
+func (m *Manager) SetHealthCheckInterval(interval time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if interval != 0 {
+		m.healthCheckInterval = interval
 	}
 }
 
 // RegisterClient registers an MCP client with a given name
 func (m *Manager) RegisterClient(name string, client Client) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.clients[name] = client
+	m.clearCacheLocked(name)
+	client.OnListChanged(func(method string) {
+		m.invalidateCache(name, method)
+	})
+}
+
+// SetToolPolicy restricts which tools of a registered server are listed
+// and may be called
+func (m *Manager) SetToolPolicy(name string, policy ToolPolicy) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.policies[name] = policy
 }
 
 // GetClient returns an MCP client by name
 func (m *Manager) GetClient(name string) (Client, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
 	client, exists := m.clients[name]
 	if !exists {
 		return nil, fmt.Errorf("MCP client '%s' not found", name)
@@ -277,6 +504,8 @@ func (m *Manager) GetClient(name string) (Client, error) {
 
 // ListClients returns names of all registered clients
 func (m *Manager) ListClients() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
 	var names []string
 	for name := range m.clients {
 		names = append(names, name)
@@ -284,18 +513,70 @@ func (m *Manager) ListClients() []string {
 	return names
 }
 
-// ConnectAll connects to all registered MCP servers
-func (m *Manager) ConnectAll(ctx context.Context) error {
+// ConnectAll connects to all registered MCP servers concurrently, each with
+// its own timeout. A failing server doesn't prevent the others from
+// connecting; the returned map holds the error of every server that failed
+// and is empty when all servers connected.
+func (m *Manager) ConnectAll(ctx context.Context) map[string]error {
+	m.mu.RLock()
+	clients := make(map[string]Client, len(m.clients))
 	for name, client := range m.clients {
-		if err := client.Connect(ctx); err != nil {
-			return fmt.Errorf("failed to connect to MCP server '%s': %w", name, err)
-		}
+		clients[name] = client
 	}
-	return nil
+	timeout := m.connectTimeout
+	m.mu.RUnlock()
+
+	var (
+		wg       sync.WaitGroup
+		errMu    sync.Mutex
+		failures = make(map[string]error)
+	)
+	for name, client := range clients {
+		wg.Add(1)
+		go func(name string, client Client) {
+			defer wg.Done()
+
+			connectCtx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+
+			err := client.Connect(connectCtx)
+			if err != nil {
+				if errors.Is(err, ErrAuthorizationRequired) {
+					err = fmt.Errorf("failed to connect to MCP server '%s' (run 'k8x mcp login %s'): %w", name, name, err)
+				} else {
+					err = fmt.Errorf("failed to connect to MCP server '%s': %w", name, err)
+				}
+				errMu.Lock()
+				failures[name] = err
+				errMu.Unlock()
+			}
+			m.recordConnectResult(name, err)
+		}(name, client)
+	}
+	wg.Wait()
+
+	return failures
+}
+
+// recordConnectResult stores the outcome of a connection attempt
+func (m *Manager) recordConnectResult(name string, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err != nil {
+		m.lastErrors[name] = err
+	} else {
+		delete(m.lastErrors, name)
+	}
+	// A new connection may expose a different set of tools and resources
+	m.clearCacheLocked(name)
 }
 
 // DisconnectAll disconnects from all MCP servers
 func (m *Manager) DisconnectAll() error {
+	m.StopHealthChecks()
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
 	var lastErr error
 	for _, client := range m.clients {
 		if err := client.Disconnect(); err != nil {
@@ -305,21 +586,84 @@ func (m *Manager) DisconnectAll() error {
 	return lastErr
 }
 
-// GetAllTools returns all tools from all connected MCP servers
-func (m *Manager) GetAllTools(ctx context.Context) (map[string][]Tool, error) {
-	allTools := make(map[string][]Tool)
+// Status returns the connection state of every registered server
+func (m *Manager) Status() map[string]ServerStatus {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	status := make(map[string]ServerStatus, len(m.clients))
+	for name, client := range m.clients {
+		status[name] = ServerStatus{
+			Connected:    client.IsConnected(),
+			Reconnecting: m.reconnecting[name],
+			LastError:    m.lastErrors[name],
+		}
+	}
+	return status
+}
+
+// invalidateCache drops the cached tool or resource list of a server after
+// a list_changed notification
+func (m *Manager) invalidateCache(name, method string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	switch method {
+	case mcp.MethodNotificationToolsListChanged:
+		delete(m.tools, name)
+	case mcp.MethodNotificationResourcesListChanged:
+		delete(m.resources, name)
+	}
+}
 
+// clearCacheLocked drops all cached lists of a server. m.mu must be held.
+func (m *Manager) clearCacheLocked(name string) {
+	delete(m.tools, name)
+	delete(m.resources, name)
+}
+
+// GetAllTools returns all tools from all connected MCP servers that their
+// tool policy allows. Tool lists are cached per server; a server whose
+// tools can't be listed is skipped.
+func (m *Manager) GetAllTools(ctx context.Context) (map[string][]Tool, error) {
+	m.mu.RLock()
+	clients := make(map[string]Client, len(m.clients))
 	for name, client := range m.clients {
+		clients[name] = client
+	}
+	m.mu.RUnlock()
+
+	allTools := make(map[string][]Tool)
+	for name, client := range clients {
 		if !client.IsConnected() {
 			continue
 		}
 
-		tools, err := client.ListTools(ctx)
-		if err != nil {
-			return nil, fmt.Errorf("failed to list tools from MCP server '%s': %w", name, err)
+		m.mu.RLock()
+		tools, cached := m.tools[name]
+		m.mu.RUnlock()
+
+		if !cached {
+			var err error
+			tools, err = client.ListTools(ctx)
+			if err != nil {
+				log.Printf("[DEBUG] Failed to list tools from MCP server '%s': %v", name, err)
+				continue
+			}
+			m.mu.Lock()
+			m.tools[name] = tools
+			m.mu.Unlock()
 		}
 
-		allTools[name] = tools
+		m.mu.RLock()
+		policy := m.policies[name]
+		m.mu.RUnlock()
+
+		var allowed []Tool
+		for _, tool := range tools {
+			if policy.Allows(tool) {
+				allowed = append(allowed, tool)
+			}
+		}
+		allTools[name] = allowed
 	}
 
 	return allTools, nil
@@ -327,21 +671,68 @@ func (m *Manager) GetAllTools(ctx context.Context) (map[string][]Tool, error) {
 
 // CallTool calls a tool on the specified MCP server
 func (m *Manager) CallTool(ctx context.Context, serverName string, call ToolCall) (*ToolResult, error) {
+	client, err := m.connectedClient(serverName)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	policy := m.policies[serverName]
+	m.mu.RUnlock()
+
+	tool, known := m.toolDefinition(serverName, call.Name)
+	if !known {
+		tool = Tool{Name: call.Name}
+	}
+	if !policy.Allows(tool) {
+		return nil, fmt.Errorf("tool '%s' of MCP server '%s' is not allowed by the server's tool filter", call.Name, serverName)
+	}
+
+	return client.CallTool(ctx, call)
+}
+
+// RequiresConfirmation reports whether calling a tool needs user
+// confirmation because it may perform destructive updates. Tools whose
+// definition isn't known are treated as destructive.
+func (m *Manager) RequiresConfirmation(serverName, toolName string) bool {
+	tool, known := m.toolDefinition(serverName, toolName)
+	return !known || IsDestructiveTool(tool)
+}
+
+// toolDefinition returns the cached definition of a server's tool
+func (m *Manager) toolDefinition(serverName, toolName string) (Tool, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, tool := range m.tools[serverName] {
+		if tool.Name == toolName {
+			return tool, true
+		}
+	}
+	return Tool{}, false
+}
+
+// connectedClient returns the named client if it is currently connected
+func (m *Manager) connectedClient(serverName string) (Client, error) {
 	client, err := m.GetClient(serverName)
 	if err != nil {
 		return nil, err
 	}
 
 	if !client.IsConnected() {
+		if m.Status()[serverName].Reconnecting {
+			return nil, fmt.Errorf("MCP server '%s' is not connected (reconnecting)", serverName)
+		}
 		return nil, fmt.Errorf("MCP server '%s' is not connected", serverName)
 	}
 
-	return client.CallTool(ctx, call)
+	return client, nil
 }
 
 // CreateFromConfig creates an MCP manager from configuration
 func CreateManagerFromConfig(cfg *config.Config) (*Manager, error) {
 	manager := NewManager()
+	manager.SetConnectTimeout(cfg.MCP.ConnectTimeout)
+	manager.SetHealthCheckInterval(cfg.MCP.HealthCheckInterval)
 
 	if !cfg.MCP.Enabled {
 		return manager, nil
@@ -352,19 +743,50 @@ func CreateManagerFromConfig(cfg *config.Config) (*Manager, error) {
 			continue
 		}
 
-		client, err := createClientFromConfig(serverConfig)
+		client, err := createClientFromConfig(name, serverConfig)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create MCP client for server '%s': %w", name, err)
 		}
 
+		policy, err := NewToolPolicy(serverConfig)
+		if err != nil {
+			return nil, fmt.Errorf("invalid tool filter for MCP server '%s': %w", name, err)
+		}
+
 		manager.RegisterClient(name, client)
+		manager.SetToolPolicy(name, policy)
 	}
 
 	return manager, nil
 }
 
-// createClientFromConfig creates an MCP client from server config
-func createClientFromConfig(serverConfig config.MCPServerConfig) (Client, error) {
+// NewClientFromConfig creates an unconnected MCP client from a server configuration
+func NewClientFromConfig(name string, serverConfig config.MCPServerConfig) (Client, error) {
+	return createClientFromConfig(name, serverConfig)
+}
+
+// TestServerConnection connects to the server described by serverConfig,
+// lists its tools and disconnects again. It is used to validate a server
+// configuration before it is saved.
+func TestServerConnection(ctx context.Context, name string, serverConfig config.MCPServerConfig) ([]Tool, error) {
+	c, err := createClientFromConfig(name, serverConfig)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = c.Disconnect()
+	}()
+
+	if err := c.Connect(ctx); err != nil {
+		return nil, err
+	}
+
+	return c.ListTools(ctx)
+}
+
+// createClientFromConfig creates an MCP client from server config. The
+// server name selects the stored token of OAuth servers.
+func createClientFromConfig(name string, serverConfig config.MCPServerConfig) (Client, error) {
 	// Default to stdio transport if not specified
 	transportType := serverConfig.Transport
 	if transportType == "" {
@@ -394,17 +816,9 @@ func createClientFromConfig(serverConfig config.MCPServerConfig) (Client, error)
 		if serverConfig.BaseURL == "" {
 			return nil, fmt.Errorf("MCP server base_url is required for OAuth SSE transport")
 		}
-		if serverConfig.OAuth == nil {
-			return nil, fmt.Errorf("OAuth configuration is required for OAuth SSE transport")
-		}
-		oauthConfig := client.OAuthConfig{
-			ClientID:              serverConfig.OAuth.ClientID,
-			ClientSecret:          serverConfig.OAuth.ClientSecret,
-			RedirectURI:           serverConfig.OAuth.RedirectURI,
-			Scopes:                serverConfig.OAuth.Scopes,
-			AuthServerMetadataURL: serverConfig.OAuth.AuthServerMetadataURL,
-			PKCEEnabled:           serverConfig.OAuth.PKCEEnabled,
-			TokenStore:            transport.NewMemoryTokenStore(),
+		oauthConfig, _, err := oauthClientConfig(name, serverConfig)
+		if err != nil {
+			return nil, err
 		}
 		return NewMCPOAuthSSEClient(serverConfig.BaseURL, oauthConfig)
 
@@ -412,17 +826,9 @@ func createClientFromConfig(serverConfig config.MCPServerConfig) (Client, error)
 		if serverConfig.BaseURL == "" {
 			return nil, fmt.Errorf("MCP server base_url is required for OAuth HTTP transport")
 		}
-		if serverConfig.OAuth == nil {
-			return nil, fmt.Errorf("OAuth configuration is required for OAuth HTTP transport")
-		}
-		oauthConfig := client.OAuthConfig{
-			ClientID:              serverConfig.OAuth.ClientID,
-			ClientSecret:          serverConfig.OAuth.ClientSecret,
-			RedirectURI:           serverConfig.OAuth.RedirectURI,
-			Scopes:                serverConfig.OAuth.Scopes,
-			AuthServerMetadataURL: serverConfig.OAuth.AuthServerMetadataURL,
-			PKCEEnabled:           serverConfig.OAuth.PKCEEnabled,
-			TokenStore:            transport.NewMemoryTokenStore(),
+		oauthConfig, _, err := oauthClientConfig(name, serverConfig)
+		if err != nil {
+			return nil, err
 		}
 		return NewMCPOAuthStreamableHTTPClient(serverConfig.BaseURL, oauthConfig)
 
@@ -443,36 +849,3 @@ func mapToEnvSlice(envMap map[string]string) []string {
 	}
 	return env
 }
-
-// ConnectAllWithConfig connects all MCP clients in the manager with configuration
-func (m *Manager) ConnectAllWithConfig(ctx context.Context, serverConfigs map[string]config.MCPServerConfig) error {
-	for _, serverName := range m.ListClients() {
-		client, err := m.GetClient(serverName)
-		if err != nil {
-			continue
-		}
-
-		// Set environment variables if specified
-		if serverConfig, exists := serverConfigs[serverName]; exists {
-			if err := setEnvironmentVariables(serverConfig.Env); err != nil {
-				return fmt.Errorf("failed to set environment variables for MCP server '%s': %w", serverName, err)
-			}
-		}
-
-		if err := client.Connect(ctx); err != nil {
-			return fmt.Errorf("failed to connect to MCP server '%s': %w", serverName, err)
-		}
-	}
-
-	return nil
-}
-
-// setEnvironmentVariables sets environment variables for MCP server execution
-func setEnvironmentVariables(env map[string]string) error {
-	for key, value := range env {
-		if err := os.Setenv(key, value); err != nil {
-			return fmt.Errorf("failed to set environment variable %s: %w", key, err)
-		}
-	}
-	return nil
-}
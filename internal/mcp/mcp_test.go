@@ -2,11 +2,14 @@ package mcp
 
 import (
 	"context"
+	"errors"
 	"strings"
 	"testing"
 	"time"
 
 	"k8x/internal/config"
+
+	"github.com/mark3labs/mcp-go/mcp"
 )
 
 func TestMCPClientCreation(t *testing.T) {
@@ -245,7 +248,7 @@ func TestMultipleTransportTypes(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			client, err := createClientFromConfig(tt.serverConfig)
+			client, err := createClientFromConfig(tt.name, tt.serverConfig)
 
 			if tt.expectedError {
 				if err == nil {
@@ -314,3 +317,238 @@ func TestCreateManagerFromConfigWithMultipleTransports(t *testing.T) {
 		}
 	}
 }
+
+// fakeClient is a Client whose connection outcome is controlled by the test
+type fakeClient struct {
+	connectErr    error
+	connected     bool
+	tools         []Tool
+	resources     []Resource
+	resourceLists int
+	onListChanged func(method string)
+}
+
+func (f *fakeClient) Connect(ctx context.Context) error {
+	if f.connectErr != nil {
+		return f.connectErr
+	}
+	f.connected = true
+	return nil
+}
+
+func (f *fakeClient) Disconnect() error {
+	f.connected = false
+	return nil
+}
+
+func (f *fakeClient) Reconnect(ctx context.Context) error { return f.Connect(ctx) }
+func (f *fakeClient) Ping(ctx context.Context) error      { return nil }
+func (f *fakeClient) IsConnected() bool                   { return f.connected }
+func (f *fakeClient) GetServerInfo() ServerInfo           { return ServerInfo{} }
+
+func (f *fakeClient) OnListChanged(handler func(method string)) {
+	f.onListChanged = handler
+}
+
+func (f *fakeClient) ListResources(ctx context.Context) ([]Resource, error) {
+	f.resourceLists++
+	return f.resources, nil
+}
+
+func (f *fakeClient) ReadResource(ctx context.Context, uri string) ([]ResourceContent, error) {
+	return []ResourceContent{{URI: uri, Text: "content of " + uri}}, nil
+}
+
+func (f *fakeClient) ListPrompts(ctx context.Context) ([]Prompt, error) {
+	return nil, nil
+}
+
+func (f *fakeClient) GetPrompt(ctx context.Context, name string, args map[string]string) (*PromptResult, error) {
+	return &PromptResult{}, nil
+}
+
+func (f *fakeClient) ListTools(ctx context.Context) ([]Tool, error) {
+	return f.tools, nil
+}
+
+func (f *fakeClient) CallTool(ctx context.Context, call ToolCall) (*ToolResult, error) {
+	return &ToolResult{}, nil
+}
+
+func TestConnectAllPartialFailure(t *testing.T) {
+	manager := NewManager()
+	manager.RegisterClient("good", &fakeClient{tools: []Tool{{Name: "read_file"}}})
+	manager.RegisterClient("bad", &fakeClient{connectErr: errors.New("connection refused")})
+
+	failures := manager.ConnectAll(context.Background())
+	if len(failures) != 1 || failures["bad"] == nil {
+		t.Fatalf("ConnectAll() failures = %v, want only 'bad'", failures)
+	}
+
+	status := manager.Status()
+	if !status["good"].Connected || status["good"].LastError != nil {
+		t.Errorf("status[good] = %+v, want connected", status["good"])
+	}
+	if status["bad"].Connected || status["bad"].LastError == nil {
+		t.Errorf("status[bad] = %+v, want disconnected with error", status["bad"])
+	}
+
+	tools, err := manager.GetAllTools(context.Background())
+	if err != nil {
+		t.Fatalf("GetAllTools() failed: %v", err)
+	}
+	if len(tools) != 1 || len(tools["good"]) != 1 {
+		t.Errorf("GetAllTools() = %v, want tools from 'good' only", tools)
+	}
+}
+
+func TestNextBackoff(t *testing.T) {
+	tests := []struct {
+		current time.Duration
+		want    time.Duration
+	}{
+		{time.Second, 2 * time.Second},
+		{16 * time.Second, 32 * time.Second},
+		{32 * time.Second, maxReconnectBackoff},
+		{maxReconnectBackoff, maxReconnectBackoff},
+	}
+
+	for _, tt := range tests {
+		if got := nextBackoff(tt.current); got != tt.want {
+			t.Errorf("nextBackoff(%v) = %v, want %v", tt.current, got, tt.want)
+		}
+	}
+}
+
+func TestListResourcesCache(t *testing.T) {
+	manager := NewManager()
+	client := &fakeClient{
+		connected: true,
+		resources: []Resource{{URI: "runbook://oom", Name: "OOM runbook"}},
+	}
+	manager.RegisterClient("runbooks", client)
+	manager.RegisterClient("offline", &fakeClient{resources: []Resource{{URI: "file:///tmp"}}})
+
+	for i := 0; i < 2; i++ {
+		resources := manager.ListResources(context.Background())
+		if len(resources) != 1 || len(resources["runbooks"]) != 1 {
+			t.Fatalf("ListResources() = %v, want one resource from 'runbooks'", resources)
+		}
+	}
+	if client.resourceLists != 1 {
+		t.Errorf("server listed %d times, want 1 (cached)", client.resourceLists)
+	}
+
+	// A resources/list_changed notification drops the cached list
+	client.onListChanged(mcp.MethodNotificationResourcesListChanged)
+	manager.ListResources(context.Background())
+	if client.resourceLists != 2 {
+		t.Errorf("server listed %d times after list_changed, want 2", client.resourceLists)
+	}
+
+	contents, err := manager.ReadResource(context.Background(), "runbooks", "runbook://oom")
+	if err != nil {
+		t.Fatalf("ReadResource() failed: %v", err)
+	}
+	if len(contents) != 1 || contents[0].Text != "content of runbook://oom" {
+		t.Errorf("ReadResource() = %v", contents)
+	}
+
+	if _, err := manager.ReadResource(context.Background(), "offline", "file:///tmp"); err == nil {
+		t.Error("ReadResource() on a disconnected server should fail")
+	}
+}
+
+func boolPtr(b bool) *bool { return &b }
+
+func TestToolPolicy(t *testing.T) {
+	readOnlyTool := Tool{Name: "list_files", Annotations: mcp.ToolAnnotation{ReadOnlyHint: boolPtr(true)}}
+	writeTool := Tool{Name: "write_file", Annotations: mcp.ToolAnnotation{DestructiveHint: boolPtr(false)}}
+	deleteTool := Tool{Name: "delete_file"}
+
+	tests := []struct {
+		name   string
+		server config.MCPServerConfig
+		want   map[string]bool
+	}{
+		{
+			name:   "no filter allows everything",
+			server: config.MCPServerConfig{},
+			want:   map[string]bool{"list_files": true, "write_file": true, "delete_file": true},
+		},
+		{
+			name:   "allow list",
+			server: config.MCPServerConfig{AllowedTools: []string{"list_*", "write_file"}},
+			want:   map[string]bool{"list_files": true, "write_file": true, "delete_file": false},
+		},
+		{
+			name:   "deny takes precedence over allow",
+			server: config.MCPServerConfig{AllowedTools: []string{"*_file*"}, DeniedTools: []string{"delete_*"}},
+			want:   map[string]bool{"list_files": true, "write_file": true, "delete_file": false},
+		},
+		{
+			name:   "read-only hint",
+			server: config.MCPServerConfig{ReadOnlyHint: true},
+			want:   map[string]bool{"list_files": true, "write_file": false, "delete_file": false},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, err := NewToolPolicy(tt.server)
+			if err != nil {
+				t.Fatalf("NewToolPolicy() failed: %v", err)
+			}
+			for _, tool := range []Tool{readOnlyTool, writeTool, deleteTool} {
+				if got := policy.Allows(tool); got != tt.want[tool.Name] {
+					t.Errorf("Allows(%s) = %v, want %v", tool.Name, got, tt.want[tool.Name])
+				}
+			}
+		})
+	}
+
+	if _, err := NewToolPolicy(config.MCPServerConfig{DeniedTools: []string{"[invalid"}}); err == nil {
+		t.Error("NewToolPolicy() should reject an invalid glob")
+	}
+
+	destructive := map[string]bool{"list_files": false, "write_file": false, "delete_file": true}
+	for _, tool := range []Tool{readOnlyTool, writeTool, deleteTool} {
+		if got := IsDestructiveTool(tool); got != destructive[tool.Name] {
+			t.Errorf("IsDestructiveTool(%s) = %v, want %v", tool.Name, got, destructive[tool.Name])
+		}
+	}
+}
+
+func TestManagerEnforcesToolPolicy(t *testing.T) {
+	manager := NewManager()
+	manager.RegisterClient("fs", &fakeClient{
+		connected: true,
+		tools: []Tool{
+			{Name: "read_file", Annotations: mcp.ToolAnnotation{ReadOnlyHint: boolPtr(true)}},
+			{Name: "delete_file"},
+		},
+	})
+	manager.SetToolPolicy("fs", ToolPolicy{DeniedTools: []string{"delete_*"}})
+
+	tools, err := manager.GetAllTools(context.Background())
+	if err != nil {
+		t.Fatalf("GetAllTools() failed: %v", err)
+	}
+	if len(tools["fs"]) != 1 || tools["fs"][0].Name != "read_file" {
+		t.Errorf("GetAllTools() = %v, want only read_file", tools["fs"])
+	}
+
+	if _, err := manager.CallTool(context.Background(), "fs", ToolCall{Name: "delete_file"}); err == nil {
+		t.Error("CallTool() should reject a denied tool")
+	}
+	if _, err := manager.CallTool(context.Background(), "fs", ToolCall{Name: "read_file"}); err != nil {
+		t.Errorf("CallTool() failed for an allowed tool: %v", err)
+	}
+
+	if manager.RequiresConfirmation("fs", "read_file") {
+		t.Error("read-only tool should not require confirmation")
+	}
+	if !manager.RequiresConfirmation("fs", "unknown_tool") {
+		t.Error("unknown tool should require confirmation")
+	}
+}
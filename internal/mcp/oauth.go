@@ -0,0 +1,245 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"k8x/internal/config"
+
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/client/transport"
+)
+
+// LoginTimeout bounds how long Login waits for the user to authorize k8x
+// in the browser
+const LoginTimeout = 5 * time.Minute
+
+// oauthCallbackPath is used when no redirect_uri is configured
+const oauthCallbackPath = "/callback"
+
+// IsOAuthTransport reports whether a transport type uses OAuth
+func IsOAuthTransport(transportType string) bool {
+	switch transportType {
+	case "oauth-sse", "oauth-http", "oauth-streamable-http":
+		return true
+	}
+	return false
+}
+
+// oauthClientConfig builds the mcp-go OAuth configuration of a server,
+// backed by its persistent token store. Client credentials from dynamic
+// registration are used when the configuration doesn't set a client ID.
+func oauthClientConfig(name string, serverConfig config.MCPServerConfig) (client.OAuthConfig, *FileTokenStore, error) {
+	if serverConfig.OAuth == nil {
+		return client.OAuthConfig{}, nil, fmt.Errorf("OAuth configuration is required for %s transport", serverConfig.Transport)
+	}
+
+	store, err := NewFileTokenStore(name)
+	if err != nil {
+		return client.OAuthConfig{}, nil, err
+	}
+
+	oauthConfig := client.OAuthConfig{
+		ClientID:              serverConfig.OAuth.ClientID,
+		ClientSecret:          serverConfig.OAuth.ClientSecret,
+		RedirectURI:           serverConfig.OAuth.RedirectURI,
+		Scopes:                serverConfig.OAuth.Scopes,
+		AuthServerMetadataURL: serverConfig.OAuth.AuthServerMetadataURL,
+		// Public clients must use PKCE
+		PKCEEnabled: serverConfig.OAuth.PKCEEnabled || serverConfig.OAuth.ClientSecret == "",
+		TokenStore:  store,
+	}
+	if oauthConfig.ClientID == "" {
+		oauthConfig.ClientID, oauthConfig.ClientSecret = store.ClientCredentials()
+	}
+
+	return oauthConfig, store, nil
+}
+
+// authResponse is the result delivered to the loopback redirect handler
+type authResponse struct {
+	code  string
+	state string
+	err   error
+}
+
+// Login runs the OAuth authorization code flow with PKCE for an MCP server.
+// It listens on a loopback redirect URI, calls openURL with the
+// authorization URL and waits for the browser to be redirected back. The
+// resulting token is stored encrypted under ~/.k8x and used by later
+// connections until it expires and can't be refreshed.
+func Login(ctx context.Context, name string, serverConfig config.MCPServerConfig, openURL func(authURL string)) error {
+	if !IsOAuthTransport(serverConfig.Transport) {
+		return fmt.Errorf("MCP server '%s' uses the %s transport, which doesn't support OAuth", name, serverConfig.Transport)
+	}
+	if serverConfig.BaseURL == "" {
+		return fmt.Errorf("MCP server base_url is required for OAuth transports")
+	}
+
+	oauthConfig, store, err := oauthClientConfig(name, serverConfig)
+	if err != nil {
+		return err
+	}
+
+	listener, redirectURI, err := listenForRedirect(serverConfig.OAuth.RedirectURI)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = listener.Close()
+	}()
+	oauthConfig.RedirectURI = redirectURI.String()
+
+	// Dynamically registered clients are bound to a redirect URI, which
+	// changes with the loopback port, so they are registered on every login
+	if serverConfig.OAuth.ClientID == "" {
+		oauthConfig.ClientID, oauthConfig.ClientSecret = "", ""
+	}
+
+	handler := transport.NewOAuthHandler(oauthConfig)
+	serverURL, err := url.Parse(serverConfig.BaseURL)
+	if err != nil {
+		return fmt.Errorf("invalid base_url: %w", err)
+	}
+	handler.SetBaseURL(fmt.Sprintf("%s://%s", serverURL.Scheme, serverURL.Host))
+
+	if handler.GetClientID() == "" {
+		if err := handler.RegisterClient(ctx, "k8x"); err != nil {
+			return fmt.Errorf("no client_id configured and dynamic client registration failed: %w", err)
+		}
+		if err := store.SaveClientCredentials(handler.GetClientID(), handler.GetClientSecret()); err != nil {
+			return err
+		}
+	}
+
+	codeVerifier, err := transport.GenerateCodeVerifier()
+	if err != nil {
+		return fmt.Errorf("failed to generate PKCE code verifier: %w", err)
+	}
+	state, err := transport.GenerateState()
+	if err != nil {
+		return fmt.Errorf("failed to generate OAuth state: %w", err)
+	}
+
+	authURL, err := handler.GetAuthorizationURL(ctx, state, transport.GenerateCodeChallenge(codeVerifier))
+	if err != nil {
+		return fmt.Errorf("failed to build authorization URL: %w", err)
+	}
+
+	responses := make(chan authResponse, 1)
+	server := &http.Server{
+		Handler:           redirectHandler(redirectURI.Path, responses),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	defer func() {
+		_ = server.Close()
+	}()
+
+	openURL(authURL)
+
+	var response authResponse
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("timed out waiting for authorization: %w", ctx.Err())
+	case response = <-responses:
+	}
+	if response.err != nil {
+		return response.err
+	}
+
+	if err := handler.ProcessAuthorizationResponse(ctx, response.code, response.state, codeVerifier); err != nil {
+		return fmt.Errorf("failed to exchange authorization code: %w", err)
+	}
+	return nil
+}
+
+// Logout deletes the stored token of an MCP server and reports whether one
+// was stored
+func Logout(name string) (bool, error) {
+	store, err := NewFileTokenStore(name)
+	if err != nil {
+		return false, err
+	}
+	return store.Delete()
+}
+
+// listenForRedirect listens on the configured loopback redirect URI, or on a
+// random local port when none is configured
+func listenForRedirect(configured string) (net.Listener, *url.URL, error) {
+	if configured == "" {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to start OAuth callback listener: %w", err)
+		}
+		redirectURI := &url.URL{Scheme: "http", Host: listener.Addr().String(), Path: oauthCallbackPath}
+		return listener, redirectURI, nil
+	}
+
+	redirectURI, err := url.Parse(configured)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid redirect_uri: %w", err)
+	}
+	if redirectURI.Scheme != "http" || redirectURI.Port() == "" || !isLoopbackHost(redirectURI.Hostname()) {
+		return nil, nil, fmt.Errorf("redirect_uri %s must be a loopback address with a port, e.g. http://localhost:8085/callback", configured)
+	}
+	if redirectURI.Path == "" {
+		redirectURI.Path = "/"
+	}
+
+	listener, err := net.Listen("tcp", redirectURI.Host)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to listen on redirect_uri %s: %w", configured, err)
+	}
+	return listener, redirectURI, nil
+}
+
+// isLoopbackHost reports whether host refers to the local machine
+func isLoopbackHost(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// redirectHandler receives the authorization response from the browser and
+// delivers the first one on responses
+func redirectHandler(path string, responses chan<- authResponse) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+
+		var response authResponse
+		if oauthErr := query.Get("error"); oauthErr != "" {
+			response.err = fmt.Errorf("authorization failed: %s %s", oauthErr, query.Get("error_description"))
+		} else if query.Get("code") == "" {
+			response.err = errors.New("authorization response is missing the code parameter")
+		} else {
+			response.code = query.Get("code")
+			response.state = query.Get("state")
+		}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if response.err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, "<html><body><h3>k8x authorization failed</h3><p>%s</p></body></html>", html.EscapeString(response.err.Error()))
+		} else {
+			fmt.Fprint(w, "<html><body><h3>k8x is authorized</h3><p>You can close this window and return to the terminal.</p></body></html>")
+		}
+
+		select {
+		case responses <- response:
+		default:
+		}
+	})
+	return mux
+}
@@ -0,0 +1,182 @@
+package mcp
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"k8x/internal/config"
+
+	"github.com/mark3labs/mcp-go/client/transport"
+)
+
+func TestFileTokenStore(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	store, err := NewFileTokenStore("docs")
+	if err != nil {
+		t.Fatalf("NewFileTokenStore() failed: %v", err)
+	}
+
+	if _, err := store.GetToken(); !errors.Is(err, ErrNoToken) {
+		t.Fatalf("GetToken() on empty store error = %v, want ErrNoToken", err)
+	}
+
+	if err := store.SaveClientCredentials("client-123", ""); err != nil {
+		t.Fatalf("SaveClientCredentials() failed: %v", err)
+	}
+	token := &transport.Token{
+		AccessToken:  "secret-access-token",
+		RefreshToken: "secret-refresh-token",
+		TokenType:    "Bearer",
+		ExpiresAt:    time.Now().Add(time.Hour).Round(time.Second),
+	}
+	if err := store.SaveToken(token); err != nil {
+		t.Fatalf("SaveToken() failed: %v", err)
+	}
+
+	// A new store for the same server reads the persisted token
+	reopened, err := NewFileTokenStore("docs")
+	if err != nil {
+		t.Fatalf("NewFileTokenStore() failed: %v", err)
+	}
+	got, err := reopened.GetToken()
+	if err != nil {
+		t.Fatalf("GetToken() failed: %v", err)
+	}
+	if got.AccessToken != token.AccessToken || got.RefreshToken != token.RefreshToken || !got.ExpiresAt.Equal(token.ExpiresAt) {
+		t.Errorf("GetToken() = %+v, want %+v", got, token)
+	}
+	if clientID, _ := reopened.ClientCredentials(); clientID != "client-123" {
+		t.Errorf("ClientCredentials() = %q, want client-123", clientID)
+	}
+
+	data, err := os.ReadFile(filepath.Join(home, config.DefaultConfigDir, config.DefaultMCPTokenDir, "docs.token"))
+	if err != nil {
+		t.Fatalf("Failed to read token file: %v", err)
+	}
+	if strings.Contains(string(data), "secret-access-token") || strings.Contains(string(data), "client-123") {
+		t.Error("Token file contains plaintext credentials")
+	}
+
+	for _, wantDeleted := range []bool{true, false} {
+		deleted, err := Logout("docs")
+		if err != nil {
+			t.Fatalf("Logout() failed: %v", err)
+		}
+		if deleted != wantDeleted {
+			t.Errorf("Logout() = %v, want %v", deleted, wantDeleted)
+		}
+	}
+	if _, err := store.GetToken(); !errors.Is(err, ErrNoToken) {
+		t.Errorf("GetToken() after logout error = %v, want ErrNoToken", err)
+	}
+}
+
+func TestLogin(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	var codeChallenge string
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	mux.HandleFunc("/.well-known/oauth-authorization-server", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 server.URL,
+			"authorization_endpoint": server.URL + "/authorize",
+			"token_endpoint":         server.URL + "/token",
+			"registration_endpoint":  server.URL + "/register",
+		})
+	})
+	mux.HandleFunc("/register", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(map[string]string{"client_id": "registered-client"})
+	})
+	mux.HandleFunc("/authorize", func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		codeChallenge = query.Get("code_challenge")
+		redirect, _ := url.Parse(query.Get("redirect_uri"))
+		redirect.RawQuery = url.Values{"code": {"auth-code"}, "state": {query.Get("state")}}.Encode()
+		http.Redirect(w, r, redirect.String(), http.StatusFound)
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		verifier := sha256.Sum256([]byte(r.Form.Get("code_verifier")))
+		if r.Form.Get("code") != "auth-code" || r.Form.Get("client_id") != "registered-client" ||
+			base64.RawURLEncoding.EncodeToString(verifier[:]) != codeChallenge {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token":  "access-token",
+			"refresh_token": "refresh-token",
+			"token_type":    "Bearer",
+			"expires_in":    3600,
+		})
+	})
+
+	serverConfig := config.MCPServerConfig{
+		Transport: "oauth-http",
+		BaseURL:   server.URL + "/mcp",
+		OAuth: &config.OAuthConfig{
+			AuthServerMetadataURL: server.URL + "/.well-known/oauth-authorization-server",
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// The "browser" follows the authorization redirect to the loopback listener
+	err := Login(ctx, "docs", serverConfig, func(authURL string) {
+		go func() {
+			resp, err := http.Get(authURL)
+			if err == nil {
+				_ = resp.Body.Close()
+			}
+		}()
+	})
+	if err != nil {
+		t.Fatalf("Login() failed: %v", err)
+	}
+
+	store, err := NewFileTokenStore("docs")
+	if err != nil {
+		t.Fatalf("NewFileTokenStore() failed: %v", err)
+	}
+	token, err := store.GetToken()
+	if err != nil {
+		t.Fatalf("GetToken() failed: %v", err)
+	}
+	if token.AccessToken != "access-token" || token.RefreshToken != "refresh-token" {
+		t.Errorf("stored token = %+v", token)
+	}
+
+	// Later connections use the dynamically registered client
+	oauthConfig, _, err := oauthClientConfig("docs", serverConfig)
+	if err != nil {
+		t.Fatalf("oauthClientConfig() failed: %v", err)
+	}
+	if oauthConfig.ClientID != "registered-client" {
+		t.Errorf("ClientID = %q, want registered-client", oauthConfig.ClientID)
+	}
+}
+
+func TestLoginRejectsNonOAuthServer(t *testing.T) {
+	err := Login(context.Background(), "fs", config.MCPServerConfig{Transport: "stdio", Command: "npx"}, func(string) {})
+	if err == nil {
+		t.Fatal("Login() should fail for a stdio server")
+	}
+}
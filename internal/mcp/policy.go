@@ -0,0 +1,77 @@
+package mcp
+
+import (
+	"fmt"
+	"path"
+
+	"k8x/internal/config"
+)
+
+// ToolPolicy decides which tools of an MCP server are exposed to the LLM
+type ToolPolicy struct {
+	// AllowedTools are glob patterns of tool names to expose. Empty allows all.
+	AllowedTools []string
+	// DeniedTools are glob patterns of tool names to hide, even if allowed
+	DeniedTools []string
+	// ReadOnly only exposes tools annotated as read-only
+	ReadOnly bool
+}
+
+// NewToolPolicy builds the tool policy of a server configuration and checks
+// that its glob patterns are valid
+func NewToolPolicy(serverConfig config.MCPServerConfig) (ToolPolicy, error) {
+	policy := ToolPolicy{
+		AllowedTools: serverConfig.AllowedTools,
+		DeniedTools:  serverConfig.DeniedTools,
+		ReadOnly:     serverConfig.ReadOnlyHint,
+	}
+
+	for _, pattern := range append(append([]string{}, policy.AllowedTools...), policy.DeniedTools...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return policy, fmt.Errorf("invalid tool pattern %q: %w", pattern, err)
+		}
+	}
+	return policy, nil
+}
+
+// Allows reports whether a tool may be listed and called
+func (p ToolPolicy) Allows(tool Tool) bool {
+	if matchesAny(p.DeniedTools, tool.Name) {
+		return false
+	}
+	if len(p.AllowedTools) > 0 && !matchesAny(p.AllowedTools, tool.Name) {
+		return false
+	}
+	if p.ReadOnly && !IsReadOnlyTool(tool) {
+		return false
+	}
+	return true
+}
+
+// IsReadOnlyTool reports whether a tool declares that it doesn't modify its
+// environment
+func IsReadOnlyTool(tool Tool) bool {
+	hint := tool.Annotations.ReadOnlyHint
+	return hint != nil && *hint
+}
+
+// IsDestructiveTool reports whether a tool may perform destructive updates.
+// Following the MCP specification, tools that aren't read-only are assumed
+// destructive unless they set destructiveHint to false.
+func IsDestructiveTool(tool Tool) bool {
+	if IsReadOnlyTool(tool) {
+		return false
+	}
+	hint := tool.Annotations.DestructiveHint
+	return hint == nil || *hint
+}
+
+// matchesAny reports whether name matches one of the glob patterns
+func matchesAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,245 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// Resource represents an MCP resource definition (alias to mcp-go type)
+type Resource = mcp.Resource
+
+// Prompt represents an MCP prompt template (alias to mcp-go type)
+type Prompt = mcp.Prompt
+
+// ResourceContent is one item returned when reading a resource. Text
+// resources set Text; binary resources set Blob to base64 encoded data.
+type ResourceContent struct {
+	URI      string `json:"uri"`
+	MIMEType string `json:"mimeType,omitempty"`
+	Text     string `json:"text,omitempty"`
+	Blob     string `json:"blob,omitempty"`
+}
+
+// PromptMessage is a single message of a rendered prompt
+type PromptMessage struct {
+	Role string `json:"role"`
+	Text string `json:"text"`
+}
+
+// PromptResult is a rendered prompt template
+type PromptResult struct {
+	Description string          `json:"description,omitempty"`
+	Messages    []PromptMessage `json:"messages"`
+}
+
+// ListResources returns the resources offered by the MCP server. Servers
+// that don't advertise the resources capability return an empty list.
+func (c *MCPClient) ListResources(ctx context.Context) ([]Resource, error) {
+	cl, err := c.connectedClient()
+	if err != nil {
+		return nil, err
+	}
+	if cl.GetServerCapabilities().Resources == nil {
+		return nil, nil
+	}
+
+	result, err := cl.ListResources(ctx, mcp.ListResourcesRequest{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list resources: %w", err)
+	}
+
+	return result.Resources, nil
+}
+
+// ReadResource reads the contents of a resource by URI
+func (c *MCPClient) ReadResource(ctx context.Context, uri string) ([]ResourceContent, error) {
+	cl, err := c.connectedClient()
+	if err != nil {
+		return nil, err
+	}
+
+	req := mcp.ReadResourceRequest{}
+	req.Params.URI = uri
+
+	result, err := cl.ReadResource(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read resource %s: %w", uri, err)
+	}
+
+	contents := make([]ResourceContent, 0, len(result.Contents))
+	for _, content := range result.Contents {
+		contents = append(contents, convertResourceContents(content))
+	}
+	return contents, nil
+}
+
+// ListPrompts returns the prompt templates offered by the MCP server.
+// Servers that don't advertise the prompts capability return an empty list.
+func (c *MCPClient) ListPrompts(ctx context.Context) ([]Prompt, error) {
+	cl, err := c.connectedClient()
+	if err != nil {
+		return nil, err
+	}
+	if cl.GetServerCapabilities().Prompts == nil {
+		return nil, nil
+	}
+
+	result, err := cl.ListPrompts(ctx, mcp.ListPromptsRequest{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list prompts: %w", err)
+	}
+
+	return result.Prompts, nil
+}
+
+// GetPrompt renders a prompt template with the given arguments
+func (c *MCPClient) GetPrompt(ctx context.Context, name string, args map[string]string) (*PromptResult, error) {
+	cl, err := c.connectedClient()
+	if err != nil {
+		return nil, err
+	}
+
+	req := mcp.GetPromptRequest{}
+	req.Params.Name = name
+	req.Params.Arguments = args
+
+	result, err := cl.GetPrompt(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get prompt %s: %w", name, err)
+	}
+
+	prompt := &PromptResult{Description: result.Description}
+	for _, message := range result.Messages {
+		prompt.Messages = append(prompt.Messages, PromptMessage{
+			Role: string(message.Role),
+			Text: promptContentText(message.Content),
+		})
+	}
+	return prompt, nil
+}
+
+// convertResourceContents converts mcp-go resource contents to our format
+func convertResourceContents(content mcp.ResourceContents) ResourceContent {
+	switch c := content.(type) {
+	case mcp.TextResourceContents:
+		return ResourceContent{URI: c.URI, MIMEType: c.MIMEType, Text: c.Text}
+	case mcp.BlobResourceContents:
+		return ResourceContent{URI: c.URI, MIMEType: c.MIMEType, Blob: c.Blob}
+	default:
+		return ResourceContent{Text: fmt.Sprintf("%v", content)}
+	}
+}
+
+// promptContentText returns the text of a prompt message, describing
+// non-text content instead of including it
+func promptContentText(content mcp.Content) string {
+	switch c := content.(type) {
+	case mcp.TextContent:
+		return c.Text
+	case mcp.EmbeddedResource:
+		resource := convertResourceContents(c.Resource)
+		if resource.Blob != "" {
+			return fmt.Sprintf("[embedded resource %s (%s)]", resource.URI, resource.MIMEType)
+		}
+		return resource.Text
+	case mcp.ImageContent:
+		return fmt.Sprintf("[image content (%s)]", c.MIMEType)
+	case mcp.AudioContent:
+		return fmt.Sprintf("[audio content (%s)]", c.MIMEType)
+	default:
+		return fmt.Sprintf("%v", content)
+	}
+}
+
+// ListResources returns the resources of all connected MCP servers. Resource
+// lists are cached per server; a server whose resources can't be listed is
+// skipped.
+func (m *Manager) ListResources(ctx context.Context) map[string][]Resource {
+	m.mu.RLock()
+	clients := make(map[string]Client, len(m.clients))
+	for name, client := range m.clients {
+		clients[name] = client
+	}
+	m.mu.RUnlock()
+
+	allResources := make(map[string][]Resource)
+	for name, client := range clients {
+		if !client.IsConnected() {
+			continue
+		}
+
+		m.mu.RLock()
+		resources, cached := m.resources[name]
+		m.mu.RUnlock()
+
+		if !cached {
+			var err error
+			resources, err = client.ListResources(ctx)
+			if err != nil {
+				log.Printf("[DEBUG] Failed to list resources from MCP server '%s': %v", name, err)
+				continue
+			}
+			m.mu.Lock()
+			m.resources[name] = resources
+			m.mu.Unlock()
+		}
+
+		if len(resources) > 0 {
+			allResources[name] = resources
+		}
+	}
+
+	return allResources
+}
+
+// ReadResource reads a resource from the specified MCP server
+func (m *Manager) ReadResource(ctx context.Context, serverName, uri string) ([]ResourceContent, error) {
+	client, err := m.connectedClient(serverName)
+	if err != nil {
+		return nil, err
+	}
+
+	return client.ReadResource(ctx, uri)
+}
+
+// ListPrompts returns the prompt templates of all connected MCP servers. A
+// server whose prompts can't be listed is skipped.
+func (m *Manager) ListPrompts(ctx context.Context) map[string][]Prompt {
+	m.mu.RLock()
+	clients := make(map[string]Client, len(m.clients))
+	for name, client := range m.clients {
+		clients[name] = client
+	}
+	m.mu.RUnlock()
+
+	allPrompts := make(map[string][]Prompt)
+	for name, client := range clients {
+		if !client.IsConnected() {
+			continue
+		}
+
+		prompts, err := client.ListPrompts(ctx)
+		if err != nil {
+			log.Printf("[DEBUG] Failed to list prompts from MCP server '%s': %v", name, err)
+			continue
+		}
+		if len(prompts) > 0 {
+			allPrompts[name] = prompts
+		}
+	}
+
+	return allPrompts
+}
+
+// GetPrompt renders a prompt template from the specified MCP server
+func (m *Manager) GetPrompt(ctx context.Context, serverName, name string, args map[string]string) (*PromptResult, error) {
+	client, err := m.connectedClient(serverName)
+	if err != nil {
+		return nil, err
+	}
+
+	return client.GetPrompt(ctx, name, args)
+}
@@ -0,0 +1,207 @@
+package mcp
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"k8x/internal/config"
+
+	"github.com/mark3labs/mcp-go/client/transport"
+)
+
+// tokenKeyFile holds the AES key used to encrypt stored tokens
+const tokenKeyFile = ".key"
+
+// ErrNoToken is returned by FileTokenStore when no token has been stored
+var ErrNoToken = errors.New("no OAuth token stored")
+
+// storedAuth is the encrypted content of a token file. Client credentials
+// obtained through dynamic client registration are kept next to the token
+// since refreshing requires the same client ID.
+type storedAuth struct {
+	ClientID     string           `json:"client_id,omitempty"`
+	ClientSecret string           `json:"client_secret,omitempty"`
+	Token        *transport.Token `json:"token,omitempty"`
+}
+
+// FileTokenStore persists the OAuth token of one MCP server in
+// ~/.k8x/mcp-tokens, encrypted with AES-GCM using a key generated on first
+// use. It implements transport.TokenStore, so refreshed tokens are written
+// back automatically.
+type FileTokenStore struct {
+	mu      sync.Mutex
+	path    string
+	keyPath string
+}
+
+// NewFileTokenStore returns the token store for an MCP server
+func NewFileTokenStore(serverName string) (*FileTokenStore, error) {
+	if serverName == "" {
+		return nil, fmt.Errorf("MCP server name cannot be empty")
+	}
+
+	tokenDir, err := config.GetMCPTokenDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get token directory: %w", err)
+	}
+
+	return &FileTokenStore{
+		path:    filepath.Join(tokenDir, url.PathEscape(serverName)+".token"),
+		keyPath: filepath.Join(tokenDir, tokenKeyFile),
+	}, nil
+}
+
+// GetToken returns the stored token
+func (s *FileTokenStore) GetToken() (*transport.Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	auth, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	if auth.Token == nil {
+		return nil, ErrNoToken
+	}
+	return auth.Token, nil
+}
+
+// SaveToken stores a token, keeping any saved client credentials
+func (s *FileTokenStore) SaveToken(token *transport.Token) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	auth, err := s.load()
+	if err != nil && !errors.Is(err, ErrNoToken) {
+		return err
+	}
+	auth.Token = token
+	return s.save(auth)
+}
+
+// ClientCredentials returns client credentials saved by SaveClientCredentials
+func (s *FileTokenStore) ClientCredentials() (clientID, clientSecret string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	auth, err := s.load()
+	if err != nil {
+		return "", ""
+	}
+	return auth.ClientID, auth.ClientSecret
+}
+
+// SaveClientCredentials stores dynamically registered client credentials.
+// Any existing token belongs to the previous client and is dropped.
+func (s *FileTokenStore) SaveClientCredentials(clientID, clientSecret string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.save(storedAuth{ClientID: clientID, ClientSecret: clientSecret})
+}
+
+// Delete removes the stored token and client credentials. It reports
+// whether anything was stored.
+func (s *FileTokenStore) Delete() (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.Remove(s.path); err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to delete token file: %w", err)
+	}
+	return true, nil
+}
+
+// load reads and decrypts the token file
+func (s *FileTokenStore) load() (storedAuth, error) {
+	var auth storedAuth
+
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return auth, ErrNoToken
+		}
+		return auth, fmt.Errorf("failed to read token file: %w", err)
+	}
+
+	gcm, err := s.cipher(false)
+	if err != nil {
+		return auth, err
+	}
+	if len(data) < gcm.NonceSize() {
+		return auth, fmt.Errorf("token file %s is corrupted", s.path)
+	}
+
+	nonce, ciphertext := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return auth, fmt.Errorf("failed to decrypt token file %s: %w", s.path, err)
+	}
+
+	if err := json.Unmarshal(plaintext, &auth); err != nil {
+		return auth, fmt.Errorf("failed to parse token file: %w", err)
+	}
+	return auth, nil
+}
+
+// save encrypts and writes the token file
+func (s *FileTokenStore) save(auth storedAuth) error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return fmt.Errorf("failed to create token directory: %w", err)
+	}
+
+	plaintext, err := json.Marshal(auth)
+	if err != nil {
+		return fmt.Errorf("failed to encode token: %w", err)
+	}
+
+	gcm, err := s.cipher(true)
+	if err != nil {
+		return err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	if err := os.WriteFile(s.path, gcm.Seal(nonce, nonce, plaintext, nil), 0600); err != nil {
+		return fmt.Errorf("failed to write token file: %w", err)
+	}
+	return nil
+}
+
+// cipher returns the AES-GCM cipher for the token directory, generating the
+// key if create is set and no key exists yet
+func (s *FileTokenStore) cipher(create bool) (cipher.AEAD, error) {
+	key, err := os.ReadFile(s.keyPath)
+	if os.IsNotExist(err) && create {
+		key = make([]byte, 32)
+		if _, err := io.ReadFull(rand.Reader, key); err != nil {
+			return nil, fmt.Errorf("failed to generate token key: %w", err)
+		}
+		if err := os.WriteFile(s.keyPath, key, 0600); err != nil {
+			return nil, fmt.Errorf("failed to write token key: %w", err)
+		}
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read token key: %w", err)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid token key %s: %w", s.keyPath, err)
+	}
+	return cipher.NewGCM(block)
+}
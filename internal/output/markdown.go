@@ -0,0 +1,192 @@
+package output
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/fatih/color"
+)
+
+var (
+	headingColor = color.New(color.FgCyan, color.Bold)
+	boldColor    = color.New(color.Bold)
+	italicColor  = color.New(color.Italic)
+	codeColor    = color.New(color.FgMagenta)
+	quoteColor   = color.New(color.FgHiBlack)
+
+	headingPattern   = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	bulletPattern    = regexp.MustCompile(`^(\s*)[-*+]\s+(.*)$`)
+	numberedPattern  = regexp.MustCompile(`^(\s*)(\d+[.)])\s+(.*)$`)
+	rulePattern      = regexp.MustCompile(`^\s*([-*_])(\s*([-*_]))+\s*$`)
+	tableSeparator   = regexp.MustCompile(`^\s*\|?\s*:?-+:?\s*(\|\s*:?-+:?\s*)*\|?\s*$`)
+	inlineCode       = regexp.MustCompile("`([^`]+)`")
+	boldPattern      = regexp.MustCompile(`\*\*([^*]+)\*\*|__([^_]+)__`)
+	italicPattern    = regexp.MustCompile(`(^|[^*\w])\*([^*\s][^*]*)\*`)
+	linkPattern      = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)\)`)
+	ansiPattern      = regexp.MustCompile("\x1b\\[[0-9;]*m")
+	fencePattern     = regexp.MustCompile("^\\s*(```|~~~)")
+	blockquotePrefix = regexp.MustCompile(`^\s*>\s?`)
+)
+
+// RenderMarkdown renders Markdown for the terminal: headings, bullet and
+// numbered lists, fenced code blocks, tables, block quotes, rules and
+// inline bold, italic, code and links. If styled is false, the markup is
+// removed instead, for plain text output.
+func RenderMarkdown(text string, styled bool) string {
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+
+	var out []string
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+
+		switch {
+		case fencePattern.MatchString(line):
+			fence := fencePattern.FindStringSubmatch(line)[1]
+			for i++; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), fence); i++ {
+				out = append(out, "    "+sprint(codeColor, styled, lines[i]))
+			}
+		case isTableRow(line) && i+1 < len(lines) && tableSeparator.MatchString(lines[i+1]):
+			rows := [][]string{tableCells(line)}
+			for i += 2; i < len(lines) && isTableRow(lines[i]); i++ {
+				rows = append(rows, tableCells(lines[i]))
+			}
+			i--
+			out = append(out, renderTable(rows, styled)...)
+		case headingPattern.MatchString(line):
+			match := headingPattern.FindStringSubmatch(line)
+			heading := renderInline(match[2], false)
+			if styled {
+				heading = headingColor.Sprint(heading)
+			} else if len(match[1]) <= 2 {
+				heading = strings.ToUpper(heading)
+			}
+			out = append(out, heading)
+		case rulePattern.MatchString(line):
+			if styled {
+				out = append(out, quoteColor.Sprint(strings.Repeat("─", 40)))
+			} else {
+				out = append(out, strings.Repeat("-", 40))
+			}
+		case blockquotePrefix.MatchString(line):
+			quote := renderInline(blockquotePrefix.ReplaceAllString(line, ""), styled)
+			if styled {
+				out = append(out, quoteColor.Sprint("│ ")+quote)
+			} else {
+				out = append(out, "> "+quote)
+			}
+		case bulletPattern.MatchString(line):
+			match := bulletPattern.FindStringSubmatch(line)
+			bullet := "-"
+			if styled {
+				bullet = "•"
+			}
+			out = append(out, match[1]+"  "+bullet+" "+renderInline(match[2], styled))
+		case numberedPattern.MatchString(line):
+			match := numberedPattern.FindStringSubmatch(line)
+			out = append(out, match[1]+"  "+match[2]+" "+renderInline(match[3], styled))
+		default:
+			out = append(out, renderInline(line, styled))
+		}
+	}
+	return strings.Join(out, "\n")
+}
+
+// renderInline renders bold, italic, inline code and links within a line
+func renderInline(line string, styled bool) string {
+	// Keep inline code from being styled further
+	var codes []string
+	line = inlineCode.ReplaceAllStringFunc(line, func(match string) string {
+		codes = append(codes, inlineCode.FindStringSubmatch(match)[1])
+		return "\x00" + strconv.Itoa(len(codes)-1) + "\x00"
+	})
+
+	line = linkPattern.ReplaceAllString(line, "$1 ($2)")
+	line = boldPattern.ReplaceAllStringFunc(line, func(match string) string {
+		groups := boldPattern.FindStringSubmatch(match)
+		return sprint(boldColor, styled, groups[1]+groups[2])
+	})
+	line = italicPattern.ReplaceAllStringFunc(line, func(match string) string {
+		groups := italicPattern.FindStringSubmatch(match)
+		return groups[1] + sprint(italicColor, styled, groups[2])
+	})
+
+	for i, code := range codes {
+		line = strings.Replace(line, "\x00"+strconv.Itoa(i)+"\x00", sprint(codeColor, styled, code), 1)
+	}
+	return line
+}
+
+// sprint styles text with c if styled is set
+func sprint(c *color.Color, styled bool, text string) string {
+	if !styled {
+		return text
+	}
+	return c.Sprint(text)
+}
+
+// isTableRow reports whether a line is a Markdown table row
+func isTableRow(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	return strings.HasPrefix(trimmed, "|") && strings.Count(trimmed, "|") >= 2
+}
+
+// tableCells splits a table row into its cells
+func tableCells(line string) []string {
+	trimmed := strings.Trim(strings.TrimSpace(line), "|")
+	cells := strings.Split(trimmed, "|")
+	for i, cell := range cells {
+		cells[i] = strings.TrimSpace(cell)
+	}
+	return cells
+}
+
+// renderTable aligns table rows into columns, the first row being the header
+func renderTable(rows [][]string, styled bool) []string {
+	var widths []int
+	rendered := make([][]string, len(rows))
+	for r, row := range rows {
+		rendered[r] = make([]string, len(row))
+		for c, cell := range row {
+			rendered[r][c] = renderInline(cell, styled)
+			width := visibleWidth(rendered[r][c])
+			if c >= len(widths) {
+				widths = append(widths, width)
+			} else if width > widths[c] {
+				widths[c] = width
+			}
+		}
+	}
+
+	var out []string
+	for r, row := range rendered {
+		cells := make([]string, len(widths))
+		for c := range widths {
+			var cell string
+			if c < len(row) {
+				cell = row[c]
+			}
+			padded := cell + strings.Repeat(" ", widths[c]-visibleWidth(cell))
+			if r == 0 {
+				padded = sprint(boldColor, styled, padded)
+			}
+			cells[c] = padded
+		}
+		out = append(out, strings.TrimRight(strings.Join(cells, "  "), " "))
+
+		if r == 0 {
+			rules := make([]string, len(widths))
+			for c, width := range widths {
+				rules[c] = strings.Repeat("-", width)
+			}
+			out = append(out, strings.Join(rules, "  "))
+		}
+	}
+	return out
+}
+
+// visibleWidth is the number of characters of text without color codes
+func visibleWidth(text string) int {
+	return utf8.RuneCountInString(ansiPattern.ReplaceAllString(text, ""))
+}
@@ -0,0 +1,84 @@
+package output
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+func TestRenderMarkdownPlain(t *testing.T) {
+	tests := []struct {
+		name     string
+		markdown string
+		want     string
+	}{
+		{
+			name:     "headings",
+			markdown: "# Diagnosis\n### Next steps",
+			want:     "DIAGNOSIS\nNext steps",
+		},
+		{
+			name:     "lists and inline markup",
+			markdown: "- pod **api-0** is `CrashLoopBackOff`\n  * see [docs](https://k8s.io)\n2. check *logs*",
+			want:     "  - pod api-0 is CrashLoopBackOff\n    - see docs (https://k8s.io)\n  2. check logs",
+		},
+		{
+			name:     "code block",
+			markdown: "Run:\n```bash\nkubectl get pods **-A**\n```\ndone",
+			want:     "Run:\n    kubectl get pods **-A**\ndone",
+		},
+		{
+			name:     "table",
+			markdown: "| Pod | Restarts |\n|-----|---:|\n| api-0 | 12 |\n| web | 0 |",
+			want:     "Pod    Restarts\n-----  --------\napi-0  12\nweb    0",
+		},
+		{
+			name:     "quote and rule",
+			markdown: "> warning\n---",
+			want:     "> warning\n" + strings.Repeat("-", 40),
+		},
+		{
+			name:     "globs and snake_case stay",
+			markdown: "allow mcp_fs_* and kubectl get *",
+			want:     "allow mcp_fs_* and kubectl get *",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RenderMarkdown(tt.markdown, false); got != tt.want {
+				t.Errorf("RenderMarkdown() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderMarkdownStyledTable(t *testing.T) {
+	noColor := color.NoColor
+	color.NoColor = false
+	defer func() { color.NoColor = noColor }()
+
+	got := RenderMarkdown("| Pod | Node |\n|---|---|\n| **api-0** | a |", true)
+	if !strings.Contains(got, "\x1b[") {
+		t.Fatalf("RenderMarkdown() = %q, want color codes", got)
+	}
+
+	// Color codes don't count towards column widths
+	want := "Pod    Node\n-----  ----\napi-0  a"
+	if plain := ansiPattern.ReplaceAllString(got, ""); plain != want {
+		t.Errorf("RenderMarkdown() without colors =\n%s\nwant\n%s", plain, want)
+	}
+}
+
+func TestFoldOutput(t *testing.T) {
+	text := strings.TrimSuffix(strings.Repeat("line\n", 30), "\n")
+
+	shown, hidden := foldOutput(text, 25)
+	if hidden != 5 || strings.Count(shown, "\n") != 24 {
+		t.Errorf("foldOutput() hid %d lines and showed %d, want 5 and 25", hidden, strings.Count(shown, "\n")+1)
+	}
+	if shown, hidden := foldOutput("short", 25); shown != "short" || hidden != 0 {
+		t.Errorf("foldOutput() folded short output: %q, %d", shown, hidden)
+	}
+}
@@ -0,0 +1,44 @@
+package output
+
+import (
+	"os"
+
+	"github.com/fatih/color"
+	"golang.org/x/term"
+)
+
+// SetupTerminal decides how output is rendered. Colors and styled Markdown
+// are disabled if noColor is set (--no-color), NO_COLOR is set, TERM is
+// dumb or standard output isn't a terminal, e.g. when piped to a file.
+func SetupTerminal(noColor bool) {
+	if noColor || os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" || !StdoutIsTerminal() {
+		color.NoColor = true
+	}
+}
+
+// StdoutIsTerminal reports whether standard output is a terminal
+func StdoutIsTerminal() bool {
+	return term.IsTerminal(int(os.Stdout.Fd()))
+}
+
+// StdinIsTerminal reports whether standard input is a terminal, rather
+// than piped input or a file
+func StdinIsTerminal() bool {
+	return term.IsTerminal(int(os.Stdin.Fd()))
+}
+
+// StdinIsPiped reports whether standard input is a pipe or a regular file,
+// such as input redirected from a file. Other inputs, e.g. a socket or a
+// device inherited from a wrapper, may never be closed and aren't read.
+func StdinIsPiped() bool {
+	info, err := os.Stdin.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeNamedPipe != 0 || info.Mode().IsRegular()
+}
+
+// ColorEnabled reports whether output is styled with colors
+func ColorEnabled() bool {
+	return !color.NoColor
+}
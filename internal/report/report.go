@@ -0,0 +1,332 @@
+// Package report generates root cause analysis documents from k8x sessions
+package report
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"k8x/internal/history"
+	"k8x/internal/llm"
+)
+
+// maxOutputChars is how much of each step's output the LLM is shown
+const maxOutputChars = 3000
+
+// Confidence is how sure the analysis is of the probable cause
+type Confidence string
+
+const (
+	// ConfidenceHigh means the evidence directly shows the cause
+	ConfidenceHigh Confidence = "high"
+	// ConfidenceMedium means the evidence is consistent with the cause
+	ConfidenceMedium Confidence = "medium"
+	// ConfidenceLow means the cause is a guess that needs confirming
+	ConfidenceLow Confidence = "low"
+)
+
+// Report is a root cause analysis of an investigated incident
+type Report struct {
+	Title               string            `json:"title"`
+	Goals               []string          `json:"goals"`
+	Date                time.Time         `json:"date"`
+	Environment         map[string]string `json:"environment,omitempty"`
+	Summary             string            `json:"summary"`
+	Symptoms            []string          `json:"symptoms"`
+	Evidence            []Evidence        `json:"evidence"`
+	ClusterComparison   []ClusterFindings `json:"cluster_comparison,omitempty"`
+	ProbableCause       string            `json:"probable_cause"`
+	Confidence          Confidence        `json:"confidence"`
+	ConfidenceRationale string            `json:"confidence_rationale,omitempty"`
+	Remediation         []string          `json:"remediation"`
+	FollowUpChecks      []string          `json:"follow_up_checks"`
+}
+
+// Evidence is a finding backed by steps of the session
+type Evidence struct {
+	Finding string `json:"finding"`
+	// Steps are the numbers of the transcript steps showing the finding
+	Steps []int `json:"steps,omitempty"`
+	// Commands are the commands those steps ran
+	Commands []string `json:"commands,omitempty"`
+}
+
+// ClusterFindings are the findings of a multi-cluster session on one cluster
+type ClusterFindings struct {
+	Cluster  string `json:"cluster"`
+	Findings string `json:"findings"`
+}
+
+// analysisPrompt asks the LLM for the report's fields as JSON
+const analysisPrompt = `You are an SRE writing a root cause analysis of a Kubernetes incident from
+the transcript of an investigation. Base every statement on the transcript;
+don't invent resources, errors or numbers that aren't in it.
+
+Respond with only a JSON object, without Markdown fences, with these fields:
+{
+  "summary": "two or three sentences on what happened",
+  "symptoms": ["what was observed to be wrong"],
+  "evidence": [{"finding": "what a step showed", "steps": [2, 3]}],
+  "probable_cause": "the most likely root cause",
+  "confidence": "high, medium or low",
+  "confidence_rationale": "why the confidence is what it is",
+  "remediation": ["suggested fix, most important first"],
+  "follow_up_checks": ["check confirming the cause or the fix"]
+}
+
+"steps" are the numbers of the transcript steps the finding is based on.
+Use "high" confidence only if the evidence directly shows the cause, and
+"low" if the cause is a guess. Remediation may include commands that change
+the cluster, but say what they change.`
+
+// clusterComparisonPrompt asks for the comparison of a multi-cluster session
+const clusterComparisonPrompt = `
+
+The session spanned the kube-contexts %s. Add a "cluster_comparison" field
+comparing them: [{"cluster": "prod", "findings": "what the session showed
+on this cluster"}], one entry per cluster. Say in the summary what differs
+between the clusters and which of them show the problem.`
+
+// sessionClusters returns the kube-contexts of a multi-cluster session from
+// its environment, nil for a single cluster
+func sessionClusters(environment map[string]string) []string {
+	var clusters []string
+	for _, cluster := range strings.Split(environment["contexts"], ",") {
+		if cluster = strings.TrimSpace(cluster); cluster != "" {
+			clusters = append(clusters, cluster)
+		}
+	}
+	if len(clusters) < 2 {
+		return nil
+	}
+	return clusters
+}
+
+// Generate asks provider for a root cause analysis of the session in transcript
+func Generate(ctx context.Context, provider llm.Provider, transcript *history.Transcript) (*Report, error) {
+	if len(transcript.Steps) == 0 {
+		return nil, fmt.Errorf("the session has no steps to analyze")
+	}
+
+	prompt := analysisPrompt
+	if clusters := sessionClusters(transcript.Environment); clusters != nil {
+		prompt += fmt.Sprintf(clusterComparisonPrompt, strings.Join(clusters, ", "))
+	}
+
+	response, err := provider.Chat(ctx, []llm.Message{
+		{Role: "system", Content: prompt},
+		{Role: "user", Content: describeTranscript(transcript)},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get analysis from LLM: %w", err)
+	}
+
+	report, err := parseReport(response.Content)
+	if err != nil {
+		return nil, err
+	}
+	report.complete(transcript)
+	return report, nil
+}
+
+// describeTranscript presents the session to the LLM, with long outputs cut
+func describeTranscript(transcript *history.Transcript) string {
+	var b strings.Builder
+	for _, goal := range transcript.Goals {
+		fmt.Fprintf(&b, "Goal: %s\n", goal)
+	}
+	keys := make([]string, 0, len(transcript.Environment))
+	for key := range transcript.Environment {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Fprintf(&b, "%s: %s\n", key, transcript.Environment[key])
+	}
+
+	for _, step := range transcript.Steps {
+		fmt.Fprintf(&b, "\n## Step %d", step.Number)
+		if step.Tool != "" {
+			fmt.Fprintf(&b, " (%s)", step.Tool)
+		}
+		if step.Context != "" {
+			fmt.Fprintf(&b, " in context %s", step.Context)
+		}
+		b.WriteString("\n")
+		if step.Explanation != "" {
+			fmt.Fprintf(&b, "Reasoning: %s\n", step.Explanation)
+		}
+		if step.Command != "" {
+			fmt.Fprintf(&b, "Command: %s\n", step.Command)
+		}
+		if step.Output != "" {
+			output := step.Output
+			if len(output) > maxOutputChars {
+				output = output[:maxOutputChars] + "\n... (output truncated)"
+			}
+			fmt.Fprintf(&b, "Output:\n%s\n", output)
+		}
+	}
+
+	if transcript.Summary != "" || len(transcript.Findings) > 0 {
+		fmt.Fprintf(&b, "\n## Final answer\n%s\n", transcript.Summary)
+		for _, finding := range transcript.Findings {
+			fmt.Fprintf(&b, "- %s\n", finding)
+		}
+	}
+	return b.String()
+}
+
+// parseReport reads the report fields from the LLM's answer, which may be
+// wrapped in a code fence or surrounded by text
+func parseReport(content string) (*Report, error) {
+	start, end := strings.Index(content, "{"), strings.LastIndex(content, "}")
+	if start == -1 || end < start {
+		return nil, fmt.Errorf("the LLM's analysis isn't JSON: %s", strings.TrimSpace(content))
+	}
+
+	var report Report
+	if err := json.Unmarshal([]byte(content[start:end+1]), &report); err != nil {
+		return nil, fmt.Errorf("failed to parse the LLM's analysis: %w", err)
+	}
+	return &report, nil
+}
+
+// complete fills in the report's details from the transcript and cleans up
+// the LLM's answer: unknown confidences become low, evidence refers only to
+// steps that exist, and only multi-cluster sessions compare clusters
+func (r *Report) complete(transcript *history.Transcript) {
+	r.Goals = transcript.Goals
+	r.Date = transcript.Date
+	r.Environment = transcript.Environment
+	r.Title = "Incident report"
+	if len(r.Goals) > 0 {
+		r.Title += ": " + r.Goals[0]
+	}
+
+	switch confidence := Confidence(strings.ToLower(strings.TrimSpace(string(r.Confidence)))); confidence {
+	case ConfidenceHigh, ConfidenceMedium:
+		r.Confidence = confidence
+	default:
+		r.Confidence = ConfidenceLow
+	}
+
+	for i := range r.Evidence {
+		evidence := &r.Evidence[i]
+		var steps []int
+		evidence.Commands = nil
+		for _, number := range evidence.Steps {
+			if number < 1 || number > len(transcript.Steps) {
+				continue
+			}
+			steps = append(steps, number)
+			if command := transcript.Steps[number-1].Command; command != "" {
+				evidence.Commands = append(evidence.Commands, command)
+			}
+		}
+		evidence.Steps = steps
+	}
+
+	if sessionClusters(transcript.Environment) == nil {
+		r.ClusterComparison = nil
+	}
+}
+
+// Render renders the report as Markdown or JSON
+func (r *Report) Render(format history.ExportFormat) ([]byte, error) {
+	switch format {
+	case history.ExportMarkdown:
+		return []byte(r.markdown()), nil
+	case history.ExportJSON:
+		data, err := json.MarshalIndent(r, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode report: %w", err)
+		}
+		return append(data, '\n'), nil
+	default:
+		return nil, fmt.Errorf("reports can be rendered as md or json, not %s", format)
+	}
+}
+
+// markdown renders the report as Markdown
+func (r *Report) markdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", r.Title)
+	if !r.Date.IsZero() {
+		fmt.Fprintf(&b, "**Date:** %s\n\n", r.Date.Format("2006-01-02 15:04 MST"))
+	}
+	if len(r.Environment) > 0 {
+		keys := make([]string, 0, len(r.Environment))
+		for key := range r.Environment {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			fmt.Fprintf(&b, "- **%s:** %s\n", key, r.Environment[key])
+		}
+		b.WriteString("\n")
+	}
+
+	if r.Summary != "" {
+		fmt.Fprintf(&b, "## Summary\n\n%s\n\n", r.Summary)
+	}
+	writeList(&b, "Symptoms", r.Symptoms, "- ")
+
+	if len(r.Evidence) > 0 {
+		b.WriteString("## Evidence\n\n")
+		for _, evidence := range r.Evidence {
+			fmt.Fprintf(&b, "- %s", evidence.Finding)
+			if len(evidence.Steps) > 0 {
+				steps := make([]string, len(evidence.Steps))
+				for i, number := range evidence.Steps {
+					steps[i] = fmt.Sprint(number)
+				}
+				fmt.Fprintf(&b, " (step %s)", strings.Join(steps, ", "))
+			}
+			b.WriteString("\n")
+			for _, command := range evidence.Commands {
+				fmt.Fprintf(&b, "  - `%s`\n", strings.ReplaceAll(command, "`", "'"))
+			}
+		}
+		b.WriteString("\n")
+	}
+
+	if len(r.ClusterComparison) > 0 {
+		b.WriteString("## Cross-cluster Comparison\n\n")
+		for _, cluster := range r.ClusterComparison {
+			fmt.Fprintf(&b, "- **%s:** %s\n", cluster.Cluster, cluster.Findings)
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("## Probable Cause\n\n")
+	if r.ProbableCause != "" {
+		b.WriteString(r.ProbableCause + "\n\n")
+	} else {
+		b.WriteString("Not determined.\n\n")
+	}
+	fmt.Fprintf(&b, "**Confidence:** %s", r.Confidence)
+	if r.ConfidenceRationale != "" {
+		fmt.Fprintf(&b, " — %s", r.ConfidenceRationale)
+	}
+	b.WriteString("\n\n")
+
+	writeList(&b, "Remediation", r.Remediation, "1. ")
+	writeList(&b, "Follow-up Checks", r.FollowUpChecks, "- [ ] ")
+	return strings.TrimRight(b.String(), "\n") + "\n"
+}
+
+// writeList writes a section listing items, each starting with marker
+func writeList(b *strings.Builder, title string, items []string, marker string) {
+	if len(items) == 0 {
+		return
+	}
+	fmt.Fprintf(b, "## %s\n\n", title)
+	for _, item := range items {
+		fmt.Fprintf(b, "%s%s\n", marker, item)
+	}
+	b.WriteString("\n")
+}
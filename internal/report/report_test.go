@@ -0,0 +1,162 @@
+package report
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"k8x/internal/history"
+	"k8x/internal/llm"
+)
+
+// fakeProvider is an LLM provider answering with fixed content
+type fakeProvider struct {
+	content  string
+	err      error
+	messages []llm.Message
+}
+
+func (p *fakeProvider) Name() string       { return "fake" }
+func (p *fakeProvider) IsConfigured() bool { return true }
+
+func (p *fakeProvider) Chat(ctx context.Context, messages []llm.Message) (*llm.Response, error) {
+	p.messages = messages
+	return &llm.Response{Content: p.content}, p.err
+}
+
+func (p *fakeProvider) Stream(ctx context.Context, messages []llm.Message) (io.ReadCloser, error) {
+	return nil, errors.New("not supported")
+}
+
+func testTranscript() *history.Transcript {
+	transcript := &history.Transcript{
+		Goals:       []string{"why is api failing?"},
+		Environment: map[string]string{"context": "prod"},
+	}
+	transcript.AddStep(history.TranscriptStep{Tool: "execute_shell_command", Output: "api-1 0/1 CrashLoopBackOff"}, `{"command":"kubectl get pods -n shop"}`)
+	transcript.AddStep(history.TranscriptStep{Tool: "execute_shell_command", Output: strings.Repeat("OOMKilled: out of memory\n", maxOutputChars/20)}, `{"command":"kubectl logs api-1 -n shop"}`)
+	return transcript
+}
+
+func TestGenerate(t *testing.T) {
+	provider := &fakeProvider{content: "Here is the analysis:\n```json\n" + `{
+  "summary": "The api pod crash loops.",
+  "symptoms": ["api-1 restarts"],
+  "evidence": [{"finding": "api-1 is in CrashLoopBackOff", "steps": [1, 7]}, {"finding": "OOM in logs", "steps": [2]}],
+  "probable_cause": "The memory limit is too low.",
+  "confidence": " Medium",
+  "remediation": ["Raise the memory limit"],
+  "follow_up_checks": ["Watch restarts for an hour"]
+}` + "\n```"}
+
+	rca, err := Generate(context.Background(), provider, testTranscript())
+	if err != nil {
+		t.Fatalf("Generate() failed: %v", err)
+	}
+
+	prompt := provider.messages[1].Content
+	if !strings.Contains(prompt, "Command: kubectl get pods -n shop") || !strings.Contains(prompt, "(output truncated)") {
+		t.Errorf("the transcript sent to the LLM = %q", prompt)
+	}
+	if rca.Title != "Incident report: why is api failing?" || rca.Confidence != ConfidenceMedium {
+		t.Errorf("title = %q, confidence = %q", rca.Title, rca.Confidence)
+	}
+	want := []Evidence{
+		{Finding: "api-1 is in CrashLoopBackOff", Steps: []int{1}, Commands: []string{"kubectl get pods -n shop"}},
+		{Finding: "OOM in logs", Steps: []int{2}, Commands: []string{"kubectl logs api-1 -n shop"}},
+	}
+	if !reflect.DeepEqual(rca.Evidence, want) {
+		t.Errorf("evidence = %+v, want %+v", rca.Evidence, want)
+	}
+}
+
+func TestGenerateComparesClusters(t *testing.T) {
+	answer := `{"summary": "Only prod crash loops.", "cluster_comparison": [{"cluster": "prod", "findings": "api-1 OOMKilled"}, {"cluster": "staging", "findings": "healthy"}], "confidence": "high"}`
+
+	single := &fakeProvider{content: answer}
+	rca, err := Generate(context.Background(), single, testTranscript())
+	if err != nil {
+		t.Fatalf("Generate() failed: %v", err)
+	}
+	if strings.Contains(single.messages[0].Content, "cluster_comparison") || rca.ClusterComparison != nil {
+		t.Errorf("a single-cluster report compares clusters: %+v", rca.ClusterComparison)
+	}
+
+	transcript := testTranscript()
+	transcript.Environment = map[string]string{"contexts": "prod, staging"}
+	multi := &fakeProvider{content: answer}
+	rca, err = Generate(context.Background(), multi, transcript)
+	if err != nil {
+		t.Fatalf("Generate() failed: %v", err)
+	}
+	if !strings.Contains(multi.messages[0].Content, "kube-contexts prod, staging") {
+		t.Errorf("the prompt doesn't ask for a comparison: %q", multi.messages[0].Content)
+	}
+	data, err := rca.Render(history.ExportMarkdown)
+	if err != nil {
+		t.Fatalf("Render() failed: %v", err)
+	}
+	if !strings.Contains(string(data), "## Cross-cluster Comparison\n\n- **prod:** api-1 OOMKilled\n- **staging:** healthy") {
+		t.Errorf("Render() = %s", data)
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		provider   *fakeProvider
+		transcript *history.Transcript
+	}{
+		{"no steps", &fakeProvider{content: "{}"}, &history.Transcript{}},
+		{"LLM error", &fakeProvider{err: errors.New("rate limited")}, testTranscript()},
+		{"not JSON", &fakeProvider{content: "I can't tell"}, testTranscript()},
+		{"invalid JSON", &fakeProvider{content: `{"symptoms": "one"}`}, testTranscript()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Generate(context.Background(), tt.provider, tt.transcript); err == nil {
+				t.Error("Generate() succeeded")
+			}
+		})
+	}
+}
+
+func TestRender(t *testing.T) {
+	rca := &Report{
+		Title:          "Incident report: why is api failing?",
+		Symptoms:       []string{"api-1 restarts"},
+		Evidence:       []Evidence{{Finding: "OOM in logs", Steps: []int{2}, Commands: []string{"kubectl logs api-1"}}},
+		ProbableCause:  "The memory limit is too low.",
+		Confidence:     ConfidenceHigh,
+		Remediation:    []string{"Raise the memory limit"},
+		FollowUpChecks: []string{"Watch restarts"},
+	}
+
+	markdown, err := rca.Render(history.ExportMarkdown)
+	if err != nil {
+		t.Fatalf("Render(md) failed: %v", err)
+	}
+	for _, want := range []string{"## Symptoms", "- OOM in logs (step 2)\n  - `kubectl logs api-1`", "**Confidence:** high", "1. Raise the memory limit", "- [ ] Watch restarts"} {
+		if !strings.Contains(string(markdown), want) {
+			t.Errorf("Markdown report misses %q:\n%s", want, markdown)
+		}
+	}
+
+	data, err := rca.Render(history.ExportJSON)
+	if err != nil {
+		t.Fatalf("Render(json) failed: %v", err)
+	}
+	var decoded Report
+	if err := json.Unmarshal(data, &decoded); err != nil || !reflect.DeepEqual(decoded.Evidence, rca.Evidence) {
+		t.Errorf("JSON report = %s, %v", data, err)
+	}
+
+	if _, err := rca.Render(history.ExportHTML); err == nil {
+		t.Error("Render(html) succeeded")
+	}
+}